@@ -119,6 +119,7 @@ func newAdapter() (adapter *Adapter) {
 	// Build REST client.
 	client := &Client{
 		baseURL: Settings.Addon.Hub.URL,
+		token:   secret.Hub.Token,
 		http:    &http.Client{},
 	}
 	//
@@ -41,3 +41,18 @@ func (h *Application) Update(r *api.Application) (err error) {
 	}
 	return
 }
+
+//
+// Patch an application by ID.
+// The patch is a JSON merge patch (RFC 7386).
+func (h *Application) Patch(id uint, patch interface{}) (err error) {
+	path := Params{api.ID: id}.inject(api.ApplicationRoot)
+	err = h.client.Patch(path, patch)
+	if err == nil {
+		Log.Info(
+			"Addon patched: application.",
+			"id",
+			id)
+	}
+	return
+}
@@ -3,8 +3,13 @@ package addon
 import (
 	"errors"
 	"github.com/konveyor/tackle2-hub/api"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"io"
+	"math"
+	"net/url"
 	"os"
 	pathlib "path"
+	"strings"
 )
 
 //
@@ -53,7 +58,7 @@ func (h *Bucket) Ensure(appId uint, name string) (r *api.Bucket, err error) {
 // Get a bucket by ID.
 func (h *Bucket) Get(id uint) (r *api.Bucket, err error) {
 	r = &api.Bucket{}
-	path := Params{api.ID: id}.inject(api.BucketsRoot)
+	path := Params{api.ID: id}.inject(api.BucketRoot)
 	err = h.client.Get(path, r)
 	return
 }
@@ -69,7 +74,7 @@ func (h *Bucket) List() (list []api.Bucket, err error) {
 //
 // Delete an bucket.
 func (h *Bucket) Delete(r *api.Bucket) (err error) {
-	path := Params{api.ID: r.ID}.inject(api.BucketsRoot)
+	path := Params{api.ID: r.ID}.inject(api.BucketRoot)
 	err = h.client.Delete(path)
 	if err == nil {
 		Log.Info(
@@ -82,17 +87,148 @@ func (h *Bucket) Delete(r *api.Bucket) (err error) {
 
 //
 // Purge bucket.
+// The bucket content is deleted.
 func (h *Bucket) Purge(r *api.Bucket) (err error) {
-	dir, err := os.ReadDir(r.Path)
+	err = h.DeleteContent(r.ID, "")
+	return
+}
+
+//
+// Snapshot the bucket content.
+// Creates an (immutable) version of the bucket.
+func (h *Bucket) Snapshot(id uint) (r *api.BucketVersion, err error) {
+	r = &api.BucketVersion{}
+	path := Params{api.ID: id}.inject(api.BucketVersionsRoot)
+	err = h.client.Post(path, r)
+	if err == nil {
+		Log.Info(
+			"Addon created: bucket version.",
+			"object",
+			r)
+	}
+	return
+}
+
+//
+// PutContent uploads the (local) file or directory to the
+// path within the bucket. Directories are uploaded as an
+// archive and extracted by the hub.
+func (h *Bucket) PutContent(id uint, source, destination string) (err error) {
+	path, err := h.contentPath(id, destination)
 	if err != nil {
 		return
 	}
-	for _, p := range dir {
-		path := pathlib.Join(r.Path, p.Name())
-		err = os.RemoveAll(path)
+	st, err := os.Stat(source)
+	if err != nil {
+		return
+	}
+	if st.IsDir() {
+		reader, writer := io.Pipe()
+		go func() {
+			aErr := (&bucket.FSStore{}).Archive(source, "", bucket.TarGz, writer)
+			_ = writer.CloseWithError(aErr)
+		}()
+		err = h.client.Upload(path, reader, bucket.MIMEGzip)
+		_ = reader.Close()
+	} else {
+		var file *os.File
+		file, err = os.Open(source)
 		if err != nil {
 			return
 		}
+		defer func() {
+			_ = file.Close()
+		}()
+		err = h.client.Upload(path, file, "application/octet-stream")
+	}
+	if err == nil {
+		Log.Info(
+			"Addon uploaded: bucket content.",
+			"id",
+			id,
+			"source",
+			source,
+			"destination",
+			destination)
+	}
+	return
+}
+
+//
+// GetContent downloads the file or directory at the path
+// within the bucket to the (local) destination. Directories
+// are downloaded as an archive and extracted.
+func (h *Bucket) GetContent(id uint, source, destination string) (err error) {
+	path, err := h.contentPath(id, source)
+	if err != nil {
+		return
+	}
+	reply, err := h.client.Download(path + "?" + api.Archive + "=" + bucket.TarGz)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reply.Body.Close()
+	}()
+	if reply.Header.Get(api.Directory) == "true" {
+		err = os.MkdirAll(destination, 0777)
+		if err != nil {
+			return
+		}
+		err = (&bucket.FSStore{}).Extract(
+			destination,
+			"",
+			bucket.TarGz,
+			reply.Body,
+			bucket.Limits{
+				Bytes:   math.MaxInt64,
+				Entries: math.MaxInt64,
+			})
+		return
+	}
+	err = os.MkdirAll(pathlib.Dir(destination), 0777)
+	if err != nil {
+		return
+	}
+	file, err := os.Create(destination)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	_, err = io.Copy(file, reply.Body)
+	return
+}
+
+//
+// DeleteContent deletes the file or directory at the path
+// within the bucket. Deleting the root empties the bucket.
+func (h *Bucket) DeleteContent(id uint, path string) (err error) {
+	path, err = h.contentPath(id, path)
+	if err != nil {
+		return
+	}
+	err = h.client.Delete(path)
+	return
+}
+
+//
+// contentPath returns the API path to the bucket content.
+// Each segment of the (bucket relative) path is escaped.
+func (h *Bucket) contentPath(id uint, path string) (s string, err error) {
+	path, err = bucket.Clean(path)
+	if err != nil {
+		return
+	}
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
 	}
+	path = strings.Join(segments, "/")
+	s = Params{
+		api.ID:       id,
+		api.Wildcard: path,
+	}.inject(api.BucketContent)
 	return
 }
@@ -3,8 +3,8 @@ package addon
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"github.com/konveyor/tackle2-hub/api"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -16,8 +16,24 @@ import (
 type Client struct {
 	// baseURL for the nub.
 	baseURL string
+	// token used to authenticate with the hub.
+	token string
 	// http client.
 	http *http.Client
+	// ifMatch (revision) precondition.
+	ifMatch string
+}
+
+//
+// IfMatch returns a client that sends the If-Match (revision)
+// precondition with each request. Updates and deletes of a model
+// changed (by others) after the revision was read fail with
+// PreconditionFailed.
+func (r *Client) IfMatch(revision uint) (client *Client) {
+	copied := *r
+	copied.ifMatch = api.RevisionTag(revision)
+	client = &copied
+	return
 }
 
 //
@@ -27,7 +43,7 @@ func (r *Client) Get(path string, object interface{}) (err error) {
 		Method: http.MethodGet,
 		URL:    r.join(path),
 	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(request)
 	if err != nil {
 		return
 	}
@@ -43,10 +59,8 @@ func (r *Client) Get(path string, object interface{}) (err error) {
 			return
 		}
 		err = json.Unmarshal(body, object)
-	case http.StatusNotFound:
-		err = &NotFound{path}
 	default:
-		err = errors.New(http.StatusText(status))
+		err = r.failed(path, reply)
 	}
 
 	return
@@ -65,7 +79,7 @@ func (r *Client) Post(path string, object interface{}) (err error) {
 		Body:   ioutil.NopCloser(reader),
 		URL:    r.join(path),
 	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(request)
 	if err != nil {
 		return
 	}
@@ -82,10 +96,8 @@ func (r *Client) Post(path string, object interface{}) (err error) {
 		if err != nil {
 			return
 		}
-	case http.StatusConflict:
-		err = &Conflict{path}
 	default:
-		err = errors.New(http.StatusText(status))
+		err = r.failed(path, reply)
 	}
 
 	return
@@ -104,7 +116,7 @@ func (r *Client) Put(path string, object interface{}) (err error) {
 		Body:   ioutil.NopCloser(reader),
 		URL:    r.join(path),
 	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(request)
 	if err != nil {
 		return
 	}
@@ -121,10 +133,42 @@ func (r *Client) Put(path string, object interface{}) (err error) {
 		if err != nil {
 			return
 		}
-	case http.StatusNotFound:
-		err = &NotFound{path}
 	default:
-		err = errors.New(http.StatusText(status))
+		err = r.failed(path, reply)
+	}
+
+	return
+}
+
+//
+// Patch a resource.
+// The patch is a JSON merge patch (RFC 7386).
+func (r *Client) Patch(path string, patch interface{}) (err error) {
+	bfr, err := json.Marshal(patch)
+	if err != nil {
+		return
+	}
+	reader := bytes.NewReader(bfr)
+	request := &http.Request{
+		Method: http.MethodPatch,
+		Header: http.Header{},
+		Body:   ioutil.NopCloser(reader),
+		URL:    r.join(path),
+	}
+	request.Header.Set("Content-Type", api.MIMEMergePatch)
+	reply, err := r.send(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reply.Body.Close()
+	}()
+	status := reply.StatusCode
+	switch status {
+	case http.StatusNoContent,
+		http.StatusOK:
+	default:
+		err = r.failed(path, reply)
 	}
 
 	return
@@ -137,7 +181,7 @@ func (r *Client) Delete(path string) (err error) {
 		Method: http.MethodDelete,
 		URL:    r.join(path),
 	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(request)
 	if err != nil {
 		return
 	}
@@ -148,29 +192,178 @@ func (r *Client) Delete(path string) (err error) {
 	switch status {
 	case http.StatusOK,
 		http.StatusNoContent:
-	case http.StatusNotFound:
-		err = &NotFound{path}
 	default:
-		err = errors.New(http.StatusText(status))
+		err = r.failed(path, reply)
 	}
 
 	return
 }
 
+//
+// Upload content to the path.
+// The reader is streamed as the request body.
+func (r *Client) Upload(path string, reader io.Reader, mime string) (err error) {
+	request := &http.Request{
+		Method: http.MethodPut,
+		Header: http.Header{},
+		Body:   ioutil.NopCloser(reader),
+		URL:    r.join(path),
+	}
+	request.Header.Set("Content-Type", mime)
+	reply, err := r.send(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reply.Body.Close()
+	}()
+	status := reply.StatusCode
+	switch status {
+	case http.StatusOK,
+		http.StatusCreated,
+		http.StatusNoContent:
+	default:
+		err = r.failed(path, reply)
+	}
+
+	return
+}
+
+//
+// Download the content at the path.
+// The caller must close the reply body.
+func (r *Client) Download(path string) (reply *http.Response, err error) {
+	request := &http.Request{
+		Method: http.MethodGet,
+		URL:    r.join(path),
+	}
+	reply, err = r.send(request)
+	if err != nil {
+		return
+	}
+	status := reply.StatusCode
+	switch status {
+	case http.StatusOK:
+		return
+	default:
+		err = r.failed(path, reply)
+	}
+	_ = reply.Body.Close()
+	reply = nil
+
+	return
+}
+
+//
+// send the request.
+// The request is authorized using the token.
+func (r *Client) send(request *http.Request) (reply *http.Response, err error) {
+	if request.Header == nil {
+		request.Header = http.Header{}
+	}
+	if r.token != "" {
+		request.Header.Set(
+			api.Authorization,
+			api.Bearer+" "+r.token)
+	}
+	if r.ifMatch != "" {
+		request.Header.Set(api.IfMatch, r.ifMatch)
+	}
+	reply, err = r.http.Do(request)
+	return
+}
+
+//
+// join the path (which may include a query) with the base URL.
 func (r *Client) join(path string) (parsedURL *url.URL) {
 	parsedURL, _ = url.Parse(r.baseURL)
-	parsedURL.Path = path
+	parsed, err := url.Parse(path)
+	if err != nil {
+		parsedURL.Path = path
+		return
+	}
+	parsedURL.Path = parsed.Path
+	parsedURL.RawPath = parsed.RawPath
+	parsedURL.RawQuery = parsed.RawQuery
+	return
+}
+
+//
+// failed returns the (typed) error for the failed request.
+// The problem (details) reported by the hub is decoded. The
+// error reports the request method.
+func (r *Client) failed(path string, reply *http.Response) (err error) {
+	method := ""
+	if reply.Request != nil {
+		method = reply.Request.Method
+	}
+	problem := &api.Problem{}
+	body, _ := io.ReadAll(reply.Body)
+	if json.Unmarshal(body, problem) != nil || problem.Status == 0 {
+		problem = api.NewProblem(reply.StatusCode, "", "")
+	}
+	switch reply.StatusCode {
+	case http.StatusBadRequest:
+		err = &BadRequest{Method: method, Path: path, Problem: problem}
+	case http.StatusUnauthorized,
+		http.StatusForbidden:
+		err = &Forbidden{Method: method, Path: path, Problem: problem}
+	case http.StatusNotFound:
+		err = &NotFound{Method: method, Path: path, Problem: problem}
+	case http.StatusConflict:
+		err = &Conflict{Method: method, Path: path, Problem: problem}
+	case http.StatusPreconditionFailed:
+		err = &PreconditionFailed{Method: method, Path: path, Problem: problem}
+	default:
+		err = problem
+	}
+	return
+}
+
+//
+// BadRequest reports 400 error.
+type BadRequest struct {
+	Method  string
+	Path    string
+	Problem *api.Problem
+}
+
+func (e BadRequest) Error() string {
+	return fmt.Sprintf("%s: path:%s [bad-request]%s", e.Method, e.Path, detail(e.Problem))
+}
+
+func (e *BadRequest) Is(err error) (matched bool) {
+	_, matched = err.(*BadRequest)
+	return
+}
+
+//
+// Forbidden reports 401 and 403 errors.
+type Forbidden struct {
+	Method  string
+	Path    string
+	Problem *api.Problem
+}
+
+func (e Forbidden) Error() string {
+	return fmt.Sprintf("%s: path:%s [forbidden]%s", e.Method, e.Path, detail(e.Problem))
+}
+
+func (e *Forbidden) Is(err error) (matched bool) {
+	_, matched = err.(*Forbidden)
 	return
 }
 
 //
 // Conflict reports 409 error.
 type Conflict struct {
-	Path string
+	Method  string
+	Path    string
+	Problem *api.Problem
 }
 
 func (e Conflict) Error() string {
-	return fmt.Sprintf("POST: path:%s [conflict]", e.Path)
+	return fmt.Sprintf("%s: path:%s [conflict]%s", e.Method, e.Path, detail(e.Problem))
 }
 
 func (e *Conflict) Is(err error) (matched bool) {
@@ -181,14 +374,43 @@ func (e *Conflict) Is(err error) (matched bool) {
 //
 // NotFound reports 404 error.
 type NotFound struct {
-	Path string
+	Method  string
+	Path    string
+	Problem *api.Problem
 }
 
 func (e NotFound) Error() string {
-	return fmt.Sprintf("GET: path:%s [not-found]", e.Path)
+	return fmt.Sprintf("%s: path:%s [not-found]%s", e.Method, e.Path, detail(e.Problem))
 }
 
 func (e *NotFound) Is(err error) (matched bool) {
 	_, matched = err.(*NotFound)
 	return
 }
+
+//
+// PreconditionFailed reports 412 error.
+// The model was changed after the (If-Match) revision was read.
+type PreconditionFailed struct {
+	Method  string
+	Path    string
+	Problem *api.Problem
+}
+
+func (e PreconditionFailed) Error() string {
+	return fmt.Sprintf("%s: path:%s [precondition-failed]%s", e.Method, e.Path, detail(e.Problem))
+}
+
+func (e *PreconditionFailed) Is(err error) (matched bool) {
+	_, matched = err.(*PreconditionFailed)
+	return
+}
+
+//
+// detail returns the problem detail (suffix).
+func detail(p *api.Problem) (s string) {
+	if p != nil && p.Detail != "" {
+		s = " " + p.Detail
+	}
+	return
+}
@@ -13,35 +13,19 @@ type Identity struct {
 
 //
 // Get an identity by ID.
+// The credentials are decrypted by the hub.
 func (h *Identity) Get(id uint) (r *api.Identity, err error) {
 	r = &api.Identity{}
 	path := Params{api.ID: id}.inject(api.IdentityRoot)
-	err = h.client.Get(path, r)
-	if err != nil {
-		return
-	}
-	m := r.Model()
-	err = m.Decrypt(Addon.secret.Hub.Encryption.Passphrase)
-	r.With(m)
+	err = h.client.Get(path+"?"+api.Decrypted+"=true", r)
 	return
 }
 
 //
 // List identities.
+// The credentials are decrypted by the hub.
 func (h *Identity) List() (list []api.Identity, err error) {
 	list = []api.Identity{}
-	err = h.client.Get(api.IdentitiesRoot, &list)
-	if err != nil {
-		return
-	}
-	for i := range list {
-		r := &list[i]
-		m := r.Model()
-		err = m.Decrypt(Addon.secret.Hub.Encryption.Passphrase)
-		r.With(m)
-		if err != nil {
-			return
-		}
-	}
+	err = h.client.Get(api.IdentitiesRoot+"?"+api.Decrypted+"=true", &list)
 	return
 }
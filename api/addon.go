@@ -4,11 +4,16 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	crd "github.com/konveyor/tackle2-hub/k8s/api/tackle/v1alpha1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+//
+// Kind
+const (
+	AddonKind = "addon"
+)
+
 //
 // Routes
 const (
@@ -16,6 +21,13 @@ const (
 	AddonRoot  = AddonsRoot + "/:" + Name
 )
 
+//
+// AddonFilter filterable fields.
+var AddonFilter = FilterFields{
+	"name":  {Kind: FilterString},
+	"image": {Kind: FilterString},
+}
+
 //
 // AddonHandler handles addon routes.
 type AddonHandler struct {
@@ -49,13 +61,8 @@ func (h AddonHandler) Get(ctx *gin.Context) {
 		},
 		addon)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			ctx.Status(http.StatusNotFound)
-			return
-		} else {
-			h.getFailed(ctx, err)
-			return
-		}
+		h.getFailed(ctx, err)
+		return
 	}
 	r := Addon{}
 	r.With(addon)
@@ -70,9 +77,15 @@ func (h AddonHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Addon
 // @router /addons [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h AddonHandler) List(ctx *gin.Context) {
+	filter, err := NewFilter(ctx, AddonFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
 	list := &crd.AddonList{}
-	err := h.Client.List(
+	err = h.Client.List(
 		context.TODO(),
 		&client.ListOptions{
 			Namespace: Settings.Namespace,
@@ -86,10 +99,17 @@ func (h AddonHandler) List(ctx *gin.Context) {
 	for _, m := range list.Items {
 		addon := Addon{}
 		addon.With(&m)
-		content = append(content, addon)
+		matched := filter.Match(
+			map[string]string{
+				"name":  addon.Name,
+				"image": addon.Image,
+			})
+		if matched {
+			content = append(content, addon)
+		}
 	}
 
-	ctx.JSON(http.StatusOK, content)
+	h.listResponse(ctx, AddonKind, content, len(content), "")
 }
 
 //
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
 	"net/http"
 	"strconv"
 )
@@ -21,6 +22,28 @@ const (
 	ApplicationRoot  = ApplicationsRoot + "/:" + ID
 )
 
+//
+// ApplicationFilter filterable fields.
+var ApplicationFilter = FilterFields{
+	"id":                 {Column: "ID", Kind: FilterInteger},
+	"name":               {Column: "Name", Kind: FilterString},
+	"description":        {Column: "Description", Kind: FilterString},
+	"comments":           {Column: "Comments", Kind: FilterString},
+	"businessService.id": {Column: "BusinessServiceID", Kind: FilterInteger},
+	"tag.id": {
+		Column: "TagID",
+		Kind:   FilterInteger,
+		Table:  "applicationTags",
+		Key:    "ApplicationID",
+	},
+	"identity.id": {
+		Column: "IdentityID",
+		Kind:   FilterInteger,
+		Table:  "ApplicationIdentity",
+		Key:    "ApplicationID",
+	},
+}
+
 //
 // ApplicationHandler handles application resource routes.
 type ApplicationHandler struct {
@@ -35,6 +58,7 @@ func (h ApplicationHandler) AddRoutes(e *gin.Engine) {
 	e.POST(ApplicationsRoot, h.Create)
 	e.GET(ApplicationRoot, h.Get)
 	e.PUT(ApplicationRoot, h.Update)
+	e.PATCH(ApplicationRoot, h.Patch)
 	e.DELETE(ApplicationRoot, h.Delete)
 }
 
@@ -44,6 +68,7 @@ func (h ApplicationHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.Application
+// @header 200 {string} ETag "Revision"
 // @router /application-inventory/application/{id} [get]
 // @param id path int true "Application ID"
 func (h ApplicationHandler) Get(ctx *gin.Context) {
@@ -53,7 +78,8 @@ func (h ApplicationHandler) Get(ctx *gin.Context) {
 		h.DB,
 		"Tags",
 		"Review",
-		"BusinessService")
+		"BusinessService",
+		"Identities.Identity")
 	result := db.First(m, id)
 	if result.Error != nil {
 		h.getFailed(ctx, result.Error)
@@ -62,6 +88,7 @@ func (h ApplicationHandler) Get(ctx *gin.Context) {
 	r := Application{}
 	r.With(m)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -72,17 +99,29 @@ func (h ApplicationHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Application
 // @router /application-inventory/application [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h ApplicationHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.Application
-	h.DB.Model(model.Application{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, ApplicationFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.Application{}).Count(&count)
+	pagination, err := NewPagination(ctx, ApplicationFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.BaseHandler.preLoad(
 		db,
 		"Tags",
 		"Review",
-		"BusinessService")
+		"BusinessService",
+		"Identities.Identity")
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -95,7 +134,7 @@ func (h ApplicationHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, ApplicationKind, resources, int(count))
+	h.listResponse(ctx, ApplicationKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -109,13 +148,17 @@ func (h ApplicationHandler) List(ctx *gin.Context) {
 // @param application body api.Application true "Application data"
 func (h ApplicationHandler) Create(ctx *gin.Context) {
 	r := &Application{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	if r.BucketQuota != nil && *r.BucketQuota != 0 && !h.admin(ctx) {
+		h.forbidden(ctx, "bucket quota requires authorization.")
+		return
+	}
 	m := r.Model()
-	result := h.DB.Create(m)
+	result := h.DB.Omit("Identities").Create(m)
 	if result.Error != nil {
 		h.createFailed(ctx, result.Error)
 		return
@@ -125,6 +168,11 @@ func (h ApplicationHandler) Create(ctx *gin.Context) {
 		h.createFailed(ctx, err)
 		return
 	}
+	err = h.replaceIdentities(m.ID, m.Identities)
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
 	r.With(m)
 
 	ctx.JSON(http.StatusCreated, r)
@@ -135,17 +183,27 @@ func (h ApplicationHandler) Create(ctx *gin.Context) {
 // @description Delete an application.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /application-inventory/application/{id} [delete]
 // @param id path int true "Application id"
+// @param If-Match header string false "Revision (ETag)"
 func (h ApplicationHandler) Delete(ctx *gin.Context) {
 	id, _ := strconv.Atoi(ctx.Param(ID))
 	m := &model.Application{}
 	m.ID = uint(id)
-	result := h.DB.Select("Tags").Delete(m)
+	p, matched := h.precondition(ctx, &model.Application{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Select("Tags").Delete(m)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -153,27 +211,132 @@ func (h ApplicationHandler) Delete(ctx *gin.Context) {
 // Update godoc
 // @summary Update an application.
 // @description Update an application.
+// @description The identities are replaced only when specified.
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /application-inventory/application/{id} [put]
 // @param id path int true "Application id"
 // @param application body api.Application true "Application data"
+// @param If-Match header string false "Revision (ETag)"
 func (h ApplicationHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &Application{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	if r.BucketQuota != nil {
+		current := &model.Application{}
+		result := h.DB.Select("BucketQuota").First(current, id)
+		if result.Error != nil {
+			h.getFailed(ctx, result.Error)
+			return
+		}
+		if current.BucketQuota != *r.BucketQuota && !h.admin(ctx) {
+			h.forbidden(ctx, "bucket quota requires authorization.")
+			return
+		}
+	}
+	p, matched := h.precondition(ctx, &model.Application{}, id)
+	if !matched {
+		return
+	}
 	m := r.Model()
-	result := h.DB.Model(&model.Application{}).Where("id = ?", id).Omit("id").Updates(m)
+	omit := []string{"id", "Identities"}
+	if r.BucketQuota == nil {
+		omit = append(omit, "BucketQuota")
+	}
+	db := p.Where(h.DB).Model(&model.Application{}).Where("ID", id)
+	result := db.Omit(omit...).Updates(m)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
-	err = h.DB.Model(&m).Association("Tags").Replace("Tags", m.Tags)
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+	appId, _ := strconv.Atoi(id)
+	m.ID = uint(appId)
+	err = h.DB.Model(m).Association("Tags").Replace("Tags", m.Tags)
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+	if r.Identities != nil {
+		err = h.replaceIdentities(m.ID, m.Identities)
+		if err != nil {
+			h.updateFailed(ctx, err)
+			return
+		}
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch an application.
+// @description Patch an application using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /application-inventory/application/{id} [patch]
+// @param id path string true "Application ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h ApplicationHandler) Patch(ctx *gin.Context) {
+	m := &model.Application{}
+	db := h.preLoad(h.DB, "Tags", "Review", "BusinessService", "Identities.Identity")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Application{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	if patched.BucketQuota != m.BucketQuota && !h.admin(ctx) {
+		h.forbidden(ctx, "bucket quota requires authorization.")
+		return
+	}
+	fields := []string{
+		"Name",
+		"Description",
+		"Comments",
+		"Repository",
+		"BusinessServiceID",
+		"BucketQuota",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+	err = h.DB.Model(patched).Association("Tags").Replace(patched.Tags)
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+	err = h.replaceIdentities(patched.ID, patched.Identities)
 	if err != nil {
 		h.updateFailed(ctx, err)
 		return
@@ -182,17 +345,43 @@ func (h ApplicationHandler) Update(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+//
+// replaceIdentities replaces the identities associated
+// with the application.
+func (h ApplicationHandler) replaceIdentities(id uint, identities []model.ApplicationIdentity) (err error) {
+	err = h.DB.Transaction(func(tx *gorm.DB) (err error) {
+		result := tx.Where("ApplicationID", id).Delete(&model.ApplicationIdentity{})
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		for i := range identities {
+			ref := &identities[i]
+			ref.ApplicationID = id
+			result = tx.Create(ref)
+			if result.Error != nil {
+				err = result.Error
+				return
+			}
+		}
+		return
+	})
+	return
+}
+
 //
 // Application REST resource.
 type Application struct {
 	Resource
-	Name            string      `json:"name" binding:"required"`
-	Description     string      `json:"description"`
-	Repository      *Repository `json:"repository"`
-	Review          *Review     `json:"review"`
-	Comments        string      `json:"comments"`
-	Tags            []string    `json:"tags"`
-	BusinessService string      `json:"businessService"`
+	Name            string        `json:"name" binding:"required"`
+	Description     string        `json:"description"`
+	Repository      *Repository   `json:"repository"`
+	Review          *Review       `json:"review"`
+	Comments        string        `json:"comments"`
+	Tags            []string      `json:"tags"`
+	BusinessService string        `json:"businessService"`
+	Identities      []AppIdentity `json:"identities" binding:"dive"`
+	BucketQuota     *int64        `json:"bucketQuota,omitempty" binding:"omitempty,min=0"`
 }
 
 //
@@ -207,11 +396,22 @@ func (r *Application) With(m *model.Application) {
 		r.Review = &Review{Resource: Resource{ID: m.Review.ID}}
 	}
 	r.BusinessService = strconv.Itoa(int(m.BusinessServiceID))
+	r.BucketQuota = &m.BucketQuota
 	for _, tag := range m.Tags {
 		r.Tags = append(
 			r.Tags,
 			strconv.Itoa(int(tag.ID)))
 	}
+	for _, ref := range m.Identities {
+		identity := AppIdentity{
+			ID:   ref.IdentityID,
+			Role: ref.Role,
+		}
+		if ref.Identity != nil {
+			identity.Name = ref.Identity.Name
+		}
+		r.Identities = append(r.Identities, identity)
+	}
 }
 
 //
@@ -223,6 +423,9 @@ func (r *Application) Model() (m *model.Application) {
 		Comments:    r.Comments,
 	}
 	m.ID = r.ID
+	if r.BucketQuota != nil {
+		m.BucketQuota = *r.BucketQuota
+	}
 	if r.Repository != nil {
 		m.Repository, _ = json.Marshal(r.Repository)
 	}
@@ -240,10 +443,27 @@ func (r *Application) Model() (m *model.Application) {
 				},
 			})
 	}
+	for _, ref := range r.Identities {
+		m.Identities = append(
+			m.Identities,
+			model.ApplicationIdentity{
+				IdentityID: ref.ID,
+				Role:       ref.Role,
+			})
+	}
 
 	return
 }
 
+//
+// AppIdentity REST nested resource.
+// An identity associated with an application in a role.
+type AppIdentity struct {
+	ID   uint   `json:"id" binding:"required"`
+	Name string `json:"name,omitempty"`
+	Role string `json:"role" binding:"required,oneof=source maven proxy"`
+}
+
 //
 // Repository REST nested resource.
 type Repository struct {
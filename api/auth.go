@@ -0,0 +1,77 @@
+package api
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/task"
+	"net/http"
+	"strings"
+)
+
+//
+// Authorization
+const (
+	Authorization = "Authorization"
+	Bearer        = "Bearer"
+)
+
+//
+// privileged returns true when the request is authorized
+// with either the admin token or the token issued to a running task.
+// The task is matched by the token digest.
+func (h *BaseHandler) privileged(ctx *gin.Context) (authorized bool) {
+	token := h.token(ctx)
+	if token == "" {
+		return
+	}
+	if h.admin(ctx) {
+		authorized = true
+		return
+	}
+	var count int64
+	db := h.DB.Model(&model.Task{})
+	db = db.Where("Token", model.TokenDigest(token))
+	db = db.Where("Status", task.Running)
+	result := db.Count(&count)
+	if result.Error != nil {
+		return
+	}
+	authorized = count > 0
+	return
+}
+
+//
+// admin returns true when the request is authorized
+// with the admin token.
+func (h *BaseHandler) admin(ctx *gin.Context) (authorized bool) {
+	token := h.token(ctx)
+	admin := Settings.Hub.Auth.Token
+	if token == "" || admin == "" {
+		return
+	}
+	authorized = subtle.ConstantTimeCompare([]byte(token), []byte(admin)) == 1
+	return
+}
+
+//
+// token returns the bearer token from the Authorization header.
+func (h *BaseHandler) token(ctx *gin.Context) (token string) {
+	header := ctx.GetHeader(Authorization)
+	fields := strings.Fields(header)
+	if len(fields) == 2 && strings.EqualFold(fields[0], Bearer) {
+		token = fields[1]
+	}
+	return
+}
+
+//
+// forbidden reports a request not authorized.
+func (h *BaseHandler) forbidden(ctx *gin.Context, reason string) {
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusForbidden,
+			CodeForbidden,
+			reason))
+}
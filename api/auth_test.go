@@ -0,0 +1,38 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/task"
+	"github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrivileged(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	_, db := testRouter(t, g)
+	token := "0123456789abcdef"
+	m := &model.Task{
+		Name:   "A",
+		Status: task.Running,
+		Token:  model.TokenDigest(token),
+	}
+	err := db.Create(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	h := BaseHandler{DB: db}
+	privileged := func(token string) bool {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx.Request.Header.Set(Authorization, Bearer+" "+token)
+		return h.privileged(ctx)
+	}
+	g.Expect(privileged("admin")).To(gomega.BeTrue())
+	g.Expect(privileged(token)).To(gomega.BeTrue())
+	g.Expect(privileged(m.Token)).To(gomega.BeFalse())
+	g.Expect(privileged("other")).To(gomega.BeFalse())
+	err = db.Model(m).Update("Status", task.Succeeded).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(privileged(token)).To(gomega.BeFalse())
+}
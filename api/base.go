@@ -4,11 +4,12 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle2-hub/model"
-	"github.com/mattn/go-sqlite3"
 	"gorm.io/gorm"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,27 +32,23 @@ func (h *BaseHandler) With(db *gorm.DB, client client.Client) {
 //
 // getFailed handles Get() errors.
 func (h *BaseHandler) getFailed(ctx *gin.Context, err error) {
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		ctx.JSON(
-			http.StatusNotFound,
-			gin.H{
-				"error": err.Error(),
-			})
-		return
-	}
-	if errors.Is(err, os.ErrNotExist) {
-		ctx.JSON(
-			http.StatusNotFound,
-			gin.H{
-				"error": err.Error(),
-			})
+	if errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, os.ErrNotExist) ||
+		k8serr.IsNotFound(err) {
+		h.problem(
+			ctx,
+			NewProblem(
+				http.StatusNotFound,
+				CodeNotFound,
+				err.Error()))
 		return
 	}
-	ctx.JSON(
-		http.StatusInternalServerError,
-		gin.H{
-			"error": err.Error(),
-		})
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusInternalServerError,
+			CodeInternal,
+			err.Error()))
 
 	url := ctx.Request.URL.String()
 	log.Error(
@@ -64,11 +61,12 @@ func (h *BaseHandler) getFailed(ctx *gin.Context, err error) {
 //
 // listFailed handles List() errors.
 func (h *BaseHandler) listFailed(ctx *gin.Context, err error) {
-	ctx.JSON(
-		http.StatusInternalServerError,
-		gin.H{
-			"error": err.Error(),
-		})
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusInternalServerError,
+			CodeInternal,
+			err.Error()))
 
 	url := ctx.Request.URL.String()
 	log.Error(
@@ -81,22 +79,15 @@ func (h *BaseHandler) listFailed(ctx *gin.Context, err error) {
 //
 // createFailed handles Create() errors.
 func (h *BaseHandler) createFailed(ctx *gin.Context, err error) {
-	status := http.StatusInternalServerError
-	sqliteErr := &sqlite3.Error{}
-
-	if errors.As(err, sqliteErr) {
-		switch sqliteErr.ExtendedCode {
-		case sqlite3.ErrConstraintUnique,
-			sqlite3.ErrConstraintPrimaryKey:
-			status = http.StatusConflict
-		}
+	p := h.constraintProblem(err, false)
+	if p == nil {
+		p = NewProblem(
+			http.StatusInternalServerError,
+			CodeInternal,
+			err.Error())
 	}
 
-	ctx.JSON(
-		status,
-		gin.H{
-			"error": err.Error(),
-		})
+	h.problem(ctx, p)
 
 	url := ctx.Request.URL.String()
 	log.Error(
@@ -109,21 +100,15 @@ func (h *BaseHandler) createFailed(ctx *gin.Context, err error) {
 //
 // updateFailed handles Update() errors.
 func (h *BaseHandler) updateFailed(ctx *gin.Context, err error) {
-	status := http.StatusInternalServerError
-	sqliteErr := &sqlite3.Error{}
-
-	if errors.As(err, sqliteErr) {
-		switch sqliteErr.ExtendedCode {
-		case sqlite3.ErrConstraintUnique:
-			status = http.StatusConflict
-		}
+	p := h.constraintProblem(err, false)
+	if p == nil {
+		p = NewProblem(
+			http.StatusInternalServerError,
+			CodeInternal,
+			err.Error())
 	}
 
-	ctx.JSON(
-		status,
-		gin.H{
-			"error": err.Error(),
-		})
+	h.problem(ctx, p)
 
 	url := ctx.Request.URL.String()
 	log.Error(
@@ -140,11 +125,15 @@ func (h *BaseHandler) deleteFailed(ctx *gin.Context, err error) {
 		ctx.Status(http.StatusOK)
 		return
 	}
-	ctx.JSON(
-		http.StatusInternalServerError,
-		gin.H{
-			"error": err.Error(),
-		})
+	p := h.constraintProblem(err, true)
+	if p == nil {
+		p = NewProblem(
+			http.StatusInternalServerError,
+			CodeInternal,
+			err.Error())
+	}
+
+	h.problem(ctx, p)
 
 	url := ctx.Request.URL.String()
 	log.Error(
@@ -157,11 +146,55 @@ func (h *BaseHandler) deleteFailed(ctx *gin.Context, err error) {
 //
 // bindFailed handles errors from BindJSON().
 func (h *BaseHandler) bindFailed(ctx *gin.Context, err error) {
-	ctx.JSON(
-		http.StatusBadRequest,
-		gin.H{
-			"error": err.Error(),
-		})
+	h.problem(ctx, h.invalid(err))
+}
+
+//
+// constraintProblem returns the problem reporting a (DB) constraint
+// violation. Unique and (deleted) referenced models are conflicts.
+// References to models not found, required (not null) and check
+// constraints are not processable. Returns nil when the error does
+// not report a constraint violation.
+func (h *BaseHandler) constraintProblem(err error, deleted bool) (p *Problem) {
+	ce := model.Classify(err)
+	if ce == nil {
+		return
+	}
+	var rule, detail string
+	switch ce.Kind {
+	case model.ErrUnique:
+		p = NewProblem(http.StatusConflict, CodeConflict, err.Error())
+		rule = "unique"
+		detail = "must be unique."
+	case model.ErrForeignKey:
+		if deleted {
+			p = NewProblem(http.StatusConflict, CodeReferenced, err.Error())
+			rule = "referenced"
+			detail = "referenced."
+		} else {
+			p = NewProblem(http.StatusUnprocessableEntity, CodeReference, err.Error())
+			rule = "exists"
+			detail = "references a resource not found."
+		}
+	case model.ErrNotNull:
+		p = NewProblem(http.StatusUnprocessableEntity, CodeConstraint, err.Error())
+		rule = "required"
+		detail = "required."
+	default:
+		p = NewProblem(http.StatusUnprocessableEntity, CodeConstraint, err.Error())
+		rule = "check"
+		detail = "must satisfy: " + ce.Constraint + "."
+	}
+	for _, name := range ce.Fields {
+		p.Errors = append(
+			p.Errors,
+			FieldError{
+				Field:  name,
+				Rule:   rule,
+				Detail: detail,
+			})
+	}
+	return
 }
 
 //
@@ -177,12 +210,19 @@ func (h *BaseHandler) preLoad(db *gorm.DB, fields ...string) (tx *gorm.DB) {
 
 //
 // listResponse selectively returns hal+json or plain json based on the "accept" header
-func (h *BaseHandler) listResponse(ctx *gin.Context, kind string, resources interface{}, count int) {
+// The (total) count and the (optional) cursor of the next page are reported
+// in headers and in the hal+json.
+func (h *BaseHandler) listResponse(ctx *gin.Context, kind string, resources interface{}, count int, next string) {
+	ctx.Header(TotalCount, strconv.Itoa(count))
+	if next != "" {
+		ctx.Header(NextCursor, next)
+	}
 	for _, accept := range ctx.Request.Header.Values("Accept") {
 		if strings.Contains(accept, "application/hal+json") {
 			ctx.Writer.Header().Set("Content-Type", "application/hal+json; charset=utf-8")
 			hal := Hal{}
 			hal.With(kind, resources, count)
+			hal.NextCursor = next
 			ctx.JSON(http.StatusOK, hal)
 			return
 		}
@@ -195,6 +235,7 @@ func (h *BaseHandler) listResponse(ctx *gin.Context, kind string, resources inte
 type Hal struct {
 	Embedded   map[string]interface{} `json:"_embedded"`
 	TotalCount int                    `json:"total_count"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
 }
 
 //
@@ -212,6 +253,7 @@ type Resource struct {
 	CreateUser string    `json:"createUser"`
 	UpdateUser string    `json:"updateUser"`
 	CreateTime time.Time `json:"createTime"`
+	Revision   uint      `json:"revision"`
 }
 
 //
@@ -221,4 +263,5 @@ func (r *Resource) With(m *model.Model) {
 	r.CreateUser = m.CreateUser
 	r.UpdateUser = m.UpdateUser
 	r.CreateTime = m.CreateTime
+	r.Revision = m.Revision
 }
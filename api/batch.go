@@ -0,0 +1,275 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//
+// Routes
+const (
+	BatchRoot = "/batch"
+)
+
+//
+// Batch modes.
+const (
+	// All operations committed or none.
+	Atomic = "atomic"
+	// Failed operations rolled back (individually).
+	BestEffort = "best-effort"
+)
+
+//
+// BatchLimit the max number of operations in a batch.
+const BatchLimit = 1000
+
+//
+// BatchExcluded routes not supported in a batch.
+// The handlers use the DB (asynchronously) after the
+// request completes and the batch transaction has ended.
+var BatchExcluded = []string{
+	BatchRoot,
+	EncryptionRoot,
+}
+
+//
+// BatchHandler handles batch routes.
+type BatchHandler struct {
+	BaseHandler
+}
+
+//
+// AddRoutes adds routes.
+func (h BatchHandler) AddRoutes(e *gin.Engine) {
+	e.POST(BatchRoot, h.Batch)
+}
+
+// Batch godoc
+// @summary Execute a batch of operations.
+// @description Execute a batch of (create, update and delete) operations in
+// @description a single transaction. Each operation is dispatched to the
+// @description route for the path as if requested individually.
+// @description In the `atomic` (default) mode, all operations are rolled back
+// @description when any operation fails and the remaining operations are not
+// @description executed. In the `best-effort` mode, only the failed operations
+// @description are rolled back. The result of each operation is returned.
+// @description Changes to bucket content and cluster resources are not
+// @description transactional. Encryption (key rotation) is not supported.
+// @tags batch
+// @accept json
+// @produce json
+// @success 200 {object} api.BatchResponse
+// @router /batch [post]
+// @param batch body api.BatchRequest true "Batch data"
+func (h BatchHandler) Batch(ctx *gin.Context) {
+	r := &BatchRequest{}
+	err := ctx.ShouldBindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	err = r.Validate()
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	if r.Mode == "" {
+		r.Mode = Atomic
+	}
+	tx, pending := model.Begin(h.DB)
+	if tx.Error != nil {
+		h.createFailed(ctx, tx.Error)
+		return
+	}
+	router := h.router(tx)
+	reply := BatchResponse{Mode: r.Mode}
+	failed := false
+	for i := range r.Operations {
+		op := &r.Operations[i]
+		if failed {
+			reply.Results = append(
+				reply.Results,
+				BatchResult{
+					Status: http.StatusFailedDependency,
+					Error:  "not executed.",
+				})
+			continue
+		}
+		savePoint := "batch" + strconv.Itoa(i)
+		mark := pending.Mark()
+		if r.Mode == BestEffort {
+			err = tx.SavePoint(savePoint).Error
+			if err != nil {
+				break
+			}
+		}
+		result := h.dispatch(ctx, router, op)
+		reply.Results = append(reply.Results, result)
+		if result.Succeeded() {
+			continue
+		}
+		if r.Mode == BestEffort {
+			err = tx.RollbackTo(savePoint).Error
+			if err != nil {
+				break
+			}
+			pending.Rollback(mark)
+		} else {
+			failed = true
+		}
+	}
+	if err != nil || failed {
+		_ = tx.Rollback()
+	} else {
+		err = tx.Commit().Error
+	}
+	if err != nil || failed {
+		pending.Rollback(0)
+	} else {
+		pending.Commit()
+	}
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+	reply.Committed = !failed
+
+	ctx.JSON(http.StatusOK, reply)
+}
+
+//
+// router builds a router with (all) handlers using the transaction.
+// Handlers for excluded routes are not added.
+func (h *BatchHandler) router(tx *gorm.DB) (router *gin.Engine) {
+	router = gin.New()
+	router.Use(gin.CustomRecovery(Recovered))
+	router.Use(RequestIdentifier)
+	router.NoRoute(NoRoute)
+	for _, handler := range All() {
+		switch handler.(type) {
+		case *BatchHandler, *EncryptionHandler:
+			continue
+		}
+		handler.With(tx, h.Client)
+		handler.AddRoutes(router)
+	}
+	return
+}
+
+//
+// dispatch the operation to the router.
+// The request is authorized using the batch request authorization.
+func (h *BatchHandler) dispatch(ctx *gin.Context, router *gin.Engine, op *BatchOperation) (result BatchResult) {
+	request, err := http.NewRequest(op.Method, op.Path, bytes.NewReader(op.Body))
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Error = err.Error()
+		return
+	}
+	request.Header.Set(Authorization, ctx.GetHeader(Authorization))
+	request.Header.Set(RequestID, h.requestID(ctx))
+	if op.Method == http.MethodPatch {
+		request.Header.Set("Content-Type", MIMEMergePatch)
+	} else {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if op.IfMatch != "" {
+		request.Header.Set(IfMatch, op.IfMatch)
+	}
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, request)
+	result.Status = writer.Code
+	body := bytes.TrimSpace(writer.Body.Bytes())
+	if len(body) > 0 && json.Valid(body) {
+		result.Body = body
+	}
+	return
+}
+
+//
+// BatchOperation REST resource.
+type BatchOperation struct {
+	Method  string          `json:"method" binding:"required,oneof=POST PUT PATCH DELETE"`
+	Path    string          `json:"path" binding:"required"`
+	Body    json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	IfMatch string          `json:"ifMatch,omitempty"`
+}
+
+//
+// Validate the operation.
+func (r *BatchOperation) Validate() (err error) {
+	u, err := url.Parse(r.Path)
+	if err != nil {
+		return
+	}
+	if !strings.HasPrefix(u.Path, "/") || u.Host != "" {
+		err = fmt.Errorf("path: '%s' must be absolute.", r.Path)
+		return
+	}
+	cleaned := path.Clean(u.Path)
+	for _, root := range BatchExcluded {
+		if cleaned == root || strings.HasPrefix(cleaned, root+"/") {
+			err = fmt.Errorf("path: '%s' not supported.", r.Path)
+			return
+		}
+	}
+	return
+}
+
+//
+// BatchRequest REST resource.
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty" binding:"omitempty,oneof=atomic best-effort"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+//
+// Validate the batch.
+func (r *BatchRequest) Validate() (err error) {
+	if len(r.Operations) > BatchLimit {
+		err = fmt.Errorf("operations: limited to %d.", BatchLimit)
+		return
+	}
+	for i := range r.Operations {
+		err = r.Operations[i].Validate()
+		if err != nil {
+			err = fmt.Errorf("operations[%d]: %w", i, err)
+			return
+		}
+	}
+	return
+}
+
+//
+// BatchResult REST resource.
+type BatchResult struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	Error  string          `json:"error,omitempty"`
+}
+
+//
+// Succeeded returns true when the operation succeeded.
+func (r *BatchResult) Succeeded() (succeeded bool) {
+	succeeded = r.Status >= 200 && r.Status < 300
+	return
+}
+
+//
+// BatchResponse REST resource.
+type BatchResponse struct {
+	Mode      string        `json:"mode"`
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/database"
+	"github.com/konveyor/tackle2-hub/database/dbtest"
+	"github.com/konveyor/tackle2-hub/migration"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/onsi/gomega"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	router, db := testRouter(t, g)
+	create := func(name string) BatchOperation {
+		return BatchOperation{
+			Method: http.MethodPost,
+			Path:   TagTypesRoot,
+			Body:   json.RawMessage(`{"name":"` + name + `"}`),
+		}
+	}
+	names := func() (names []string) {
+		err := db.Model(&model.TagType{}).Order("ID").Pluck("Name", &names).Error
+		g.Expect(err).To(gomega.BeNil())
+		return
+	}
+	statuses := func(reply *BatchResponse) (statuses []int) {
+		for _, result := range reply.Results {
+			statuses = append(statuses, result.Status)
+		}
+		return
+	}
+	//
+	// Atomic (committed).
+	status, reply := testBatch(g, router, BatchRequest{
+		Operations: []BatchOperation{create("A"), create("B")},
+	})
+	g.Expect(status).To(gomega.Equal(http.StatusOK))
+	g.Expect(reply.Mode).To(gomega.Equal(Atomic))
+	g.Expect(reply.Committed).To(gomega.BeTrue())
+	g.Expect(statuses(reply)).To(gomega.Equal([]int{http.StatusCreated, http.StatusCreated}))
+	created := &TagType{}
+	err := json.Unmarshal(reply.Results[1].Body, created)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(created.Name).To(gomega.Equal("B"))
+	g.Expect(created.ID).ToNot(gomega.BeZero())
+	g.Expect(names()).To(gomega.Equal([]string{"A", "B"}))
+	//
+	// Atomic (rolled back).
+	status, reply = testBatch(g, router, BatchRequest{
+		Operations: []BatchOperation{create("C"), create("A"), create("D")},
+	})
+	g.Expect(status).To(gomega.Equal(http.StatusOK))
+	g.Expect(reply.Committed).To(gomega.BeFalse())
+	g.Expect(statuses(reply)).To(
+		gomega.Equal(
+			[]int{
+				http.StatusCreated,
+				http.StatusConflict,
+				http.StatusFailedDependency,
+			}))
+	g.Expect(names()).To(gomega.Equal([]string{"A", "B"}))
+	//
+	// Best effort (failed operation rolled back to the savepoint).
+	status, reply = testBatch(g, router, BatchRequest{
+		Mode: BestEffort,
+		Operations: []BatchOperation{
+			create("C"),
+			create("A"),
+			{
+				Method: http.MethodDelete,
+				Path:   TagTypesRoot + "/1",
+			},
+			create("D"),
+		},
+	})
+	g.Expect(status).To(gomega.Equal(http.StatusOK))
+	g.Expect(reply.Mode).To(gomega.Equal(BestEffort))
+	g.Expect(reply.Committed).To(gomega.BeTrue())
+	g.Expect(statuses(reply)).To(
+		gomega.Equal(
+			[]int{
+				http.StatusCreated,
+				http.StatusConflict,
+				http.StatusNoContent,
+				http.StatusCreated,
+			}))
+	g.Expect(names()).To(gomega.Equal([]string{"B", "C", "D"}))
+	//
+	// Excluded.
+	for _, excluded := range []string{KeyRotationRoot, BatchRoot, "/tagtypes/../batch"} {
+		status, _ = testBatch(g, router, BatchRequest{
+			Operations: []BatchOperation{
+				{Method: http.MethodPost, Path: excluded},
+			},
+		})
+		g.Expect(status).To(gomega.Equal(http.StatusBadRequest), excluded)
+	}
+	g.Expect(names()).To(gomega.Equal([]string{"B", "C", "D"}))
+}
+
+//
+// testBatch posts the batch request.
+func testBatch(g *gomega.WithT, router *gin.Engine, r BatchRequest) (status int, reply *BatchResponse) {
+	body, err := json.Marshal(r)
+	g.Expect(err).To(gomega.BeNil())
+	request := httptest.NewRequest(http.MethodPost, BatchRoot, bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(Authorization, Bearer+" admin")
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, request)
+	status = writer.Code
+	if status == http.StatusOK {
+		reply = &BatchResponse{}
+		err = json.Unmarshal(writer.Body.Bytes(), reply)
+		g.Expect(err).To(gomega.BeNil())
+	}
+	return
+}
+
+//
+// testRouter builds a router with all handlers using a
+// (migrated) test DB. SQLite (temporary file) by default.
+func testRouter(t *testing.T, g *gomega.WithT) (router *gin.Engine, db *gorm.DB) {
+	err := Settings.Hub.Load()
+	g.Expect(err).To(gomega.BeNil())
+	if Settings.DB.Driver == database.SQLite {
+		Settings.DB.Path = path.Join(t.TempDir(), "test.db")
+	}
+	Settings.DB.SeedPath = t.TempDir()
+	Settings.Hub.Bucket.Path = t.TempDir()
+	Settings.Hub.Auth.Token = "admin"
+	db, err = database.Open()
+	g.Expect(err).To(gomega.BeNil())
+	err = dbtest.Reset(db)
+	g.Expect(err).To(gomega.BeNil())
+	err = migration.Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	t.Cleanup(func() {
+		_ = dbtest.Reset(db)
+		dbtest.Close(db)
+	})
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	router.Use(gin.CustomRecovery(Recovered))
+	router.Use(RequestIdentifier)
+	router.NoRoute(NoRoute)
+	for _, handler := range All() {
+		handler.With(db, nil)
+		handler.AddRoutes(router)
+	}
+	return
+}
@@ -1,12 +1,30 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"github.com/konveyor/tackle2-hub/bucket"
 	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
-	"os"
 	pathlib "path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Kind
+const (
+	BucketKind = "bucket"
 )
 
 //
@@ -20,6 +38,29 @@ const (
 	AppBucketContentRoot = AppBucketRoot + "/content/*" + Wildcard
 )
 
+//
+// BucketFilter filterable fields.
+var BucketFilter = FilterFields{
+	"id":             {Column: "ID", Kind: FilterInteger},
+	"name":           {Column: "Name", Kind: FilterString},
+	"application.id": {Column: "ApplicationID", Kind: FilterInteger},
+}
+
+//
+// Headers
+const (
+	// Directory reports the content is a directory (archive).
+	Directory = "X-Directory"
+)
+
+//
+// Params
+const (
+	Archive  = "archive"
+	Depth    = "depth"
+	Checksum = "checksum"
+)
+
 //
 // BucketHandler handles bucket routes.
 type BucketHandler struct {
@@ -33,13 +74,29 @@ func (h BucketHandler) AddRoutes(e *gin.Engine) {
 	e.GET(BucketsRoot+"/", h.List)
 	e.POST(BucketsRoot, h.Create)
 	e.GET(BucketRoot, h.Get)
+	e.PUT(BucketRoot, h.Update)
+	e.PATCH(BucketRoot, h.Patch)
 	e.DELETE(BucketRoot, h.Delete)
 	e.GET(BucketContent, h.GetContent)
+	e.HEAD(BucketContent, h.GetContent)
+	e.PUT(BucketContent, h.PutContent)
+	e.POST(BucketContent, h.PutContent)
+	e.DELETE(BucketContent, h.DeleteContent)
+	e.GET(BucketVersionsRoot, h.ListVersions)
+	e.POST(BucketVersionsRoot, h.CreateVersion)
+	e.GET(BucketVersionRoot, h.GetVersion)
+	e.GET(BucketVersionContent, h.VersionContent)
+	e.HEAD(BucketVersionContent, h.VersionContent)
+	e.GET(BucketVersionDiff, h.VersionDiff)
 	e.GET(AppBucketsRoot, h.AppList)
 	e.GET(AppBucketsRoot+"/", h.AppList)
 	e.GET(AppBucketRoot+"/", h.AppGet)
 	e.POST(AppBucketRoot, h.AppCreate)
 	e.GET(AppBucketContentRoot, h.AppContent)
+	e.HEAD(AppBucketContentRoot, h.AppContent)
+	e.PUT(AppBucketContentRoot, h.AppPutContent)
+	e.POST(AppBucketContentRoot, h.AppPutContent)
+	e.DELETE(AppBucketContentRoot, h.AppDeleteContent)
 }
 
 // Get godoc
@@ -48,6 +105,7 @@ func (h BucketHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} Bucket
+// @header 200 {string} ETag "Revision"
 // @router /buckets/{id} [get]
 // @param id path string true "Bucket ID"
 func (h BucketHandler) Get(ctx *gin.Context) {
@@ -61,6 +119,7 @@ func (h BucketHandler) Get(ctx *gin.Context) {
 	r := Bucket{}
 	r.With(m)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -71,10 +130,23 @@ func (h BucketHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []Bucket
 // @router /buckets [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h BucketHandler) List(ctx *gin.Context) {
+	var count int64
 	var list []model.Bucket
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, BucketFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, BucketFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(&model.Bucket{}).Count(&count)
+	db = pagination.apply(db)
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -87,7 +159,7 @@ func (h BucketHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, BucketKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -101,8 +173,13 @@ func (h BucketHandler) List(ctx *gin.Context) {
 // @param bucket body Bucket true "Bucket data"
 func (h BucketHandler) Create(ctx *gin.Context) {
 	r := &Bucket{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	if r.Quota != 0 && !h.admin(ctx) {
+		h.forbidden(ctx, "bucket quota requires authorization.")
 		return
 	}
 	err = h.create(r)
@@ -114,6 +191,112 @@ func (h BucketHandler) Create(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, r)
 }
 
+// Update godoc
+// @summary Update a bucket.
+// @description Update a bucket.
+// @description Only the quota and expiration may be updated.
+// @description The ttl (seconds) sets the expiration.
+// @tags update
+// @accept json
+// @success 204
+// @failure 412
+// @router /buckets/{id} [put]
+// @param id path string true "Bucket ID"
+// @param bucket body Bucket true "Bucket data"
+// @param If-Match header string false "Revision (ETag)"
+func (h BucketHandler) Update(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	r := &Bucket{}
+	err := ctx.ShouldBindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := &model.Bucket{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, id)
+	if !matched {
+		return
+	}
+	if r.Quota != m.Quota && !h.admin(ctx) {
+		h.forbidden(ctx, "bucket quota requires authorization.")
+		return
+	}
+	updated := r.Model()
+	result = p.Where(h.DB).Model(m).Updates(
+		map[string]interface{}{
+			"Quota":      updated.Quota,
+			"Expiration": updated.Expiration,
+		})
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a bucket.
+// @description Patch a bucket using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared. Only the quota and
+// @description expiration (ttl) may be changed.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /buckets/{id} [patch]
+// @param id path string true "Bucket ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h BucketHandler) Patch(ctx *gin.Context) {
+	m := &model.Bucket{}
+	result := h.DB.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Bucket{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	if r.Quota != m.Quota && !h.admin(ctx) {
+		h.forbidden(ctx, "bucket quota requires authorization.")
+		return
+	}
+	patched := r.Model()
+	result = p.Where(h.DB).Model(m).Updates(
+		map[string]interface{}{
+			"Quota":      patched.Quota,
+			"Expiration": patched.Expiration,
+		})
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 // Delete godoc
 // @summary Delete a bucket.
 // @description Delete a bucket.
@@ -121,6 +304,7 @@ func (h BucketHandler) Create(ctx *gin.Context) {
 // @success 204 {object} Bucket
 // @router /buckets/{id} [delete]
 // @param id path string true "Bucket ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h BucketHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	m := &model.Bucket{}
@@ -129,11 +313,19 @@ func (h BucketHandler) Delete(ctx *gin.Context) {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
-	result = h.DB.Delete(m, id)
+	p, matched := h.precondition(ctx, m, id)
+	if !matched {
+		return
+	}
+	result = p.Where(h.DB).Delete(m, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -141,11 +333,21 @@ func (h BucketHandler) Delete(ctx *gin.Context) {
 // GetContent godoc
 // @summary Get bucket content by ID and path.
 // @description Get bucket content by ID and path.
+// @description A directory is listed (JSON) unless an archive is requested
+// @description by the Accept header or query.
+// @description Files are served with a (strong) ETag and Last-Modified and
+// @description support conditional (If-None-Match, If-Modified-Since) and
+// @description Range requests.
 // @tags get
-// @produce json
-// @success 200 {object}
+// @produce octet-stream,json
+// @success 200 {object} []BucketEntry
+// @success 206
+// @success 304
 // @router /bucket/{id}/content/* [get]
 // @param id path string true "Bucket ID"
+// @param archive query string false "Archive format (tar|tar.gz|zip)"
+// @param depth query int false "Listing depth (default: 1)"
+// @param checksum query bool false "Listing includes (sha256) checksums"
 func (h BucketHandler) GetContent(ctx *gin.Context) {
 	rPath := ctx.Param(Wildcard)
 	m := &model.Bucket{}
@@ -155,9 +357,55 @@ func (h BucketHandler) GetContent(ctx *gin.Context) {
 		h.getFailed(ctx, result.Error)
 		return
 	}
-	ctx.File(pathlib.Join(
-		m.Path,
-		rPath))
+	h.getContent(ctx, m, rPath)
+}
+
+// PutContent godoc
+// @summary Upload bucket content by ID and path.
+// @description Upload bucket content by ID and path.
+// @description The request body is streamed to the file at the path.
+// @description A multipart (form-data) request stores each file part
+// @description in the directory at the path.
+// @description An archive (by Content-Type or query) is extracted into
+// @description the directory at the path.
+// @tags update
+// @accept octet-stream,mpfd
+// @success 201
+// @success 204
+// @router /buckets/{id}/content/{wildcard} [put]
+// @router /buckets/{id}/content/{wildcard} [post]
+// @param id path string true "Bucket ID"
+// @param wildcard path string true "Content path"
+// @param archive query string false "Archive format (tar|tar.gz|zip)"
+func (h BucketHandler) PutContent(ctx *gin.Context) {
+	m := &model.Bucket{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	h.putContent(ctx, m)
+}
+
+// DeleteContent godoc
+// @summary Delete bucket content by ID and path.
+// @description Delete the file or directory (recursively) by ID and path.
+// @description Deleting the root empties the bucket.
+// @tags delete
+// @success 204
+// @router /buckets/{id}/content/{wildcard} [delete]
+// @param id path string true "Bucket ID"
+// @param wildcard path string true "Content path"
+func (h BucketHandler) DeleteContent(ctx *gin.Context) {
+	m := &model.Bucket{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+	h.deleteContent(ctx, m)
 }
 
 // AppList godoc
@@ -168,12 +416,26 @@ func (h BucketHandler) GetContent(ctx *gin.Context) {
 // @success 200 {object} []Bucket
 // @router /application-inventory/application/{id}/buckets [get]
 // @param id path int true "Application ID"
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h BucketHandler) AppList(ctx *gin.Context) {
+	var count int64
 	var list []model.Bucket
 	appId := ctx.Param(ID)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
-	db = db.Where("applicationid", appId)
+	filter, err := NewFilter(ctx, BucketFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, BucketFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db = db.Where("ApplicationID", appId)
+	db = db.Session(&gorm.Session{})
+	db.Model(&model.Bucket{}).Count(&count)
+	db = pagination.apply(db)
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -186,7 +448,7 @@ func (h BucketHandler) AppList(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, BucketKind, resources, int(count), pagination.Next(list))
 }
 
 // AppGet godoc
@@ -202,7 +464,7 @@ func (h BucketHandler) AppGet(ctx *gin.Context) {
 	m := &model.Bucket{}
 	appId := ctx.Param(ID)
 	name := ctx.Param(Name)
-	db := h.DB.Where("applicationID", appId).Where("name", name)
+	db := h.DB.Where("ApplicationID", appId).Where("Name", name)
 	result := db.First(m)
 	if result.Error != nil {
 		h.getFailed(ctx, result.Error)
@@ -249,36 +511,528 @@ func (h BucketHandler) AppCreate(ctx *gin.Context) {
 // AppContent godoc
 // @summary Get bucket content by application ID, bucket name and path.
 // @description Get bucket content by application ID, bucket name and path.
+// @description A directory is listed (JSON) unless an archive is requested
+// @description by the Accept header or query.
+// @description Files support conditional and Range requests.
 // @tags get
-// @produce json
-// @success 200 {object}
+// @produce octet-stream,json
+// @success 200 {object} []BucketEntry
+// @success 206
+// @success 304
 // @router /application-inventory/application/{id}/buckets/{name}/content/* [get]
 // @param id path string true "Bucket ID"
 // @param name path string true "Bucket Name"
+// @param archive query string false "Archive format (tar|tar.gz|zip)"
+// @param depth query int false "Listing depth (default: 1)"
+// @param checksum query bool false "Listing includes (sha256) checksums"
 func (h BucketHandler) AppContent(ctx *gin.Context) {
 	rPath := ctx.Param(Wildcard)
 	appID := ctx.Param(ID)
 	name := ctx.Param(Name)
 	m := &model.Bucket{}
-	db := h.DB.Where("applicationID", appID).Where("name", name)
+	db := h.DB.Where("ApplicationID", appID).Where("Name", name)
+	result := db.First(m)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	h.getContent(ctx, m, rPath)
+}
+
+// AppPutContent godoc
+// @summary Upload bucket content by application ID, bucket name and path.
+// @description Upload bucket content by application ID, bucket name and path.
+// @description The request body is streamed to the file at the path.
+// @description A multipart (form-data) request stores each file part
+// @description in the directory at the path.
+// @description An archive (by Content-Type or query) is extracted into
+// @description the directory at the path.
+// @tags update
+// @accept octet-stream,mpfd
+// @success 201
+// @success 204
+// @router /application-inventory/application/{id}/buckets/{name}/content/{wildcard} [put]
+// @router /application-inventory/application/{id}/buckets/{name}/content/{wildcard} [post]
+// @param id path string true "Application ID"
+// @param name path string true "Bucket Name"
+// @param wildcard path string true "Content path"
+// @param archive query string false "Archive format (tar|tar.gz|zip)"
+func (h BucketHandler) AppPutContent(ctx *gin.Context) {
+	appID := ctx.Param(ID)
+	name := ctx.Param(Name)
+	m := &model.Bucket{}
+	db := h.DB.Where("ApplicationID", appID).Where("Name", name)
 	result := db.First(m)
 	if result.Error != nil {
 		h.getFailed(ctx, result.Error)
 		return
 	}
-	ctx.File(pathlib.Join(
+	h.putContent(ctx, m)
+}
+
+// AppDeleteContent godoc
+// @summary Delete bucket content by application ID, bucket name and path.
+// @description Delete the file or directory (recursively) by application ID, bucket name and path.
+// @description Deleting the root empties the bucket.
+// @tags delete
+// @success 204
+// @router /application-inventory/application/{id}/buckets/{name}/content/{wildcard} [delete]
+// @param id path string true "Application ID"
+// @param name path string true "Bucket Name"
+// @param wildcard path string true "Content path"
+func (h BucketHandler) AppDeleteContent(ctx *gin.Context) {
+	appID := ctx.Param(ID)
+	name := ctx.Param(Name)
+	m := &model.Bucket{}
+	db := h.DB.Where("ApplicationID", appID).Where("Name", name)
+	result := db.First(m)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+	h.deleteContent(ctx, m)
+}
+
+//
+// getContent returns the content at the path.
+// A directory is listed or returned as an archive.
+func (h BucketHandler) getContent(ctx *gin.Context, m *model.Bucket, rPath string) {
+	store := model.Buckets()
+	entry, err := store.Stat(m.Path, rPath)
+	if err != nil {
+		h.pathFailed(ctx, err)
+		return
+	}
+	if !entry.IsDir {
+		reader, err := store.Get(m.Path, entry.Path)
+		if err != nil {
+			h.pathFailed(ctx, err)
+			return
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+		digest, dErr := h.storage().Digest(m, entry)
+		if dErr == nil {
+			ctx.Header("ETag", "\""+digest+"\"")
+		} else {
+			log.Error(
+				dErr,
+				"Bucket digest failed.",
+				"id",
+				m.ID,
+				"path",
+				entry.Path)
+		}
+		http.ServeContent(ctx.Writer, ctx.Request, entry.Name, entry.ModTime, reader)
+		return
+	}
+	format := h.archiveFormat(ctx)
+	if format == "" {
+		h.listContent(ctx, m, entry.Path)
+		return
+	}
+	if bucket.MIME(format) == "" {
+		h.bindFailed(ctx, fmt.Errorf("%w: %s", bucket.ErrFormat, format))
+		return
+	}
+	h.archiveHeaders(ctx, m, entry, format)
+	err = store.Archive(m.Path, entry.Path, format, ctx.Writer)
+	if err != nil {
+		_ = ctx.Error(err)
+		log.Error(
+			err,
+			"Archive failed.",
+			"url",
+			ctx.Request.URL.String())
+	}
+}
+
+//
+// archiveFormat returns the archive format requested by the
+// query or the Accept header.
+func (h BucketHandler) archiveFormat(ctx *gin.Context) (format string) {
+	format = ctx.Query(Archive)
+	if format != "" {
+		return
+	}
+	for _, accepted := range ctx.Request.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accepted, ",") {
+			mediaType, _, _ = mime.ParseMediaType(mediaType)
+			format = bucket.Format(mediaType)
+			if format != "" {
+				return
+			}
+		}
+	}
+	return
+}
+
+//
+// archiveHeaders sets the (directory) archive response headers.
+func (h BucketHandler) archiveHeaders(ctx *gin.Context, m *model.Bucket, entry *bucket.Entry, format string) {
+	name := entry.Name
+	if entry.Path == "" {
+		name = m.Name
+	}
+	name += "." + format
+	ctx.Header("Content-Type", bucket.MIME(format))
+	ctx.Header("Content-Disposition", "attachment; filename=\""+name+"\"")
+	ctx.Header(Directory, "true")
+	ctx.Status(http.StatusOK)
+}
+
+//
+// listContent returns the (JSON) listing of the directory at the path.
+func (h BucketHandler) listContent(ctx *gin.Context, m *model.Bucket, path string) {
+	options, err := h.listOptions(ctx)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	entries, err := model.Buckets().List(m.Path, path, options)
+	if err != nil {
+		h.listFailed(ctx, err)
+		return
+	}
+	h.listed(ctx, entries)
+}
+
+//
+// listOptions returns the listing options requested by the query.
+func (h BucketHandler) listOptions(ctx *gin.Context) (options bucket.ListOptions, err error) {
+	options.Depth = 1
+	if s := ctx.Query(Depth); s != "" {
+		n, pErr := strconv.Atoi(s)
+		if pErr != nil || n < 1 {
+			err = errors.New("depth: must be a positive integer.")
+			return
+		}
+		options.Depth = n
+	}
+	options.Checksum = ctx.Query(Checksum) == "true"
+	return
+}
+
+//
+// listed responds with the listed entries.
+func (h BucketHandler) listed(ctx *gin.Context, entries []bucket.Entry) {
+	resources := []BucketEntry{}
+	for i := range entries {
+		r := BucketEntry{}
+		r.With(&entries[i])
+		resources = append(resources, r)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+//
+// putContent writes the uploaded content.
+// A multipart request is stored as files in the directory at
+// the path. An archive is extracted into the directory at the
+// path. Otherwise, the body is stored as the file at the path.
+// Content exceeding the (bucket or application) quota is rejected.
+func (h BucketHandler) putContent(ctx *gin.Context, m *model.Bucket) {
+	store := model.Buckets()
+	path, err := bucket.Clean(ctx.Param(Wildcard))
+	if err != nil {
+		h.pathFailed(ctx, err)
+		return
+	}
+	remaining, err := h.remaining(m)
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	format := ctx.Query(Archive)
+	if format == "" {
+		format = bucket.Format(mediaType)
+	}
+	var before *bucket.Entry
+	if format == "" && mediaType != "multipart/form-data" && path != "" {
+		before, err = store.Stat(m.Path, path)
+		if err != nil || before.IsDir {
+			before = nil
+		}
+		if before != nil && remaining >= 0 {
+			remaining += before.Size
+		}
+	}
+	if remaining == 0 ||
+		(remaining > 0 && ctx.Request.ContentLength > remaining) {
+		h.tooLarge(ctx, fmt.Errorf("%w: (%d) bytes remaining", bucket.ErrQuota, remaining))
+		return
+	}
+	if format != "" {
+		h.putArchive(ctx, m, path, format, remaining)
+		return
+	}
+	if path == "" {
+		h.bindFailed(ctx, errors.New("path required."))
+		return
+	}
+	if remaining > 0 {
+		ctx.Request.Body = ioutil.NopCloser(
+			bucket.QuotaReader(ctx.Request.Body, remaining))
+	}
+	if mediaType == "multipart/form-data" {
+		reader, err := ctx.Request.MultipartReader()
+		if err != nil {
+			h.bindFailed(ctx, err)
+			return
+		}
+		defer h.account(m)
+		for {
+			part, nErr := reader.NextPart()
+			if nErr != nil {
+				if nErr != io.EOF {
+					if errors.Is(nErr, bucket.ErrQuota) {
+						h.tooLarge(ctx, nErr)
+					} else {
+						h.bindFailed(ctx, nErr)
+					}
+					return
+				}
+				break
+			}
+			fileName := pathlib.Base(pathlib.Clean("/" + part.FileName()))
+			if part.FileName() == "" || fileName == "/" {
+				_ = part.Close()
+				continue
+			}
+			filePath := pathlib.Join(path, fileName)
+			err = store.Put(m.Path, filePath, part)
+			_ = part.Close()
+			h.storage().Forget(m, filePath)
+			if err != nil {
+				h.contentFailed(ctx, err)
+				return
+			}
+		}
+		ctx.Status(http.StatusCreated)
+		return
+	}
+	hash := sha256.New()
+	err = store.Put(m.Path, path, io.TeeReader(ctx.Request.Body, hash))
+	if err != nil {
+		h.storage().Forget(m, path)
+		h.contentFailed(ctx, err)
+		return
+	}
+	after, err := store.Stat(m.Path, path)
+	if err == nil {
+		h.accountPut(m, before, after)
+		h.storage().Remember(m, after, hex.EncodeToString(hash.Sum(nil)))
+	} else {
+		h.account(m)
+		h.storage().Forget(m, path)
+	}
+	if before != nil {
+		ctx.Status(http.StatusNoContent)
+	} else {
+		ctx.Status(http.StatusCreated)
+	}
+}
+
+//
+// putArchive extracts the uploaded archive into the directory at the path.
+// The extracted content is limited to the remaining (quota) bytes.
+func (h BucketHandler) putArchive(ctx *gin.Context, m *model.Bucket, path, format string, remaining int64) {
+	limits := Settings.Hub.Bucket.Archive
+	reader := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limits.UploadLimit)
+	extractLimits := bucket.Limits{
+		Bytes:   limits.ExtractLimit,
+		Entries: limits.EntryLimit,
+	}
+	quota := remaining > 0 && remaining < extractLimits.Bytes
+	if quota {
+		extractLimits.Bytes = remaining
+	}
+	err := model.Buckets().Extract(
 		m.Path,
-		rPath))
+		path,
+		format,
+		reader,
+		extractLimits)
+	h.storage().Forget(m, path)
+	if err != nil {
+		switch {
+		case errors.Is(err, bucket.ErrLimit) && quota:
+			h.tooLarge(ctx, fmt.Errorf("%w: (%d) bytes remaining", bucket.ErrQuota, remaining))
+		case errors.Is(err, bucket.ErrLimit),
+			strings.Contains(err.Error(), "http: request body too large"):
+			h.tooLarge(ctx, err)
+		default:
+			h.bindFailed(ctx, err)
+		}
+		return
+	}
+	h.account(m)
+
+	ctx.Status(http.StatusCreated)
+}
+
+//
+// deleteContent deletes the file or directory at the path.
+// Deleting the root empties the bucket.
+func (h BucketHandler) deleteContent(ctx *gin.Context, m *model.Bucket) {
+	path, err := bucket.Clean(ctx.Param(Wildcard))
+	if err != nil {
+		h.pathFailed(ctx, err)
+		return
+	}
+	err = model.Buckets().Delete(m.Path, path)
+	if err != nil {
+		h.pathFailed(ctx, err)
+		return
+	}
+	h.storage().Forget(m, path)
+	h.account(m)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+//
+// remaining returns the bytes remaining within the bucket and
+// application quotas. The default (settings) quotas are used when
+// not specified. Returns -1 when unlimited.
+func (h BucketHandler) remaining(m *model.Bucket) (n int64, err error) {
+	n = -1
+	limited := false
+	quota := m.Quota
+	if quota == 0 {
+		quota = Settings.Hub.Bucket.Quota.Bucket
+	}
+	if quota > 0 {
+		n = quota - m.Bytes
+		limited = true
+	}
+	application := &model.Application{}
+	result := h.DB.Select("BucketQuota").First(application, m.ApplicationID)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	quota = application.BucketQuota
+	if quota == 0 {
+		quota = Settings.Hub.Bucket.Quota.Application
+	}
+	if quota > 0 {
+		var used int64
+		db := h.DB.Model(&model.Bucket{})
+		db = db.Select("COALESCE(SUM(?), 0)", clause.Column{Name: "Bytes"})
+		db = db.Where("ApplicationID", m.ApplicationID)
+		result = db.Scan(&used)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		if !limited || quota-used < n {
+			n = quota - used
+		}
+		limited = true
+	}
+	if limited && n < 0 {
+		n = 0
+	}
+	return
+}
+
+//
+// account updates the bucket usage.
+// The bucket content is measured.
+func (h BucketHandler) account(m *model.Bucket) {
+	usage, err := bucket.Measure(model.Buckets(), m.Path)
+	if err == nil {
+		m.Bytes = usage.Bytes
+		m.Files = usage.Files
+		result := model.Bookkeeping(h.DB).Model(m).Select("Bytes", "Files").Updates(m)
+		err = result.Error
+	}
+	if err != nil {
+		log.Error(
+			err,
+			"Bucket usage accounting failed.",
+			"id",
+			m.ID)
+	}
+}
+
+//
+// accountPut updates the bucket usage for the (after) file
+// written. The (optional) before entry is the replaced file.
+func (h BucketHandler) accountPut(m *model.Bucket, before, after *bucket.Entry) {
+	bytes := after.Size
+	files := 1
+	if before != nil {
+		bytes -= before.Size
+		files = 0
+	}
+	result := model.Bookkeeping(h.DB).Model(m).Updates(
+		map[string]interface{}{
+			"Bytes": gorm.Expr("? + ?", clause.Column{Name: "Bytes"}, bytes),
+			"Files": gorm.Expr("? + ?", clause.Column{Name: "Files"}, files),
+		})
+	if result.Error != nil {
+		log.Error(
+			result.Error,
+			"Bucket usage accounting failed.",
+			"id",
+			m.ID)
+	}
+}
+
+//
+// storage returns the bucket storage manager.
+func (h BucketHandler) storage() (manager *storage.Manager) {
+	manager = &storage.Manager{DB: h.DB}
+	return
+}
+
+//
+// contentFailed handles content (write) errors.
+// Paths not within the bucket are bad requests and
+// content exceeding the quota is too large.
+func (h BucketHandler) contentFailed(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, bucket.ErrPath):
+		h.bindFailed(ctx, err)
+	case errors.Is(err, bucket.ErrQuota):
+		h.tooLarge(ctx, err)
+	default:
+		h.updateFailed(ctx, err)
+	}
+}
+
+//
+// tooLarge reports content too large.
+func (h BucketHandler) tooLarge(ctx *gin.Context, err error) {
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusRequestEntityTooLarge,
+			CodeTooLarge,
+			err.Error()))
+}
+
+//
+// pathFailed handles path resolution errors.
+// Paths not within the bucket are bad requests.
+func (h BucketHandler) pathFailed(ctx *gin.Context, err error) {
+	if errors.Is(err, bucket.ErrPath) {
+		h.bindFailed(ctx, err)
+		return
+	}
+	h.getFailed(ctx, err)
 }
 
 //
 // create a bucket.
 func (h BucketHandler) create(r *Bucket) (err error) {
-	uid := uuid.New()
-	r.Path = pathlib.Join(
-		Settings.Hub.Bucket.Path,
-		uid.String())
-	err = os.MkdirAll(r.Path, 0777)
+	store := model.Buckets()
+	r.Path, err = store.Create()
 	if err != nil {
 		return
 	}
@@ -287,7 +1041,7 @@ func (h BucketHandler) create(r *Bucket) (err error) {
 	result := h.DB.Create(m)
 	err = result.Error
 	if err != nil {
-		_ = os.Remove(r.Path)
+		_ = store.Remove(r.Path)
 	}
 	r.With(m)
 
@@ -298,9 +1052,21 @@ func (h BucketHandler) create(r *Bucket) (err error) {
 // Bucket REST Resource.
 type Bucket struct {
 	Resource
-	Name          string `json:"name" binding:"alphanum|containsany=_-"`
-	Path          string `json:"path"`
-	ApplicationID uint   `json:"application" binding:"required"`
+	Name          string      `json:"name" binding:"alphanum|containsany=_-"`
+	Path          string      `json:"path"`
+	ApplicationID uint        `json:"application" binding:"required"`
+	Quota         int64       `json:"quota" binding:"min=0"`
+	Usage         BucketUsage `json:"usage"`
+	TTL           int64       `json:"ttl,omitempty" binding:"min=0"`
+	Expiration    *time.Time  `json:"expiration,omitempty"`
+}
+
+//
+// BucketUsage REST nested resource.
+// The (tracked) bucket usage.
+type BucketUsage struct {
+	Bytes int64 `json:"bytes"`
+	Files int64 `json:"files"`
 }
 
 //
@@ -310,6 +1076,10 @@ func (r *Bucket) With(m *model.Bucket) {
 	r.Name = m.Name
 	r.Path = m.Path
 	r.ApplicationID = m.ApplicationID
+	r.Quota = m.Quota
+	r.Usage.Bytes = m.Bytes
+	r.Usage.Files = m.Files
+	r.Expiration = m.Expiration
 }
 
 //
@@ -319,8 +1089,39 @@ func (r *Bucket) Model() (m *model.Bucket) {
 		Name:          r.Name,
 		Path:          r.Path,
 		ApplicationID: r.ApplicationID,
+		Quota:         r.Quota,
+		Expiration:    r.Expiration,
 	}
 	m.ID = r.ID
+	if r.TTL > 0 {
+		expiration := time.Now().Add(time.Duration(r.TTL) * time.Second)
+		m.Expiration = &expiration
+	}
 
 	return
 }
+
+//
+// BucketEntry REST resource.
+// A bucket (directory) content entry.
+type BucketEntry struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Mode     string    `json:"mode"`
+	ModTime  time.Time `json:"mtime"`
+	IsDir    bool      `json:"isDir"`
+	Checksum string    `json:"checksum,omitempty"`
+}
+
+//
+// With updates the resource with the entry.
+func (r *BucketEntry) With(entry *bucket.Entry) {
+	r.Name = entry.Name
+	r.Path = entry.Path
+	r.Size = entry.Size
+	r.Mode = entry.Mode.String()
+	r.ModTime = entry.ModTime
+	r.IsDir = entry.IsDir
+	r.Checksum = entry.Checksum
+}
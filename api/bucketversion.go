@@ -0,0 +1,348 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"strconv"
+)
+
+//
+// Kind
+const (
+	BucketVersionKind = "bucket-version"
+)
+
+//
+// Routes
+const (
+	BucketVersionsRoot   = BucketRoot + "/versions"
+	BucketVersionRoot    = BucketVersionsRoot + "/:" + Version
+	BucketVersionContent = BucketVersionRoot + "/content/*" + Wildcard
+	BucketVersionDiff    = BucketVersionRoot + "/diff"
+)
+
+//
+// BucketVersionFilter filterable fields.
+var BucketVersionFilter = FilterFields{
+	"version": {Column: "Version", Kind: FilterInteger},
+	"digest":  {Column: "Digest", Kind: FilterString},
+	"task.id": {Column: "TaskID", Kind: FilterInteger},
+}
+
+//
+// Params
+const (
+	Version = "version"
+	From    = "from"
+)
+
+// ListVersions godoc
+// @summary List bucket versions.
+// @description List bucket versions (snapshots).
+// @tags get
+// @produce json
+// @success 200 {object} []BucketVersion
+// @router /buckets/{id}/versions [get]
+// @param id path string true "Bucket ID"
+// @param filter query string false "Filter expression (see: api.Filter)"
+func (h BucketHandler) ListVersions(ctx *gin.Context) {
+	m := &model.Bucket{}
+	result := h.DB.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	var count int64
+	var list []model.BucketVersion
+	filter, err := NewFilter(ctx, BucketVersionFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, BucketVersionFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db = db.Where("BucketID", m.ID)
+	db = db.Session(&gorm.Session{})
+	db.Model(&model.BucketVersion{}).Count(&count)
+	db = pagination.apply(db)
+	db = db.Omit("Manifest").Order(clause.OrderByColumn{Column: clause.Column{Name: "Version"}})
+	result = db.Find(&list)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	resources := []BucketVersion{}
+	for i := range list {
+		r := BucketVersion{}
+		r.With(&list[i])
+		resources = append(resources, r)
+	}
+
+	h.listResponse(ctx, BucketVersionKind, resources, int(count), pagination.Next(list))
+}
+
+// CreateVersion godoc
+// @summary Create a bucket version.
+// @description Create a bucket version (snapshot) of the current content.
+// @description Versions are immutable and the content is stored (once)
+// @description by digest.
+// @tags create
+// @produce json
+// @success 201 {object} BucketVersion
+// @router /buckets/{id}/versions [post]
+// @param id path string true "Bucket ID"
+func (h BucketHandler) CreateVersion(ctx *gin.Context) {
+	m := &model.Bucket{}
+	result := h.DB.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	version, err := h.storage().Snapshot(m, nil)
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+	r := BucketVersion{}
+	r.With(version)
+
+	ctx.JSON(http.StatusCreated, r)
+}
+
+// GetVersion godoc
+// @summary Get a bucket version.
+// @description Get a bucket version (snapshot).
+// @tags get
+// @produce json
+// @success 200 {object} BucketVersion
+// @router /buckets/{id}/versions/{version} [get]
+// @param id path string true "Bucket ID"
+// @param version path int true "Version"
+func (h BucketHandler) GetVersion(ctx *gin.Context) {
+	version, found := h.version(ctx, false)
+	if !found {
+		return
+	}
+	r := BucketVersion{}
+	r.With(version)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+// VersionContent godoc
+// @summary Get bucket version content by path.
+// @description Get bucket version content by path.
+// @description A directory is listed (JSON) unless an archive is requested
+// @description by the Accept header or query. Files are served with the
+// @description content digest as the ETag.
+// @tags get
+// @produce octet-stream,json
+// @success 200 {object} []BucketEntry
+// @success 206
+// @success 304
+// @router /buckets/{id}/versions/{version}/content/* [get]
+// @param id path string true "Bucket ID"
+// @param version path int true "Version"
+// @param archive query string false "Archive format (tar|tar.gz|zip)"
+// @param depth query int false "Listing depth (default: 1)"
+// @param checksum query bool false "Listing includes (sha256) checksums"
+func (h BucketHandler) VersionContent(ctx *gin.Context) {
+	version, found := h.version(ctx, true)
+	if !found {
+		return
+	}
+	m := &model.Bucket{}
+	result := h.DB.First(m, version.BucketID)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	manifest, err := h.storage().Manifest(version)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	entry, err := manifest.Stat(ctx.Param(Wildcard))
+	if err != nil {
+		h.pathFailed(ctx, err)
+		return
+	}
+	store := model.Buckets()
+	if !entry.IsDir {
+		reader, err := manifest.Get(store, m.Objects, entry.Path)
+		if err != nil {
+			h.pathFailed(ctx, err)
+			return
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+		ctx.Header("ETag", "\""+entry.Checksum+"\"")
+		http.ServeContent(ctx.Writer, ctx.Request, entry.Name, entry.ModTime, reader)
+		return
+	}
+	format := h.archiveFormat(ctx)
+	if format == "" {
+		options, err := h.listOptions(ctx)
+		if err != nil {
+			h.bindFailed(ctx, err)
+			return
+		}
+		entries, err := manifest.List(entry.Path, options)
+		if err != nil {
+			h.listFailed(ctx, err)
+			return
+		}
+		h.listed(ctx, entries)
+		return
+	}
+	if bucket.MIME(format) == "" {
+		h.bindFailed(ctx, fmt.Errorf("%w: %s", bucket.ErrFormat, format))
+		return
+	}
+	h.archiveHeaders(ctx, m, entry, format)
+	err = manifest.Archive(store, m.Objects, entry.Path, format, ctx.Writer)
+	if err != nil {
+		_ = ctx.Error(err)
+		log.Error(
+			err,
+			"Archive failed.",
+			"url",
+			ctx.Request.URL.String())
+	}
+}
+
+// VersionDiff godoc
+// @summary Diff bucket versions.
+// @description List the files added, removed and changed (content)
+// @description between versions. Compared with the previous version
+// @description unless specified; 0 = empty.
+// @tags get
+// @produce json
+// @success 200 {object} BucketDiff
+// @router /buckets/{id}/versions/{version}/diff [get]
+// @param id path string true "Bucket ID"
+// @param version path int true "Version"
+// @param from query int false "Version compared (default: previous)"
+func (h BucketHandler) VersionDiff(ctx *gin.Context) {
+	version, found := h.version(ctx, true)
+	if !found {
+		return
+	}
+	from := version.Version - 1
+	if s := ctx.Query(From); s != "" {
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			h.bindFailed(ctx, errors.New("from: must be a version number."))
+			return
+		}
+		from = uint(n)
+	}
+	previous := &model.BucketVersion{}
+	if from > 0 {
+		result := h.DB.Take(
+			previous,
+			"BucketID = ? AND Version = ?",
+			version.BucketID,
+			from)
+		if result.Error != nil {
+			h.getFailed(ctx, result.Error)
+			return
+		}
+	}
+	manager := h.storage()
+	manifest, err := manager.Manifest(version)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	previousManifest, err := manager.Manifest(previous)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	diff := manifest.Compare(previousManifest)
+	r := BucketDiff{
+		From:    from,
+		To:      version.Version,
+		Added:   diff.Added,
+		Removed: diff.Removed,
+		Changed: diff.Changed,
+	}
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+//
+// version returns the version specified by the bucket ID
+// and version params. Responds with the error when not found.
+// The manifest is fetched only when requested.
+func (h BucketHandler) version(ctx *gin.Context, manifest bool) (version *model.BucketVersion, found bool) {
+	n, err := strconv.ParseUint(ctx.Param(Version), 10, 32)
+	if err != nil {
+		h.bindFailed(ctx, errors.New("version: must be a version number."))
+		return
+	}
+	version = &model.BucketVersion{}
+	db := h.DB
+	if !manifest {
+		db = db.Omit("Manifest")
+	}
+	result := db.Take(
+		version,
+		"BucketID = ? AND Version = ?",
+		ctx.Param(ID),
+		n)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	found = true
+	return
+}
+
+//
+// BucketVersion REST resource.
+// An (immutable) snapshot of the bucket content.
+type BucketVersion struct {
+	Resource
+	Bucket  uint   `json:"bucket"`
+	Version uint   `json:"version"`
+	Digest  string `json:"digest"`
+	Bytes   int64  `json:"bytes"`
+	Files   int64  `json:"files"`
+	Task    *uint  `json:"task,omitempty"`
+}
+
+//
+// With updates the resource with the model.
+func (r *BucketVersion) With(m *model.BucketVersion) {
+	r.Resource.With(&m.Model)
+	r.Bucket = m.BucketID
+	r.Version = m.Version
+	r.Digest = m.Digest
+	r.Bytes = m.Bytes
+	r.Files = m.Files
+	r.Task = m.TaskID
+}
+
+//
+// BucketDiff REST resource.
+// The files added, removed and changed between versions.
+type BucketDiff struct {
+	From    uint     `json:"from"`
+	To      uint     `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
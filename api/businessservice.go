@@ -19,6 +19,15 @@ const (
 	BusinessServiceRoot  = BusinessServicesRoot + "/:" + ID
 )
 
+//
+// BusinessServiceFilter filterable fields.
+var BusinessServiceFilter = FilterFields{
+	"id":          {Column: "ID", Kind: FilterInteger},
+	"name":        {Column: "Name", Kind: FilterString},
+	"description": {Column: "Description", Kind: FilterString},
+	"owner.id":    {Column: "OwnerID", Kind: FilterInteger},
+}
+
 //
 // BusinessServiceHandler handles business-service routes.
 type BusinessServiceHandler struct {
@@ -33,6 +42,7 @@ func (h BusinessServiceHandler) AddRoutes(e *gin.Engine) {
 	e.POST(BusinessServicesRoot, h.Create)
 	e.GET(BusinessServiceRoot, h.Get)
 	e.PUT(BusinessServiceRoot, h.Update)
+	e.PATCH(BusinessServiceRoot, h.Patch)
 	e.DELETE(BusinessServiceRoot, h.Delete)
 }
 
@@ -42,6 +52,7 @@ func (h BusinessServiceHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.BusinessService
+// @header 200 {string} ETag "Revision"
 // @router /controls/business-service/{id} [get]
 // @param id path string true "Business Service ID"
 func (h BusinessServiceHandler) Get(ctx *gin.Context) {
@@ -56,6 +67,7 @@ func (h BusinessServiceHandler) Get(ctx *gin.Context) {
 
 	resource := BusinessService{}
 	resource.With(m)
+	h.etag(ctx, resource.Revision)
 	ctx.JSON(http.StatusOK, resource)
 }
 
@@ -66,12 +78,23 @@ func (h BusinessServiceHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} api.BusinessService
 // @router /controls/business-service [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h BusinessServiceHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.BusinessService
-	h.DB.Model(&model.BusinessService{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, BusinessServiceFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(&model.BusinessService{}).Count(&count)
+	pagination, err := NewPagination(ctx, BusinessServiceFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "Owner")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -85,7 +108,7 @@ func (h BusinessServiceHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, BusinessServiceKind, resources, int(count))
+	h.listResponse(ctx, BusinessServiceKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -99,7 +122,7 @@ func (h BusinessServiceHandler) List(ctx *gin.Context) {
 // @param business_service body api.BusinessService true "Business service data"
 func (h BusinessServiceHandler) Create(ctx *gin.Context) {
 	r := &BusinessService{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -120,15 +143,25 @@ func (h BusinessServiceHandler) Create(ctx *gin.Context) {
 // @description Delete a business service.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /controls/business-service/{id} [delete]
 // @param id path string true "Business service ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h BusinessServiceHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
-	result := h.DB.Delete(&model.BusinessService{}, id)
+	p, matched := h.precondition(ctx, &model.BusinessService{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Delete(&model.BusinessService{}, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -139,23 +172,84 @@ func (h BusinessServiceHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /controls/business-service/{id} [put]
 // @param id path string true "Business service ID"
 // @param business_service body api.BusinessService true "Business service data"
+// @param If-Match header string false "Revision (ETag)"
 func (h BusinessServiceHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &BusinessService{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.BusinessService{}, id)
+	if !matched {
+		return
+	}
 	updates := r.Model()
-	result := h.DB.Model(&model.BusinessService{}).Where("id = ?", id).Omit("id").Updates(updates)
+	result := p.Where(h.DB).Model(&model.BusinessService{}).Where("ID", id).Omit("id").Updates(updates)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a business service.
+// @description Patch a business service using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /controls/business-service/{id} [patch]
+// @param id path string true "Business service ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h BusinessServiceHandler) Patch(ctx *gin.Context) {
+	m := &model.BusinessService{}
+	db := h.preLoad(h.DB, "Owner")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &BusinessService{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	fields := []string{
+		"Name",
+		"Description",
+		"OwnerID",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
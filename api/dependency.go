@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
 	"net/http"
 )
 
@@ -19,6 +20,14 @@ const (
 	DependencyRoot   = DependenciesRoot + "/:" + ID
 )
 
+//
+// DependencyFilter filterable fields.
+var DependencyFilter = FilterFields{
+	"id":      {Column: "ID", Kind: FilterInteger},
+	"to.id":   {Column: "ToID", Kind: FilterInteger},
+	"from.id": {Column: "FromID", Kind: FilterInteger},
+}
+
 //
 // DependencyHandler handles application dependency routes.
 type DependencyHandler struct {
@@ -41,6 +50,7 @@ func (h DependencyHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.Dependency
+// @header 200 {string} ETag "Revision"
 // @router /application-inventory/applications-dependency/{id} [get]
 // @param id path string true "Dependency ID"
 func (h DependencyHandler) Get(ctx *gin.Context) {
@@ -55,6 +65,7 @@ func (h DependencyHandler) Get(ctx *gin.Context) {
 	r := Dependency{}
 	r.With(m)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -66,21 +77,31 @@ func (h DependencyHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Dependency
 // @router /application-inventory/applications-dependency [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h DependencyHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.Dependency
-
-	db := h.DB
+	filter, err := NewFilter(ctx, DependencyFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
 	to := ctx.Query("to.id")
 	from := ctx.Query("from.id")
 	if to != "" {
-		db = db.Where("toid = ?", to)
+		db = db.Where("ToID", to)
 	} else if from != "" {
-		db = db.Where("fromid = ?", from)
+		db = db.Where("FromID", from)
 	}
 
+	db = db.Session(&gorm.Session{})
 	db.Model(model.Dependency{}).Count(&count)
-	pagination := NewPagination(ctx)
+	pagination, err := NewPagination(ctx, DependencyFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
 	db = pagination.apply(db)
 	db = h.preLoad(db, "To", "From")
 	result := db.Find(&list)
@@ -96,7 +117,7 @@ func (h DependencyHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, DependencyKind, resources, int(count))
+	h.listResponse(ctx, DependencyKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -110,9 +131,9 @@ func (h DependencyHandler) List(ctx *gin.Context) {
 // @param applications_dependency body Dependency true "Dependency data"
 func (h DependencyHandler) Create(ctx *gin.Context) {
 	r := Dependency{}
-	err := ctx.BindJSON(&r)
+	err := ctx.ShouldBindJSON(&r)
 	if err != nil {
-		h.createFailed(ctx, err)
+		h.bindFailed(ctx, err)
 		return
 	}
 	m := r.Model()
@@ -131,15 +152,25 @@ func (h DependencyHandler) Create(ctx *gin.Context) {
 // @tags delete
 // @accept json
 // @success 204
+// @failure 412
 // @router /application-inventory/applications-dependency/{id} [delete]
 // @param id path string true "Dependency id"
+// @param If-Match header string false "Revision (ETag)"
 func (h DependencyHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
-	result := h.DB.Delete(&model.Dependency{}, id)
+	p, matched := h.precondition(ctx, &model.Dependency{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Delete(&model.Dependency{}, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/task"
+	"gorm.io/gorm"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//
+// Routes
+const (
+	EncryptionRoot  = "/encryption"
+	KeyRotationRoot = EncryptionRoot + "/rotation"
+)
+
+//
+// rotation the (single) key rotation.
+var rotation = &keyRotation{}
+
+//
+// EncryptionHandler handles encryption routes.
+type EncryptionHandler struct {
+	BaseHandler
+}
+
+//
+// AddRoutes adds routes.
+func (h EncryptionHandler) AddRoutes(e *gin.Engine) {
+	e.GET(KeyRotationRoot, h.GetRotation)
+	e.POST(KeyRotationRoot, h.Rotate)
+}
+
+// GetRotation godoc
+// @summary Get the status of the key rotation.
+// @description Get the status of the (last) key rotation.
+// @tags get
+// @produce json
+// @success 200 {object} api.KeyRotation
+// @router /encryption/rotation [get]
+func (h EncryptionHandler) GetRotation(ctx *gin.Context) {
+	if !h.admin(ctx) {
+		h.forbidden(ctx, "key rotation requires authorization.")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rotation.Status())
+}
+
+// Rotate godoc
+// @summary Rotate encryption keys.
+// @description Re-encrypt all identities using the current key.
+// @description The identities are re-encrypted in a (single) transaction.
+// @description Progress is reported by the rotation status.
+// @tags create
+// @produce json
+// @success 202 {object} api.KeyRotation
+// @router /encryption/rotation [post]
+func (h EncryptionHandler) Rotate(ctx *gin.Context) {
+	if !h.admin(ctx) {
+		h.forbidden(ctx, "key rotation requires authorization.")
+		return
+	}
+	started := rotation.Start(h.DB)
+	if !started {
+		h.problem(
+			ctx,
+			NewProblem(
+				http.StatusConflict,
+				CodeInProgress,
+				"key rotation in progress."))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, rotation.Status())
+}
+
+//
+// KeyRotation REST resource.
+type KeyRotation struct {
+	Status     string     `json:"status"`
+	KeyID      string     `json:"keyID"`
+	Error      string     `json:"error,omitempty"`
+	Total      int        `json:"total"`
+	Completed  int        `json:"completed"`
+	Rotated    int        `json:"rotated"`
+	Started    *time.Time `json:"started,omitempty"`
+	Terminated *time.Time `json:"terminated,omitempty"`
+}
+
+//
+// keyRotation re-encrypts identities using the current key.
+type keyRotation struct {
+	// status of the (last) rotation.
+	status KeyRotation
+	// mutex protects status.
+	mutex sync.Mutex
+}
+
+//
+// Status returns the status of the rotation.
+func (r *keyRotation) Status() (status KeyRotation) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	status = r.status
+	return
+}
+
+//
+// Start the rotation.
+// Returns false when already running.
+func (r *keyRotation) Start(db *gorm.DB) (started bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.status.Status == task.Running {
+		return
+	}
+	mark := time.Now()
+	r.status = KeyRotation{
+		Status:  task.Running,
+		KeyID:   model.Keyring().Current,
+		Started: &mark,
+	}
+	go func() {
+		err := model.Transaction(db, func(tx *gorm.DB) (err error) {
+			err = r.rotate(tx)
+			return
+		})
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		mark := time.Now()
+		r.status.Terminated = &mark
+		if err != nil {
+			r.status.Status = task.Failed
+			r.status.Error = err.Error()
+			log.Error(err, "Key rotation failed.")
+		} else {
+			r.status.Status = task.Succeeded
+			log.Info(
+				"Key rotation succeeded.",
+				"keyID",
+				r.status.KeyID,
+				"rotated",
+				r.status.Rotated)
+		}
+	}()
+
+	started = true
+	return
+}
+
+//
+// rotate re-encrypts identities not encrypted
+// using the current key. Identities with external
+// secrets are re-stored.
+func (r *keyRotation) rotate(tx *gorm.DB) (err error) {
+	keyring := model.Keyring()
+	list := []model.Identity{}
+	result := tx.Find(&list)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	r.progress(len(list), 0, 0)
+	for i := range list {
+		m := &list[i]
+		if m.Secret == "" && keyring.IsCurrent(m.Encrypted) {
+			r.progress(len(list), i+1, 0)
+			continue
+		}
+		err = m.Decrypt(model.SecretStore())
+		if err != nil {
+			return
+		}
+		m.Encrypted = ""
+		result = tx.Save(m)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		r.progress(len(list), i+1, 1)
+	}
+	return
+}
+
+//
+// progress updates the progress.
+func (r *keyRotation) progress(total, completed, rotated int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status.Total = total
+	r.status.Completed = completed
+	r.status.Rotated += rotated
+}
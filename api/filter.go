@@ -0,0 +1,540 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//
+// Params
+const (
+	FilterParam = "filter"
+)
+
+//
+// Filter field (value) kinds.
+const (
+	FilterString  = "string"
+	FilterInteger = "integer"
+	FilterBoolean = "boolean"
+)
+
+//
+// Filter operators.
+const (
+	FilterEq   = "="
+	FilterNe   = "!="
+	FilterLike = "~"
+	FilterLt   = "<"
+	FilterLe   = "<="
+	FilterGt   = ">"
+	FilterGe   = ">="
+)
+
+//
+// ErrFilter reports an invalid filter.
+var ErrFilter = errors.New("filter")
+
+//
+// FilterField a filterable field.
+type FilterField struct {
+	// Column compared.
+	Column string
+	// Kind of value.
+	Kind string
+	// Table (optional) join table. The field matches when the
+	// (model) ID is selected (by key) from the join table. For !=,
+	// the field matches when the ID is NOT selected using =.
+	Table string
+	// Key column (of the join table) selected.
+	Key string
+}
+
+//
+// FilterFields filterable fields (whitelist) keyed by name.
+type FilterFields map[string]FilterField
+
+//
+// Predicate a filter predicate.
+// A list of values matches any value.
+type Predicate struct {
+	Field    string
+	Operator string
+	Values   []string
+	List     bool
+}
+
+//
+// Filter a parsed filter expression.
+// The syntax is: field op value[,field op value]... where the
+// predicates (separated by commas) must all match. The op is one
+// of: = != ~ < <= > >= and the value is a (bare or single-quoted)
+// literal or a list of literals: (a|b|c) matching any. The ~ (like)
+// operator matches using * as a wildcard.
+// Example: name~'pay*',tag.id=(3|4),businessService.id=2
+type Filter struct {
+	// Predicates (validated).
+	Predicates []Predicate
+	// fields whitelist.
+	fields FilterFields
+}
+
+//
+// NewFilter parses the filter query parameter.
+// The predicates are validated using the field whitelist.
+func NewFilter(ctx *gin.Context, fields FilterFields) (f Filter, err error) {
+	f, err = ParseFilter(ctx.Query(FilterParam), fields)
+	return
+}
+
+//
+// ParseFilter parses the filter expression.
+// The predicates are validated using the field whitelist.
+func ParseFilter(expression string, fields FilterFields) (f Filter, err error) {
+	f.fields = fields
+	p := filterParser{input: []rune(expression)}
+	f.Predicates, err = p.parse()
+	if err != nil {
+		return
+	}
+	for i := range f.Predicates {
+		err = f.validate(&f.Predicates[i])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// Where applies the filter.
+// The returned DB may be shared (by count and find) queries.
+func (f *Filter) Where(db *gorm.DB) (tx *gorm.DB) {
+	tx = db
+	for _, p := range f.Predicates {
+		field := f.fields[p.Field]
+		if field.Table != "" {
+			in := "? IN (?)"
+			if p.Operator == FilterNe {
+				in = "? NOT IN (?)"
+				p.Operator = FilterEq
+			}
+			sql, values := f.condition(field, &p)
+			join := db.Session(&gorm.Session{NewDB: true})
+			join = join.Table(field.Table).Select("?", clause.Column{Name: field.Key})
+			join = join.Where(sql, values...)
+			tx = tx.Where(in, clause.Column{Name: "ID"}, join)
+		} else {
+			sql, values := f.condition(field, &p)
+			tx = tx.Where(sql, values...)
+		}
+	}
+	tx = tx.Session(&gorm.Session{})
+	return
+}
+
+//
+// Match the filter (in memory) using the (string) field values.
+// Used to filter resources not stored in the DB. Values are compared
+// by field kind; integers are compared numerically.
+func (f *Filter) Match(values map[string]string) (matched bool) {
+	for _, p := range f.Predicates {
+		field := f.fields[p.Field]
+		value := values[p.Field]
+		any := false
+		for _, wanted := range p.Values {
+			if p.Operator == FilterLike {
+				any = f.like(value, wanted)
+			} else if n, valid := f.compare(field, value, wanted); valid {
+				switch p.Operator {
+				case FilterEq, FilterNe:
+					any = n == 0
+				case FilterLt:
+					any = n < 0
+				case FilterLe:
+					any = n <= 0
+				case FilterGt:
+					any = n > 0
+				case FilterGe:
+					any = n >= 0
+				}
+			}
+			if any {
+				break
+			}
+		}
+		if p.Operator == FilterNe {
+			any = !any
+		}
+		if !any {
+			return
+		}
+	}
+	matched = true
+	return
+}
+
+//
+// validate the predicate.
+// The field must be whitelisted and the values valid.
+func (f *Filter) validate(p *Predicate) (err error) {
+	field, found := f.fields[p.Field]
+	if !found {
+		err = fmt.Errorf("%w: field '%s' not supported.", ErrFilter, p.Field)
+		return
+	}
+	switch p.Operator {
+	case FilterLike:
+		if field.Kind != FilterString {
+			err = fmt.Errorf("%w: '%s' (%s) does not support ~.", ErrFilter, p.Field, field.Kind)
+			return
+		}
+	case FilterLt, FilterLe, FilterGt, FilterGe:
+		if p.List {
+			err = fmt.Errorf("%w: '%s' %s does not support a list.", ErrFilter, p.Field, p.Operator)
+			return
+		}
+		if field.Kind == FilterBoolean {
+			err = fmt.Errorf("%w: '%s' (%s) does not support %s.", ErrFilter, p.Field, field.Kind, p.Operator)
+			return
+		}
+	}
+	for _, value := range p.Values {
+		switch field.Kind {
+		case FilterInteger:
+			_, pErr := strconv.ParseInt(value, 10, 64)
+			if pErr != nil {
+				err = fmt.Errorf("%w: '%s' must be an integer.", ErrFilter, p.Field)
+				return
+			}
+		case FilterBoolean:
+			_, pErr := strconv.ParseBool(value)
+			if pErr != nil {
+				err = fmt.Errorf("%w: '%s' must be a boolean.", ErrFilter, p.Field)
+				return
+			}
+		}
+	}
+	return
+}
+
+//
+// condition returns the SQL condition and values for the predicate.
+// The (whitelisted) column is quoted (by the dialect) and the LIKE
+// match is case-insensitive.
+func (f *Filter) condition(field FilterField, p *Predicate) (sql string, values []interface{}) {
+	column := clause.Column{Name: field.Column}
+	for _, value := range p.Values {
+		switch field.Kind {
+		case FilterInteger:
+			n, _ := strconv.ParseInt(value, 10, 64)
+			values = append(values, n)
+		case FilterBoolean:
+			b, _ := strconv.ParseBool(value)
+			values = append(values, b)
+		default:
+			values = append(values, value)
+		}
+	}
+	switch p.Operator {
+	case FilterEq:
+		if p.List {
+			sql = "? IN ?"
+			values = []interface{}{column, values}
+		} else {
+			sql = "? = ?"
+			values = []interface{}{column, values[0]}
+		}
+	case FilterNe:
+		if p.List {
+			sql = "? NOT IN ?"
+			values = []interface{}{column, values}
+		} else {
+			sql = "? != ?"
+			values = []interface{}{column, values[0]}
+		}
+	case FilterLike:
+		part := []string{}
+		patterns := []interface{}{}
+		for i := range values {
+			part = append(part, "LOWER(?) LIKE LOWER(?) ESCAPE '\\'")
+			patterns = append(patterns, column, f.pattern(values[i].(string)))
+		}
+		sql = "(" + strings.Join(part, " OR ") + ")"
+		values = patterns
+	default:
+		sql = "? " + p.Operator + " ?"
+		values = []interface{}{column, values[0]}
+	}
+	return
+}
+
+//
+// compare the value to the wanted (validated) value by field kind.
+// Returns -1, 0, +1 when the value is less, equal or greater. Not
+// valid when the value cannot be parsed.
+func (f *Filter) compare(field FilterField, value, wanted string) (n int, valid bool) {
+	switch field.Kind {
+	case FilterInteger:
+		a, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return
+		}
+		b, _ := strconv.ParseInt(wanted, 10, 64)
+		switch {
+		case a < b:
+			n = -1
+		case a > b:
+			n = 1
+		}
+	case FilterBoolean:
+		a, err := strconv.ParseBool(value)
+		if err != nil {
+			return
+		}
+		b, _ := strconv.ParseBool(wanted)
+		if a != b {
+			n = 1
+		}
+	default:
+		n = strings.Compare(value, wanted)
+	}
+	valid = true
+	return
+}
+
+//
+// pattern returns the SQL (LIKE) pattern.
+// The * wildcard matches any characters.
+func (f *Filter) pattern(value string) (pattern string) {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		"%", "\\%",
+		"_", "\\_",
+		"*", "%")
+	pattern = replacer.Replace(value)
+	return
+}
+
+//
+// like returns true when the value matches the (wildcard) pattern.
+func (f *Filter) like(value, pattern string) (matched bool) {
+	part := strings.Split(strings.ToLower(pattern), "*")
+	value = strings.ToLower(value)
+	if !strings.HasPrefix(value, part[0]) {
+		return
+	}
+	value = value[len(part[0]):]
+	last := len(part) - 1
+	for i := 1; i < last; i++ {
+		n := strings.Index(value, part[i])
+		if n < 0 {
+			return
+		}
+		value = value[n+len(part[i]):]
+	}
+	if last > 0 {
+		matched = strings.HasSuffix(value, part[last])
+	} else {
+		matched = value == ""
+	}
+	return
+}
+
+//
+// filterParser filter expression parser.
+type filterParser struct {
+	input []rune
+	pos   int
+}
+
+//
+// parse the expression.
+func (r *filterParser) parse() (predicates []Predicate, err error) {
+	r.skip()
+	if r.end() {
+		return
+	}
+	for {
+		var p Predicate
+		p, err = r.predicate()
+		if err != nil {
+			return
+		}
+		predicates = append(predicates, p)
+		r.skip()
+		if r.end() {
+			break
+		}
+		if r.input[r.pos] != ',' {
+			err = r.unexpected()
+			return
+		}
+		r.pos++
+	}
+	return
+}
+
+//
+// predicate parses: field op value.
+func (r *filterParser) predicate() (p Predicate, err error) {
+	r.skip()
+	start := r.pos
+	for !r.end() {
+		ch := r.input[r.pos]
+		if unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '.' || ch == '_' {
+			r.pos++
+			continue
+		}
+		break
+	}
+	if start == r.pos {
+		err = r.unexpected()
+		return
+	}
+	p.Field = string(r.input[start:r.pos])
+	r.skip()
+	p.Operator, err = r.operator()
+	if err != nil {
+		return
+	}
+	r.skip()
+	if !r.end() && r.input[r.pos] == '(' {
+		r.pos++
+		p.List = true
+		for {
+			var value string
+			value, err = r.literal()
+			if err != nil {
+				return
+			}
+			p.Values = append(p.Values, value)
+			r.skip()
+			if r.end() {
+				err = r.unexpected()
+				return
+			}
+			ch := r.input[r.pos]
+			r.pos++
+			if ch == ')' {
+				break
+			}
+			if ch != '|' {
+				r.pos--
+				err = r.unexpected()
+				return
+			}
+		}
+		switch p.Operator {
+		case FilterEq, FilterNe, FilterLike:
+		default:
+			err = fmt.Errorf("%w: '%s' %s does not support a list.", ErrFilter, p.Field, p.Operator)
+		}
+		return
+	}
+	value, err := r.literal()
+	if err != nil {
+		return
+	}
+	p.Values = []string{value}
+	return
+}
+
+//
+// operator parses the operator.
+func (r *filterParser) operator() (op string, err error) {
+	for _, candidate := range []string{FilterNe, FilterLe, FilterGe, FilterEq, FilterLike, FilterLt, FilterGt} {
+		n := len([]rune(candidate))
+		if r.pos+n <= len(r.input) && string(r.input[r.pos:r.pos+n]) == candidate {
+			op = candidate
+			r.pos += n
+			return
+		}
+	}
+	err = r.unexpected()
+	return
+}
+
+//
+// literal parses a bare or (single) quoted literal.
+// Within quotes, a backslash escapes the next character.
+func (r *filterParser) literal() (value string, err error) {
+	r.skip()
+	if r.end() {
+		err = r.unexpected()
+		return
+	}
+	if r.input[r.pos] == '\'' {
+		r.pos++
+		var b strings.Builder
+		for {
+			if r.end() {
+				err = fmt.Errorf("%w: unterminated quote.", ErrFilter)
+				return
+			}
+			ch := r.input[r.pos]
+			r.pos++
+			if ch == '\\' && !r.end() {
+				b.WriteRune(r.input[r.pos])
+				r.pos++
+				continue
+			}
+			if ch == '\'' {
+				break
+			}
+			b.WriteRune(ch)
+		}
+		value = b.String()
+		return
+	}
+	start := r.pos
+	for !r.end() {
+		ch := r.input[r.pos]
+		if strings.ContainsRune(",|()'", ch) || unicode.IsSpace(ch) {
+			break
+		}
+		r.pos++
+	}
+	if start == r.pos {
+		err = r.unexpected()
+		return
+	}
+	value = string(r.input[start:r.pos])
+	return
+}
+
+//
+// skip whitespace.
+func (r *filterParser) skip() {
+	for !r.end() && unicode.IsSpace(r.input[r.pos]) {
+		r.pos++
+	}
+}
+
+//
+// end returns true at the end of the input.
+func (r *filterParser) end() (b bool) {
+	b = r.pos >= len(r.input)
+	return
+}
+
+//
+// unexpected returns an unexpected (input) error.
+func (r *filterParser) unexpected() (err error) {
+	if r.end() {
+		err = fmt.Errorf("%w: unexpected end.", ErrFilter)
+	} else {
+		err = fmt.Errorf(
+			"%w: unexpected '%c' at %d.",
+			ErrFilter,
+			r.input[r.pos],
+			r.pos)
+	}
+	return
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"errors"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"testing"
+)
+
+func TestFilterParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	cases := []struct {
+		expression string
+		predicates []Predicate
+		invalid    bool
+	}{
+		{
+			expression: "",
+		},
+		{
+			expression: "name=A",
+			predicates: []Predicate{
+				{Field: "name", Operator: FilterEq, Values: []string{"A"}},
+			},
+		},
+		{
+			expression: " name ~ 'pay*' , id>=2 ",
+			predicates: []Predicate{
+				{Field: "name", Operator: FilterLike, Values: []string{"pay*"}},
+				{Field: "id", Operator: FilterGe, Values: []string{"2"}},
+			},
+		},
+		{
+			expression: "tag.id!=(3|4),name='a,b\\'c'",
+			predicates: []Predicate{
+				{Field: "tag.id", Operator: FilterNe, Values: []string{"3", "4"}, List: true},
+				{Field: "name", Operator: FilterEq, Values: []string{"a,b'c"}},
+			},
+		},
+		{
+			expression: "id<2,id<=3,id>4",
+			predicates: []Predicate{
+				{Field: "id", Operator: FilterLt, Values: []string{"2"}},
+				{Field: "id", Operator: FilterLe, Values: []string{"3"}},
+				{Field: "id", Operator: FilterGt, Values: []string{"4"}},
+			},
+		},
+		{expression: "name", invalid: true},
+		{expression: "name=", invalid: true},
+		{expression: "name=A,", invalid: true},
+		{expression: "name=A id=1", invalid: true},
+		{expression: "name='A", invalid: true},
+		{expression: "name=(A|B", invalid: true},
+		{expression: "id<(1|2)", invalid: true},
+		{expression: "other=A", invalid: true},
+		{expression: "id=A", invalid: true},
+		{expression: "id~1", invalid: true},
+	}
+	for _, c := range cases {
+		f, err := ParseFilter(c.expression, ApplicationFilter)
+		if c.invalid {
+			g.Expect(errors.Is(err, ErrFilter)).To(gomega.BeTrue(), c.expression)
+			continue
+		}
+		g.Expect(err).To(gomega.BeNil(), c.expression)
+		g.Expect(f.Predicates).To(gomega.Equal(c.predicates), c.expression)
+	}
+}
+
+func TestFilterWhere(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db, err := gorm.Open(
+		sqlite.Open("file::memory:"),
+		&gorm.Config{
+			DryRun: true,
+			NamingStrategy: &schema.NamingStrategy{
+				SingularTable: true,
+				NoLowerCase:   true,
+			},
+		})
+	g.Expect(err).To(gomega.BeNil())
+	selected := "SELECT * FROM `Application` WHERE "
+	cases := []struct {
+		expression string
+		where      string
+	}{
+		{
+			expression: "name=A",
+			where:      "`Name` = \"A\"",
+		},
+		{
+			expression: "id!=(1|2),id>=3",
+			where:      "`ID` NOT IN (1,2) AND `ID` >= 3",
+		},
+		{
+			expression: "name~'a_b*'",
+			where:      "(LOWER(`Name`) LIKE LOWER(\"a\\_b%\") ESCAPE '\\')",
+		},
+		{
+			expression: "name~(a|b*)",
+			where: "(LOWER(`Name`) LIKE LOWER(\"a\") ESCAPE '\\' OR " +
+				"LOWER(`Name`) LIKE LOWER(\"b%\") ESCAPE '\\')",
+		},
+		{
+			expression: "tag.id=3",
+			where:      "`ID` IN (SELECT `ApplicationID` FROM `applicationTags` WHERE `TagID` = 3)",
+		},
+		{
+			expression: "tag.id=(3|4)",
+			where:      "`ID` IN (SELECT `ApplicationID` FROM `applicationTags` WHERE `TagID` IN (3,4))",
+		},
+		{
+			expression: "tag.id!=3",
+			where:      "`ID` NOT IN (SELECT `ApplicationID` FROM `applicationTags` WHERE `TagID` = 3)",
+		},
+		{
+			expression: "tag.id!=(3|4)",
+			where:      "`ID` NOT IN (SELECT `ApplicationID` FROM `applicationTags` WHERE `TagID` IN (3,4))",
+		},
+	}
+	for _, c := range cases {
+		f, err := ParseFilter(c.expression, ApplicationFilter)
+		g.Expect(err).To(gomega.BeNil(), c.expression)
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return f.Where(tx).Find(&[]model.Application{})
+		})
+		g.Expect(sql).To(gomega.Equal(selected+c.where), c.expression)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	fields := FilterFields{
+		"name":    {Kind: FilterString},
+		"count":   {Kind: FilterInteger},
+		"enabled": {Kind: FilterBoolean},
+	}
+	values := map[string]string{
+		"name":    "Payment",
+		"count":   "10",
+		"enabled": "true",
+	}
+	cases := []struct {
+		expression string
+		matched    bool
+	}{
+		{expression: "", matched: true},
+		{expression: "name=Payment", matched: true},
+		{expression: "name=payment", matched: false},
+		{expression: "name!=(A|B)", matched: true},
+		{expression: "name!=(A|Payment)", matched: false},
+		{expression: "name~pay*", matched: true},
+		{expression: "name~*MEN*", matched: true},
+		{expression: "name~(a*|b*)", matched: false},
+		{expression: "count=10", matched: true},
+		{expression: "count=010", matched: true},
+		{expression: "count>9", matched: true},
+		{expression: "count<9", matched: false},
+		{expression: "count<=10", matched: true},
+		{expression: "count>=11", matched: false},
+		{expression: "count<100", matched: true},
+		{expression: "enabled=true", matched: true},
+		{expression: "enabled=1", matched: true},
+		{expression: "enabled!=false", matched: true},
+		{expression: "name=Payment,count>20", matched: false},
+	}
+	for _, c := range cases {
+		f, err := ParseFilter(c.expression, fields)
+		g.Expect(err).To(gomega.BeNil(), c.expression)
+		g.Expect(f.Match(values)).To(gomega.Equal(c.matched), c.expression)
+	}
+}
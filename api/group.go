@@ -20,6 +20,20 @@ const (
 	StakeholderGroupRoot  = StakeholderGroupsRoot + "/:" + ID
 )
 
+//
+// StakeholderGroupFilter filterable fields.
+var StakeholderGroupFilter = FilterFields{
+	"id":          {Column: "ID", Kind: FilterInteger},
+	"name":        {Column: "Name", Kind: FilterString},
+	"description": {Column: "Description", Kind: FilterString},
+	"stakeholder.id": {
+		Column: "StakeholderID",
+		Kind:   FilterInteger,
+		Table:  "sgStakeholder",
+		Key:    "StakeholderGroupID",
+	},
+}
+
 //
 // StakeholderGroupHandler handles stakeholder-group routes.
 type StakeholderGroupHandler struct {
@@ -34,6 +48,7 @@ func (h StakeholderGroupHandler) AddRoutes(e *gin.Engine) {
 	e.POST(StakeholderGroupsRoot, h.Create)
 	e.GET(StakeholderGroupRoot, h.Get)
 	e.PUT(StakeholderGroupRoot, h.Update)
+	e.PATCH(StakeholderGroupRoot, h.Patch)
 	e.DELETE(StakeholderGroupRoot, h.Delete)
 }
 
@@ -43,6 +58,7 @@ func (h StakeholderGroupHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.StakeholderGroup
+// @header 200 {string} ETag "Revision"
 // @router /controls/stakeholder-group/{id} [get]
 // @param id path string true "Stakeholder Group ID"
 func (h StakeholderGroupHandler) Get(ctx *gin.Context) {
@@ -57,6 +73,7 @@ func (h StakeholderGroupHandler) Get(ctx *gin.Context) {
 	r := StakeholderGroup{}
 	r.With(m)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, m)
 }
 
@@ -67,12 +84,23 @@ func (h StakeholderGroupHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.StakeholderGroup
 // @router /controls/stakeholder-group [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h StakeholderGroupHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.StakeholderGroup
-	h.DB.Model(model.StakeholderGroup{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, StakeholderGroupFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.StakeholderGroup{}).Count(&count)
+	pagination, err := NewPagination(ctx, StakeholderGroupFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "Stakeholders")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -86,7 +114,7 @@ func (h StakeholderGroupHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, StakeholderGroupKind, resources, int(count))
+	h.listResponse(ctx, StakeholderGroupKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -100,7 +128,7 @@ func (h StakeholderGroupHandler) List(ctx *gin.Context) {
 // @param stakeholder_group body api.StakeholderGroup true "Stakeholder Group data"
 func (h StakeholderGroupHandler) Create(ctx *gin.Context) {
 	r := &StakeholderGroup{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -121,17 +149,27 @@ func (h StakeholderGroupHandler) Create(ctx *gin.Context) {
 // @description Delete a stakeholder group.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /controls/stakeholder-group/{id} [delete]
 // @param id path string true "Stakeholder Group ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h StakeholderGroupHandler) Delete(ctx *gin.Context) {
 	id, _ := strconv.Atoi(ctx.Param(ID))
 	m := &model.StakeholderGroup{}
 	m.ID = uint(id)
-	result := h.DB.Select("Stakeholders").Delete(m)
+	p, matched := h.precondition(ctx, &model.StakeholderGroup{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Select("Stakeholders").Delete(m)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -142,23 +180,33 @@ func (h StakeholderGroupHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /controls/stakeholder-group/{id} [put]
 // @param id path string true "Stakeholder Group ID"
 // @param stakeholder_group body api.StakeholderGroup true "Stakeholder Group data"
+// @param If-Match header string false "Revision (ETag)"
 func (h StakeholderGroupHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &StakeholderGroup{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.StakeholderGroup{}, id)
+	if !matched {
+		return
+	}
 	m := r.Model()
-	result := h.DB.Model(&model.StakeholderGroup{}).Where("id = ?", id).Omit("id").Updates(m)
+	result := p.Where(h.DB).Model(&model.StakeholderGroup{}).Where("ID", id).Omit("id").Updates(m)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 	err = h.DB.Model(m).Association("Stakeholders").Replace("Stakeholders", m.Stakeholders)
 	if err != nil {
 		h.updateFailed(ctx, err)
@@ -168,6 +216,57 @@ func (h StakeholderGroupHandler) Update(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// Patch godoc
+// @summary Patch a stakeholder group.
+// @description Patch a stakeholder group using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /controls/stakeholder-group/{id} [patch]
+// @param id path string true "Stakeholder Group ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h StakeholderGroupHandler) Patch(ctx *gin.Context) {
+	m := &model.StakeholderGroup{}
+	db := h.preLoad(h.DB, "Stakeholders")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &StakeholderGroup{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	result = p.Where(h.DB).Select("Name", "Description").Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+	err = h.DB.Model(patched).Association("Stakeholders").Replace(patched.Stakeholders)
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 //
 // StakeholderGroup REST resource.
 type StakeholderGroup struct {
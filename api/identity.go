@@ -1,19 +1,70 @@
 package api
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/credential"
 	"github.com/konveyor/tackle2-hub/model"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
 	"net/http"
 )
 
+//
+// Kind
+const (
+	IdentityKind = "identity"
+)
+
 //
 // Routes
 const (
 	IdentitiesRoot    = "/identities"
 	IdentityRoot      = IdentitiesRoot + "/:" + ID
+	IdentityUsageRoot = IdentityRoot + "/usage"
+	IdentityTestRoot  = IdentityRoot + "/test"
 	AppIdentitiesRoot = ApplicationRoot + IdentitiesRoot
 )
 
+//
+// IdentityFilter filterable fields.
+var IdentityFilter = FilterFields{
+	"id":   {Column: "ID", Kind: FilterInteger},
+	"name": {Column: "Name", Kind: FilterString},
+	"kind": {Column: "Kind", Kind: FilterString},
+}
+
+//
+// Params
+const (
+	Decrypted = "decrypted"
+	Force     = "force"
+	Role      = "role"
+)
+
+//
+// Identity kinds.
+const (
+	IdentityGit   = "git"
+	IdentitySvn   = "svn"
+	IdentityMaven = "mvn"
+	IdentityProxy = "proxy"
+)
+
+//
+// Identity (application) roles.
+const (
+	RoleSource = "source"
+	RoleMaven  = "maven"
+	RoleProxy  = "proxy"
+)
+
 //
 // IdentityHandler handles identity resource routes.
 type IdentityHandler struct {
@@ -26,7 +77,10 @@ func (h IdentityHandler) AddRoutes(e *gin.Engine) {
 	e.POST(IdentitiesRoot, h.Create)
 	e.GET(IdentityRoot, h.Get)
 	e.PUT(IdentityRoot, h.Update)
+	e.PATCH(IdentityRoot, h.Patch)
 	e.DELETE(IdentityRoot, h.Delete)
+	e.GET(IdentityUsageRoot, h.Usage)
+	e.POST(IdentityTestRoot, h.Test)
 	e.POST(AppIdentitiesRoot, h.CreateForApplication)
 	e.GET(AppIdentitiesRoot, h.ListByApplication)
 	e.GET(AppIdentitiesRoot+"/", h.ListByApplication)
@@ -38,9 +92,15 @@ func (h IdentityHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} Identity
+// @header 200 {string} ETag "Revision"
 // @router /identities/{id} [get]
 // @param id path string true "Identity ID"
+// @param decrypted query bool false "Include credentials (privileged)"
 func (h IdentityHandler) Get(ctx *gin.Context) {
+	decrypted, authorized := h.decrypted(ctx)
+	if !authorized {
+		return
+	}
 	m := &model.Identity{}
 	id := ctx.Param(ID)
 	result := h.DB.First(m, id)
@@ -48,9 +108,18 @@ func (h IdentityHandler) Get(ctx *gin.Context) {
 		h.getFailed(ctx, result.Error)
 		return
 	}
+	err := m.Decrypt(model.SecretStore())
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
 	r := Identity{}
 	r.With(m)
+	if !decrypted {
+		r.Redact()
+	}
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -59,12 +128,33 @@ func (h IdentityHandler) Get(ctx *gin.Context) {
 // @description List all identities.
 // @tags get
 // @produce json
+// @description Unless decrypted, the credentials (and user) are not
+// @description fetched from the secret store and only the (has)
+// @description indicators are reported.
 // @success 200 {object} []Identity
 // @router /identities [get]
+// @param decrypted query bool false "Include credentials (privileged)"
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h IdentityHandler) List(ctx *gin.Context) {
+	decrypted, authorized := h.decrypted(ctx)
+	if !authorized {
+		return
+	}
+	var count int64
 	var list []model.Identity
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, IdentityFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, IdentityFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(&model.Identity{}).Count(&count)
+	db = pagination.apply(db)
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -72,12 +162,20 @@ func (h IdentityHandler) List(ctx *gin.Context) {
 	}
 	resources := []Identity{}
 	for i := range list {
+		m := &list[i]
+		if decrypted {
+			err := m.Decrypt(model.SecretStore())
+			if err != nil {
+				h.listFailed(ctx, err)
+				return
+			}
+		}
 		r := Identity{}
-		r.With(&list[i])
+		r.With(m)
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, IdentityKind, resources, int(count), pagination.Next(list))
 }
 
 // ListByApplication  godoc
@@ -85,15 +183,41 @@ func (h IdentityHandler) List(ctx *gin.Context) {
 // @description List identities for an application.
 // @tags get
 // @produce json
+// @description Unless decrypted, the credentials (and user) are not
+// @description fetched from the secret store and only the (has)
+// @description indicators are reported.
 // @success 200 {object} []Identity
 // @router /application-inventory/application/{id}/identities [get]
 // @param id path int true "Application ID"
+// @param decrypted query bool false "Include credentials (privileged)"
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h IdentityHandler) ListByApplication(ctx *gin.Context) {
+	decrypted, authorized := h.decrypted(ctx)
+	if !authorized {
+		return
+	}
+	var count int64
 	var list []model.Identity
 	appId := ctx.Param(ID)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
-	db = db.Where("applicationid", appId)
+	filter, err := NewFilter(ctx, IdentityFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, IdentityFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db = db.Where(
+		"ID IN (?)",
+		h.DB.Model(&model.ApplicationIdentity{}).
+			Select("IdentityID").
+			Where("ApplicationID", appId))
+	db = db.Session(&gorm.Session{})
+	db.Model(&model.Identity{}).Count(&count)
+	db = pagination.apply(db)
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -101,12 +225,20 @@ func (h IdentityHandler) ListByApplication(ctx *gin.Context) {
 	}
 	resources := []Identity{}
 	for i := range list {
+		m := &list[i]
+		if decrypted {
+			err := m.Decrypt(model.SecretStore())
+			if err != nil {
+				h.listFailed(ctx, err)
+				return
+			}
+		}
 		r := Identity{}
-		r.With(&list[i])
+		r.With(m)
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, IdentityKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -120,35 +252,48 @@ func (h IdentityHandler) ListByApplication(ctx *gin.Context) {
 // @param identity body Identity true "Identity data"
 func (h IdentityHandler) Create(ctx *gin.Context) {
 	r := &Identity{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
 	m := r.Model()
+	err = h.validate(m)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
 	result := h.DB.Create(m)
 	if result.Error != nil {
 		h.createFailed(ctx, result.Error)
 		return
 	}
+	err = m.Decrypt(model.SecretStore())
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
 	r.With(m)
+	r.Redact()
 
 	ctx.JSON(http.StatusCreated, r)
 }
 
 // CreateForApplication godoc
 // @summary Create an identity for an application.
-// @description Create an identity for an application.
+// @description Create an identity and associate it with an application.
+// @description The role defaults based on the identity kind.
 // @tags create
 // @accept json
 // @produce json
 // @success 201 {object} Identity
 // @router /application-inventory/application/{id}/identities [post]
 // @param id path int true "Application ID"
+// @param role query string false "Role (source|maven|proxy)"
 // @param identity body Identity true "Identity data"
 func (h IdentityHandler) CreateForApplication(ctx *gin.Context) {
 	r := &Identity{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -160,14 +305,50 @@ func (h IdentityHandler) CreateForApplication(ctx *gin.Context) {
 		h.createFailed(ctx, result.Error)
 		return
 	}
-	r.ApplicationID = application.ID
+	role := ctx.Query(Role)
+	if role == "" {
+		role = DefaultRole(r.Kind)
+	}
+	err = ValidateRole(role)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
 	m := r.Model()
-	result = h.DB.Create(m)
-	if result.Error != nil {
-		h.createFailed(ctx, result.Error)
+	err = h.validate(m)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	err = model.Transaction(h.DB, func(tx *gorm.DB) (err error) {
+		result := tx.Create(m)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		result = tx.Create(
+			&model.ApplicationIdentity{
+				ApplicationID: application.ID,
+				IdentityID:    m.ID,
+				Role:          role,
+			})
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		return
+	})
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+	err = m.Decrypt(model.SecretStore())
+	if err != nil {
+		h.createFailed(ctx, err)
 		return
 	}
 	r.With(m)
+	r.Redact()
 
 	ctx.JSON(http.StatusCreated, r)
 }
@@ -175,10 +356,17 @@ func (h IdentityHandler) CreateForApplication(ctx *gin.Context) {
 // Delete godoc
 // @summary Delete an identity.
 // @description Delete an identity.
+// @description An identity in use is not deleted unless forced.
+// @description When forced, the identity is removed from the applications
+// @description and proxies using it.
 // @tags delete
 // @success 204
+// @failure 412
+// @failure 409 {object} IdentityUsage
 // @router /identities/{id} [delete]
 // @param id path string true "Identity ID"
+// @param force query bool false "Delete when in use"
+// @param If-Match header string false "Revision (ETag)"
 func (h IdentityHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	identity := &model.Identity{}
@@ -187,58 +375,426 @@ func (h IdentityHandler) Delete(ctx *gin.Context) {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
-	result = h.DB.Delete(identity, id)
-	if result.Error != nil {
-		h.deleteFailed(ctx, result.Error)
+	p, matched := h.precondition(ctx, identity, identity.ID)
+	if !matched {
+		return
+	}
+	usage, err := h.usage(identity.ID)
+	if err != nil {
+		h.deleteFailed(ctx, err)
+		return
+	}
+	if usage.InUse() && ctx.Query(Force) != "true" {
+		problem := NewProblem(
+			http.StatusConflict,
+			CodeInUse,
+			"identity in use.")
+		problem.Extensions = map[string]interface{}{
+			"usage": usage,
+		}
+		h.problem(ctx, problem)
+		return
+	}
+	err = model.Transaction(h.DB, func(tx *gorm.DB) (err error) {
+		result := tx.Where("IdentityID", identity.ID).Delete(&model.ApplicationIdentity{})
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		result = tx.Model(&model.Proxy{}).Where("IdentityID", identity.ID).Update("IdentityID", 0)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		result = p.Where(tx).Delete(identity)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		if p.Failed(result) {
+			err = ErrRevision
+			return
+		}
+		return
+	})
+	if errors.Is(err, ErrRevision) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+	if err != nil {
+		h.deleteFailed(ctx, err)
 		return
 	}
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// Usage godoc
+// @summary Get the usage of an identity.
+// @description Get the applications and proxies using an identity.
+// @tags get
+// @produce json
+// @success 200 {object} IdentityUsage
+// @router /identities/{id}/usage [get]
+// @param id path string true "Identity ID"
+func (h IdentityHandler) Usage(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	identity := &model.Identity{}
+	result := h.DB.First(identity, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	usage, err := h.usage(identity.ID)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, usage)
+}
+
 // Update godoc
 // @summary Update an identity.
 // @description Update an identity.
+// @description Credentials not specified are unchanged.
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /identities/{id} [put]
 // @param id path string true "Identity ID"
 // @param identity body Identity true "Identity data"
+// @param If-Match header string false "Revision (ETag)"
 func (h IdentityHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &Identity{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	current := &model.Identity{}
+	result := h.DB.First(current, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, current, id)
+	if !matched {
+		return
+	}
+	err = current.Decrypt(model.SecretStore())
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
 	m := r.Model()
-	db := h.DB.Model(&Identity{})
-	db = db.Where("id", id)
-	db = db.Omit("id")
-	result := db.Updates(m)
+	m.Model = current.Model
+	m.Secret = current.Secret
+	m.Merge(current)
+	err = h.validate(m)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	result = p.Where(h.DB).Select("*").Updates(m)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// Patch godoc
+// @summary Patch an identity.
+// @description Patch an identity using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /identities/{id} [patch]
+// @param id path string true "Identity ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h IdentityHandler) Patch(ctx *gin.Context) {
+	current := &model.Identity{}
+	result := h.DB.First(current, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, current, current.ID)
+	if !matched {
+		return
+	}
+	err := current.Decrypt(model.SecretStore())
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+	r := &Identity{}
+	r.With(current)
+	err = h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := r.Model()
+	m.Model = current.Model
+	m.Secret = current.Secret
+	err = h.validate(m)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	result = p.Where(h.DB).Select("*").Updates(m)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Test godoc
+// @summary Test identity credentials.
+// @description Test authentication using the identity credentials.
+// @description The target is the repository of the application; the
+// @description (maven) repositories defined in the settings, or the URL.
+// @description When a proxy is specified, the target is requested through the proxy.
+// @description The URL may only be specified by privileged requests.
+// @tags create
+// @accept json
+// @produce json
+// @success 200 {object} []IdentityTestResult
+// @router /identities/{id}/test [post]
+// @param id path string true "Identity ID"
+// @param target body IdentityTest true "Test target"
+func (h IdentityHandler) Test(ctx *gin.Context) {
+	r := &IdentityTest{}
+	err := ctx.ShouldBindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	if r.URL != "" && !h.privileged(ctx) {
+		h.forbidden(ctx, "test URL requires authorization.")
+		return
+	}
+	m := &model.Identity{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	err = m.Decrypt(model.SecretStore())
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	credentials := credential.Credentials{
+		User:     m.User,
+		Password: m.Password,
+		Key:      m.Key,
+		Settings: m.Settings,
+	}
+	targets := []string{}
+	if r.URL != "" {
+		targets = append(targets, r.URL)
+	}
+	if r.Application != 0 {
+		application := &model.Application{}
+		result = h.DB.First(application, r.Application)
+		if result.Error != nil {
+			h.getFailed(ctx, result.Error)
+			return
+		}
+		repository := &Repository{}
+		_ = json.Unmarshal(application.Repository, repository)
+		if repository.URL == "" {
+			h.bindFailed(ctx, errors.New("application: repository URL not defined."))
+			return
+		}
+		targets = append(targets, repository.URL)
+	}
+	var tester credential.Tester
+	switch {
+	case r.Proxy != 0:
+		proxy := &model.Proxy{}
+		result = h.DB.First(proxy, r.Proxy)
+		if result.Error != nil {
+			h.getFailed(ctx, result.Error)
+			return
+		}
+		tester = &credential.Proxy{
+			Credentials: credentials,
+			Kind:        proxy.Kind,
+			Host:        proxy.Host,
+			Port:        proxy.Port,
+		}
+	case m.Kind == IdentityGit:
+		tester = &credential.Git{
+			Credentials: credentials,
+			KnownHosts:  Settings.Hub.SSH.KnownHosts,
+		}
+	case m.Kind == IdentityMaven:
+		maven := &credential.Maven{Credentials: credentials}
+		if len(targets) == 0 {
+			targets, err = maven.Targets()
+			if err != nil {
+				h.bindFailed(ctx, err)
+				return
+			}
+		}
+		tester = maven
+	default:
+		h.bindFailed(ctx, fmt.Errorf("%s: test not supported.", m.Kind))
+		return
+	}
+	if len(targets) == 0 {
+		h.bindFailed(ctx, errors.New("target (application|url) required."))
+		return
+	}
+	resources := []IdentityTestResult{}
+	for _, url := range targets {
+		tr := IdentityTestResult{URL: url}
+		err = tester.Test(url)
+		if err != nil {
+			tr.Error = err.Error()
+		} else {
+			tr.Succeeded = true
+		}
+		resources = append(resources, tr)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+//
+// decrypted returns whether decrypted credentials have been requested
+// and whether the request is authorized. Decrypted credentials are
+// only returned to privileged (admin|task) requests.
+func (h IdentityHandler) decrypted(ctx *gin.Context) (decrypted bool, authorized bool) {
+	authorized = true
+	decrypted = ctx.Query(Decrypted) == "true"
+	if decrypted && !h.privileged(ctx) {
+		h.forbidden(ctx, "decrypted credentials require authorization.")
+		authorized = false
+	}
+	return
+}
+
+//
+// usage returns the applications and proxies using the identity.
+func (h IdentityHandler) usage(id uint) (usage *IdentityUsage, err error) {
+	usage = &IdentityUsage{
+		Applications: []UsageApplication{},
+		Proxies:      []UsageProxy{},
+	}
+	var refs []model.ApplicationIdentity
+	db := h.preLoad(h.DB, "Application")
+	result := db.Where("IdentityID", id).Find(&refs)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	for _, ref := range refs {
+		r := UsageApplication{
+			ID:   ref.ApplicationID,
+			Role: ref.Role,
+		}
+		if ref.Application != nil {
+			r.Name = ref.Application.Name
+		}
+		usage.Applications = append(usage.Applications, r)
+	}
+	var proxies []model.Proxy
+	result = h.DB.Where("IdentityID", id).Find(&proxies)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	for _, proxy := range proxies {
+		usage.Proxies = append(
+			usage.Proxies,
+			UsageProxy{
+				ID:   proxy.ID,
+				Kind: proxy.Kind,
+			})
+	}
+	return
+}
+
+//
+// validate the identity credentials based on kind.
+//
+//	git|svn: (user and password) or key.
+//	mvn: settings (XML).
+//	proxy: user and password.
+//
+// An SSH key must be parsable; when passphrase protected, the
+// password is the passphrase.
+func (h IdentityHandler) validate(m *model.Identity) (err error) {
+	switch m.Kind {
+	case IdentityGit, IdentitySvn:
+		if m.Key != "" {
+			_, err = NewKeyInfo(m.Key, m.Password)
+			return
+		}
+		if m.User == "" || m.Password == "" {
+			err = fmt.Errorf("%s: (user and password) or key required.", m.Kind)
+			return
+		}
+	case IdentityMaven:
+		if m.Settings == "" {
+			err = errors.New("mvn: settings required.")
+			return
+		}
+		settings := &struct {
+			XMLName xml.Name `xml:"settings"`
+		}{}
+		err = xml.Unmarshal([]byte(m.Settings), settings)
+		if err != nil {
+			err = fmt.Errorf("mvn: settings not valid: %w", err)
+			return
+		}
+	case IdentityProxy:
+		if m.User == "" || m.Password == "" {
+			err = errors.New("proxy: user and password required.")
+			return
+		}
+	}
+
+	return
+}
+
 //
 // Identity REST resource.
 type Identity struct {
 	Resource
-	Kind          string `json:"kind" binding:"required"`
-	Name          string `json:"name" binding:"required"`
-	Description   string `json:"description"`
-	User          string `json:"user"`
-	Password      string `json:"password"`
-	Key           string `json:"key"`
-	Settings      string `json:"settings"`
-	Encrypted     string `json:"encrypted"`
-	ApplicationID uint   `json:"application"`
+	Kind        string   `json:"kind" binding:"required,oneof=git svn mvn proxy"`
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	User        string   `json:"user"`
+	Password    string   `json:"password,omitempty"`
+	Key         string   `json:"key,omitempty"`
+	Settings    string   `json:"settings,omitempty"`
+	HasPassword bool     `json:"hasPassword"`
+	HasKey      bool     `json:"hasKey"`
+	HasSettings bool     `json:"hasSettings"`
+	KeyInfo     *KeyInfo `json:"keyInfo,omitempty"`
 }
 
 //
@@ -252,25 +808,161 @@ func (r *Identity) With(m *model.Identity) {
 	r.Password = m.Password
 	r.Key = m.Key
 	r.Settings = m.Settings
-	r.Encrypted = m.Encrypted
-	r.ApplicationID = m.ApplicationID
+	r.HasPassword = m.HasPassword
+	r.HasKey = m.HasKey
+	r.HasSettings = m.HasSettings
+	if m.Key != "" {
+		r.KeyInfo, _ = NewKeyInfo(m.Key, m.Password)
+	}
+}
+
+//
+// Redact credentials.
+func (r *Identity) Redact() {
+	r.Password = ""
+	r.Key = ""
+	r.Settings = ""
 }
 
 //
 // Model builds a model.
 func (r *Identity) Model() (m *model.Identity) {
 	m = &model.Identity{
-		Kind:          r.Kind,
-		Name:          r.Name,
-		Description:   r.Description,
-		User:          r.User,
-		Password:      r.Password,
-		Key:           r.Key,
-		Settings:      r.Settings,
-		Encrypted:     r.Encrypted,
-		ApplicationID: r.ApplicationID,
+		Kind:        r.Kind,
+		Name:        r.Name,
+		Description: r.Description,
+		User:        r.User,
+		Password:    r.Password,
+		Key:         r.Key,
+		Settings:    r.Settings,
 	}
 	m.ID = r.ID
 
 	return
 }
+
+//
+// IdentityUsage REST resource.
+// The applications and proxies using an identity.
+type IdentityUsage struct {
+	Applications []UsageApplication `json:"applications"`
+	Proxies      []UsageProxy       `json:"proxies"`
+}
+
+//
+// InUse returns true when the identity is used.
+func (r *IdentityUsage) InUse() (inUse bool) {
+	inUse = len(r.Applications) > 0 || len(r.Proxies) > 0
+	return
+}
+
+//
+// UsageApplication an application using an identity.
+type UsageApplication struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+//
+// UsageProxy a proxy using an identity.
+type UsageProxy struct {
+	ID   uint   `json:"id"`
+	Kind string `json:"kind"`
+}
+
+//
+// IdentityTest REST resource.
+// The target of an identity (credentials) test.
+type IdentityTest struct {
+	Application uint   `json:"application"`
+	Proxy       uint   `json:"proxy"`
+	URL         string `json:"url"`
+}
+
+//
+// IdentityTestResult REST resource.
+type IdentityTestResult struct {
+	URL       string `json:"url"`
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+}
+
+//
+// DefaultRole returns the default (application) role
+// for the identity kind.
+func DefaultRole(kind string) (role string) {
+	switch kind {
+	case IdentityMaven:
+		role = RoleMaven
+	case IdentityProxy:
+		role = RoleProxy
+	default:
+		role = RoleSource
+	}
+	return
+}
+
+//
+// ValidateRole validates an (application) identity role.
+func ValidateRole(role string) (err error) {
+	switch role {
+	case RoleSource, RoleMaven, RoleProxy:
+	default:
+		err = fmt.Errorf("role: '%s' not valid; (source|maven|proxy) expected.", role)
+	}
+	return
+}
+
+//
+// KeyInfo SSH key (derived) metadata.
+type KeyInfo struct {
+	Type        string `json:"type"`
+	Bits        int    `json:"bits"`
+	Fingerprint string `json:"fingerprint"`
+	Protected   bool   `json:"protected"`
+}
+
+//
+// NewKeyInfo parses the SSH private key.
+// The passphrase is used when the key is passphrase protected.
+func NewKeyInfo(key, passphrase string) (info *KeyInfo, err error) {
+	info = &KeyInfo{}
+	parsed, err := ssh.ParseRawPrivateKey([]byte(key))
+	if err != nil {
+		if _, protected := err.(*ssh.PassphraseMissingError); !protected {
+			err = fmt.Errorf("key not valid: %w", err)
+			return
+		}
+		info.Protected = true
+		if passphrase == "" {
+			err = errors.New("key passphrase protected: password (passphrase) required.")
+			return
+		}
+		parsed, err = ssh.ParseRawPrivateKeyWithPassphrase(
+			[]byte(key),
+			[]byte(passphrase))
+		if err != nil {
+			err = fmt.Errorf("key not valid: %w", err)
+			return
+		}
+	}
+	signer, err := ssh.NewSignerFromKey(parsed)
+	if err != nil {
+		err = fmt.Errorf("key not valid: %w", err)
+		return
+	}
+	publicKey := signer.PublicKey()
+	info.Type = publicKey.Type()
+	info.Fingerprint = ssh.FingerprintSHA256(publicKey)
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		info.Bits = k.N.BitLen()
+	case *ecdsa.PrivateKey:
+		info.Bits = k.Curve.Params().BitSize
+	case *ed25519.PrivateKey, ed25519.PrivateKey:
+		info.Bits = 256
+	}
+
+	return
+}
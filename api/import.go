@@ -3,9 +3,11 @@ package api
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
 	"io"
 	"net/http"
 	"time"
@@ -43,6 +45,24 @@ const (
 	DownloadRoot  = InventoryRoot + "/csv-export"
 )
 
+//
+// ImportFilter filterable fields.
+var ImportFilter = FilterFields{
+	"id":               {Column: "ID", Kind: FilterInteger},
+	"importSummary.id": {Column: "ImportSummaryID", Kind: FilterInteger},
+	"filename":         {Column: "Filename", Kind: FilterString},
+	"isValid":          {Column: "IsValid", Kind: FilterBoolean},
+	"processed":        {Column: "Processed", Kind: FilterBoolean},
+}
+
+//
+// SummaryFilter filterable fields.
+var SummaryFilter = FilterFields{
+	"id":           {Column: "ID", Kind: FilterInteger},
+	"filename":     {Column: "Filename", Kind: FilterString},
+	"importStatus": {Column: "ImportStatus", Kind: FilterString},
+}
+
 //
 // ImportHandler handles import routes.
 type ImportHandler struct {
@@ -93,22 +113,33 @@ func (h ImportHandler) GetImport(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Import
 // @router /application-inventory/application-import [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h ImportHandler) ListImports(ctx *gin.Context) {
 	var count int64
 	var list []model.Import
-	db := h.DB
+	filter, err := NewFilter(ctx, ImportFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
 	summaryId := ctx.Query("importSummary.id")
 	if summaryId != "" {
-		db = db.Where("importsummaryid = ?", summaryId)
+		db = db.Where("ImportSummaryID", summaryId)
 	}
 	isValid := ctx.Query("isValid")
 	if isValid == "true" {
-		db = db.Where("isvalid")
+		db = db.Where("IsValid", true)
 	} else if isValid == "false" {
-		db = db.Not("isvalid")
+		db = db.Where("IsValid", false)
 	}
+	db = db.Session(&gorm.Session{})
 	db.Model(model.Import{}).Count(&count)
-	pagination := NewPagination(ctx)
+	pagination, err := NewPagination(ctx, ImportFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
 	db = pagination.apply(db)
 	db = h.preLoad(db, "ImportTags")
 	result := db.Find(&list)
@@ -121,7 +152,7 @@ func (h ImportHandler) ListImports(ctx *gin.Context) {
 		resources = append(resources, list[i].AsMap())
 	}
 
-	h.listResponse(ctx, ImportKind, resources, int(count))
+	h.listResponse(ctx, ImportKind, resources, int(count), pagination.Next(list))
 }
 
 //
@@ -172,12 +203,23 @@ func (h ImportHandler) GetSummary(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.ImportSummary
 // @router /application-inventory/import-summary [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h ImportHandler) ListSummaries(ctx *gin.Context) {
 	var count int64
 	var list []model.ImportSummary
-	h.DB.Model(model.ImportSummary{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, SummaryFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.ImportSummary{}).Count(&count)
+	pagination, err := NewPagination(ctx, SummaryFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "Imports")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -191,7 +233,7 @@ func (h ImportHandler) ListSummaries(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, SummaryKind, resources, int(count))
+	h.listResponse(ctx, SummaryKind, resources, int(count), pagination.Next(list))
 }
 
 //
@@ -224,20 +266,24 @@ func (h ImportHandler) DeleteSummary(ctx *gin.Context) {
 func (h ImportHandler) UploadCSV(ctx *gin.Context) {
 	fileName, ok := ctx.GetPostForm("fileName")
 	if !ok {
-		ctx.Status(http.StatusBadRequest)
+		h.bindFailed(ctx, errors.New("fileName: required."))
+		return
 	}
 	file, err := ctx.FormFile("file")
 	if err != nil {
-		ctx.Status(http.StatusBadRequest)
+		h.bindFailed(ctx, err)
+		return
 	}
 	fileReader, err := file.Open()
 	if err != nil {
-		ctx.Status(http.StatusBadRequest)
+		h.bindFailed(ctx, err)
+		return
 	}
 	buf := bytes.NewBuffer(nil)
 	_, err = io.Copy(buf, fileReader)
 	if err != nil {
-		ctx.Status(http.StatusInternalServerError)
+		h.createFailed(ctx, err)
+		return
 	}
 	m := model.ImportSummary{
 		Filename:     fileName,
@@ -251,14 +297,16 @@ func (h ImportHandler) UploadCSV(ctx *gin.Context) {
 	}
 	_, err = fileReader.Seek(0, 0)
 	if err != nil {
-		ctx.Status(http.StatusInternalServerError)
+		h.createFailed(ctx, err)
+		return
 	}
 	csvReader := csv.NewReader(fileReader)
 	csvReader.TrimLeadingSpace = true
 	// skip the header
 	_, err = csvReader.Read()
 	if err != nil {
-		ctx.Status(http.StatusBadRequest)
+		h.bindFailed(ctx, err)
+		return
 	}
 
 	for {
@@ -267,7 +315,8 @@ func (h ImportHandler) UploadCSV(ctx *gin.Context) {
 			if err == io.EOF {
 				break
 			} else {
-				ctx.Status(http.StatusBadRequest)
+				h.bindFailed(ctx, err)
+				return
 			}
 		}
 		var imp model.Import
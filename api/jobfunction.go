@@ -19,6 +19,13 @@ const (
 	JobFunctionRoot  = JobFunctionsRoot + "/:" + ID
 )
 
+//
+// JobFunctionFilter filterable fields.
+var JobFunctionFilter = FilterFields{
+	"id":   {Column: "ID", Kind: FilterInteger},
+	"role": {Column: "Role", Kind: FilterString},
+}
+
 //
 // JobFunctionHandler handles job-function routes.
 type JobFunctionHandler struct {
@@ -33,6 +40,7 @@ func (h JobFunctionHandler) AddRoutes(e *gin.Engine) {
 	e.POST(JobFunctionsRoot, h.Create)
 	e.GET(JobFunctionRoot, h.Get)
 	e.PUT(JobFunctionRoot, h.Update)
+	e.PATCH(JobFunctionRoot, h.Patch)
 	e.DELETE(JobFunctionRoot, h.Delete)
 }
 
@@ -42,6 +50,7 @@ func (h JobFunctionHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} []api.JobFunction
+// @header 200 {string} ETag "Revision"
 // @router /controls/job-function/{id} [get]
 // @param id path string true "Job Function ID"
 func (h JobFunctionHandler) Get(ctx *gin.Context) {
@@ -56,6 +65,7 @@ func (h JobFunctionHandler) Get(ctx *gin.Context) {
 	r := JobFunction{}
 	r.With(m)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -66,12 +76,23 @@ func (h JobFunctionHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.JobFunction
 // @router /controls/job-function [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h JobFunctionHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.JobFunction
-	h.DB.Model(model.JobFunction{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, JobFunctionFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.JobFunction{}).Count(&count)
+	pagination, err := NewPagination(ctx, JobFunctionFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "Stakeholders")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -85,7 +106,7 @@ func (h JobFunctionHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, JobFunctionKind, resources, int(count))
+	h.listResponse(ctx, JobFunctionKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -99,7 +120,7 @@ func (h JobFunctionHandler) List(ctx *gin.Context) {
 // @param job_function body api.JobFunction true "Job Function data"
 func (h JobFunctionHandler) Create(ctx *gin.Context) {
 	r := &JobFunction{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -120,15 +141,25 @@ func (h JobFunctionHandler) Create(ctx *gin.Context) {
 // @description Delete a job function.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /controls/job-function/{id} [delete]
 // @param id path string true "Job Function ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h JobFunctionHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
-	result := h.DB.Delete(&model.JobFunction{}, id)
+	p, matched := h.precondition(ctx, &model.JobFunction{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Delete(&model.JobFunction{}, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -139,23 +170,79 @@ func (h JobFunctionHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /controls/job-function/{id} [put]
 // @param id path string true "Job Function ID"
 // @param job_function body api.JobFunction true "Job Function data"
+// @param If-Match header string false "Revision (ETag)"
 func (h JobFunctionHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &JobFunction{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.JobFunction{}, id)
+	if !matched {
+		return
+	}
 	m := r.Model()
-	result := h.DB.Model(&JobFunction{}).Where("id = ?", id).Omit("id").Updates(m)
+	result := p.Where(h.DB).Model(&JobFunction{}).Where("ID", id).Omit("id").Updates(m)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a job function.
+// @description Patch a job function using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /controls/job-function/{id} [patch]
+// @param id path string true "Job Function ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h JobFunctionHandler) Patch(ctx *gin.Context) {
+	m := &model.JobFunction{}
+	db := h.preLoad(h.DB, "Stakeholders")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &JobFunction{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	result = p.Where(h.DB).Select("Role").Updates(patched)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
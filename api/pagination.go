@@ -1,8 +1,13 @@
 package api
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -15,45 +20,177 @@ const (
 	Sort   = ""
 )
 
+//
+// Params
+const (
+	SizeParam   = "size"
+	PageParam   = "page"
+	SortParam   = "sort"
+	CursorParam = "cursor"
+)
+
+//
+// Headers
+const (
+	TotalCount = "X-Total-Count"
+	NextCursor = "X-Next-Cursor"
+)
+
+//
+// ErrPagination reports invalid pagination.
+var ErrPagination = errors.New("pagination")
+
 //
 // Pagination provides pagination and sorting.
+// Pages are selected by (page) index or by cursor. When the cursor
+// parameter is specified (empty for the first page), pages are
+// selected by (keyset) ID and the cursor of the next page is
+// returned in the X-Next-Cursor header.
 type Pagination struct {
 	Limit  int
 	Offset int
-	Sort   string
+	Sort   []clause.OrderByColumn
+	// Keyset pagination enabled.
+	Keyset bool
+	// Cursor (ID) of the last (listed) model.
+	Cursor uint
+	// Descending (keyset) order.
+	Descending bool
 }
 
 //
 // apply pagination.
 func (p *Pagination) apply(db *gorm.DB) (tx *gorm.DB) {
+	if p.Keyset {
+		tx = db.Limit(p.Limit)
+		if p.Descending {
+			if p.Cursor > 0 {
+				tx = tx.Where(clause.Lt{Column: "ID", Value: p.Cursor})
+			}
+			tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: "ID"}, Desc: true})
+		} else {
+			tx = tx.Where(clause.Gt{Column: "ID", Value: p.Cursor})
+			tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: "ID"}})
+		}
+		return
+	}
 	tx = db.Offset(p.Offset).Limit(p.Limit)
-	if p.Sort != "" {
-		tx = tx.Order(p.Sort)
+	for _, column := range p.Sort {
+		tx = tx.Order(column)
+	}
+	return
+}
+
+//
+// Next returns the cursor of the next page.
+// The list is the (page) of models found. Returns "" when
+// not keyset paginated or on the last page.
+func (p *Pagination) Next(list interface{}) (cursor string) {
+	if !p.Keyset || p.Limit < 1 {
+		return
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice || v.Len() == 0 || v.Len() < p.Limit {
+		return
+	}
+	last := reflect.Indirect(v.Index(v.Len() - 1))
+	id := last.FieldByName("ID")
+	if !id.IsValid() {
+		return
 	}
+	cursor = base64.RawURLEncoding.EncodeToString(
+		[]byte(strconv.FormatUint(id.Uint(), 10)))
 	return
 }
 
 //
 // NewPagination factory.
-func NewPagination(ctx *gin.Context) Pagination {
-	limit, err := strconv.Atoi(ctx.Query("size"))
+// The sort is a comma separated list of fields (prefixed with "-"
+// for descending) validated using the field whitelist. The size
+// must be > 0 and the page >= 0.
+func NewPagination(ctx *gin.Context, fields FilterFields) (p Pagination, err error) {
+	limit, err := strconv.Atoi(ctx.Query(SizeParam))
 	if err != nil {
 		limit = Limit
 	}
-	offset, err := strconv.Atoi(ctx.Query("page"))
+	if limit < 1 {
+		err = fmt.Errorf("%w: size must be > 0.", ErrPagination)
+		return
+	}
+	offset, err := strconv.Atoi(ctx.Query(PageParam))
 	if err != nil {
 		offset = Offset
 	}
-	sort := ctx.Query("sort")
-	if strings.Contains(sort, ".") {
-		sort = Sort
-	}
-	if strings.HasPrefix(sort, "-") {
-		sort = strings.Replace(sort, "-", "", 1) + " desc"
+	if offset < 0 {
+		err = fmt.Errorf("%w: page must be >= 0.", ErrPagination)
+		return
 	}
-	return Pagination{
+	p = Pagination{
 		Limit:  limit,
 		Offset: offset * limit,
-		Sort:   sort,
 	}
+	p.Sort, err = p.order(ctx.Query(SortParam), fields)
+	if err != nil {
+		return
+	}
+	cursor, found := ctx.GetQuery(CursorParam)
+	if found {
+		err = p.keyset(cursor, ctx.Query(SortParam))
+	}
+	return
+}
+
+//
+// order returns the (SQL) order for the sort parameter.
+func (p *Pagination) order(sort string, fields FilterFields) (order []clause.OrderByColumn, err error) {
+	if sort == "" {
+		return
+	}
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		descending := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		field, found := fields[name]
+		if !found || field.Column == "" || field.Table != "" {
+			err = fmt.Errorf("%w: sort by '%s' not supported.", ErrPagination, name)
+			return
+		}
+		order = append(
+			order,
+			clause.OrderByColumn{
+				Column: clause.Column{Name: field.Column},
+				Desc:   descending,
+			})
+	}
+	return
+}
+
+//
+// keyset enables keyset pagination.
+// Keyset pages may only be sorted by ID.
+func (p *Pagination) keyset(cursor, sort string) (err error) {
+	switch sort {
+	case "", "id":
+	case "-id":
+		p.Descending = true
+	default:
+		err = fmt.Errorf("%w: cursor requires sort by id.", ErrPagination)
+		return
+	}
+	p.Keyset = true
+	p.Offset = 0
+	p.Sort = nil
+	if cursor == "" {
+		return
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		var n uint64
+		n, err = strconv.ParseUint(string(b), 10, 64)
+		p.Cursor = uint(n)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: cursor not valid.", ErrPagination)
+	}
+	return
 }
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	cases := []struct {
+		query   string
+		limit   int
+		offset  int
+		invalid bool
+	}{
+		{query: "", limit: Limit, offset: 0},
+		{query: "size=10&page=2", limit: 10, offset: 20},
+		{query: "size=x&page=y", limit: Limit, offset: 0},
+		{query: "size=1&page=0", limit: 1, offset: 0},
+		{query: "size=0", invalid: true},
+		{query: "size=-5&page=1", invalid: true},
+		{query: "page=-1", invalid: true},
+		{query: "sort=other", invalid: true},
+	}
+	for _, c := range cases {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+c.query, nil)
+		p, err := NewPagination(ctx, ApplicationFilter)
+		if c.invalid {
+			g.Expect(errors.Is(err, ErrPagination)).To(gomega.BeTrue(), c.query)
+			continue
+		}
+		g.Expect(err).To(gomega.BeNil(), c.query)
+		g.Expect(p.Limit).To(gomega.Equal(c.limit), c.query)
+		g.Expect(p.Offset).To(gomega.Equal(c.offset), c.query)
+	}
+}
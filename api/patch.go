@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"reflect"
+)
+
+//
+// MIME types.
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+)
+
+//
+// ErrPatch reports an invalid (merge) patch.
+var ErrPatch = errors.New("patch")
+
+//
+// MergePatch returns the target with the (RFC 7386) JSON merge
+// patch applied. Members of the patch set to null are removed.
+func MergePatch(target, patch interface{}) (merged interface{}) {
+	members, isObject := patch.(map[string]interface{})
+	if !isObject {
+		merged = patch
+		return
+	}
+	object, isObject := target.(map[string]interface{})
+	if !isObject {
+		object = make(map[string]interface{})
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = MergePatch(object[name], value)
+	}
+	merged = object
+	return
+}
+
+//
+// mergePatch applies the (RFC 7386) JSON merge patch in the request
+// body to the resource. Fields removed (set to null) by the patch are
+// cleared. The patched resource is validated using the same (binding)
+// rules as when created.
+func (h *BaseHandler) mergePatch(ctx *gin.Context, r interface{}) (err error) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return
+	}
+	patch, err := h.decodeJSON(body)
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrPatch, err.Error())
+		return
+	}
+	if _, isObject := patch.(map[string]interface{}); !isObject {
+		err = fmt.Errorf("%w: must be an object.", ErrPatch)
+		return
+	}
+	current, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	document, err := h.decodeJSON(current)
+	if err != nil {
+		return
+	}
+	patched, err := json.Marshal(MergePatch(document, patch))
+	if err != nil {
+		return
+	}
+	v := reflect.ValueOf(r).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(r)
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrPatch, err.Error())
+		return
+	}
+	err = binding.Validator.ValidateStruct(r)
+	return
+}
+
+//
+// decodeJSON decodes the JSON document.
+// Numbers are preserved.
+func (h *BaseHandler) decodeJSON(b []byte) (document interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	err = decoder.Decode(&document)
+	return
+}
@@ -35,9 +35,11 @@ func All() []Handler {
 	return []Handler{
 		&AddonHandler{},
 		&ApplicationHandler{},
+		&BatchHandler{},
 		&BucketHandler{},
 		&BusinessServiceHandler{},
 		&DependencyHandler{},
+		&EncryptionHandler{},
 		&ImportHandler{},
 		&JobFunctionHandler{},
 		&IdentityHandler{},
@@ -46,6 +48,7 @@ func All() []Handler {
 		&SettingHandler{},
 		&StakeholderHandler{},
 		&StakeholderGroupHandler{},
+		&StorageHandler{},
 		&TagHandler{},
 		&TagTypeHandler{},
 		&TaskHandler{},
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//
+// Headers
+const (
+	IfMatch = "If-Match"
+)
+
+//
+// ErrRevision reports the model revision changed
+// after the precondition was matched.
+var ErrRevision = errors.New("revision changed")
+
+//
+// RevisionTag returns the (strong) entity tag for the revision.
+func RevisionTag(revision uint) (tag string) {
+	tag = "\"" + strconv.FormatUint(uint64(revision), 10) + "\""
+	return
+}
+
+//
+// Precondition (If-Match) on the revision of a model.
+type Precondition struct {
+	// Revision matched. Zero when unconditional.
+	Revision uint
+}
+
+//
+// Where conditions the (update or delete) on the matched revision.
+func (p *Precondition) Where(db *gorm.DB) (tx *gorm.DB) {
+	tx = db
+	if p.Revision > 0 {
+		tx = tx.Where("Revision", p.Revision)
+	}
+	return
+}
+
+//
+// Failed returns true when the conditional (update or delete)
+// did not affect the model. The model was changed after the
+// precondition was matched.
+func (p *Precondition) Failed(result *gorm.DB) (failed bool) {
+	failed = p.Revision > 0 &&
+		result.Error == nil &&
+		result.RowsAffected == 0
+	return
+}
+
+//
+// etag sets the ETag header for the model revision.
+func (h *BaseHandler) etag(ctx *gin.Context, revision uint) {
+	ctx.Header("ETag", RevisionTag(revision))
+}
+
+//
+// precondition matches the If-Match header with the current revision
+// of the model (by ID). Responds 412 and returns false when not matched.
+func (h *BaseHandler) precondition(ctx *gin.Context, m interface{}, id interface{}) (p Precondition, matched bool) {
+	header := strings.TrimSpace(ctx.GetHeader(IfMatch))
+	if header == "" || header == "*" {
+		matched = true
+		return
+	}
+	var revisions []uint
+	db := h.DB.Model(m).Where("ID", id)
+	result := db.Pluck("Revision", &revisions)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	if len(revisions) == 0 {
+		h.preconditionFailed(ctx, "not found.")
+		return
+	}
+	current := revisions[0]
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == RevisionTag(current) {
+			p.Revision = current
+			matched = true
+			return
+		}
+	}
+	h.preconditionFailed(
+		ctx,
+		fmt.Sprintf("revision %s not matched.", RevisionTag(current)))
+	return
+}
+
+//
+// preconditionFailed reports (412) a failed precondition.
+func (h *BaseHandler) preconditionFailed(ctx *gin.Context, reason string) {
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusPreconditionFailed,
+			CodePreconditionFailed,
+			"If-Match: "+reason))
+}
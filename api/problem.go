@@ -0,0 +1,297 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+//
+// MIME types.
+const (
+	MIMEProblem = "application/problem+json"
+)
+
+//
+// Headers
+const (
+	RequestID = "X-Request-ID"
+)
+
+//
+// Problem (error) codes.
+// The code is stable and identifies the problem.
+const (
+	CodeInvalid            = "invalid"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not-found"
+	CodeConflict           = "conflict"
+	CodeReference          = "reference"
+	CodeReferenced         = "referenced"
+	CodeConstraint         = "constraint"
+	CodeInUse              = "in-use"
+	CodeInProgress         = "in-progress"
+	CodeReadOnly           = "read-only"
+	CodePreconditionFailed = "precondition-failed"
+	CodeTooLarge           = "too-large"
+	CodeInternal           = "internal"
+)
+
+//
+// ProblemType the (RFC 7807) problem type.
+// The problem is identified by the code.
+const ProblemType = "about:blank"
+
+//
+// Problem (RFC 7807) details REST resource.
+// Rendered as application/problem+json for all failed requests.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Extension members.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+//
+// NewProblem returns a problem.
+func NewProblem(status int, code string, detail string) (p *Problem) {
+	p = &Problem{
+		Type:   ProblemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+	return
+}
+
+//
+// Error returns the problem description.
+func (p *Problem) Error() (s string) {
+	s = fmt.Sprintf("(%d) %s", p.Status, p.Code)
+	if p.Detail != "" {
+		s += ": " + p.Detail
+	}
+	for _, e := range p.Errors {
+		s += "; " + e.Field + ": " + e.Detail
+	}
+	return
+}
+
+//
+// MarshalJSON includes the extension members.
+func (p Problem) MarshalJSON() (b []byte, err error) {
+	type problem Problem
+	b, err = json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return
+	}
+	members := make(map[string]interface{})
+	err = json.Unmarshal(b, &members)
+	if err != nil {
+		return
+	}
+	for name, value := range p.Extensions {
+		if _, found := members[name]; !found {
+			members[name] = value
+		}
+	}
+	b, err = json.Marshal(members)
+	return
+}
+
+//
+// UnmarshalJSON collects the extension members.
+func (p *Problem) UnmarshalJSON(b []byte) (err error) {
+	type problem Problem
+	decoded := problem{}
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		return
+	}
+	members := make(map[string]interface{})
+	err = json.Unmarshal(b, &members)
+	if err != nil {
+		return
+	}
+	t := reflect.TypeOf(decoded)
+	for i := 0; i < t.NumField(); i++ {
+		delete(members, jsonName(t.Field(i)))
+	}
+	if len(members) > 0 {
+		decoded.Extensions = members
+	}
+	*p = Problem(decoded)
+	return
+}
+
+//
+// FieldError reports an invalid (request) field.
+type FieldError struct {
+	// Field path. Example: tags[0].id
+	Field string `json:"field"`
+	// Rule (validation) not satisfied.
+	Rule string `json:"rule,omitempty"`
+	// Param of the rule.
+	Param  string `json:"param,omitempty"`
+	Detail string `json:"detail"`
+}
+
+//
+// problem renders the problem (details).
+func (h *BaseHandler) problem(ctx *gin.Context, p *Problem) {
+	if p.Type == "" {
+		p.Type = ProblemType
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = ctx.Request.URL.Path
+	p.RequestID = h.requestID(ctx)
+	ctx.Header("Content-Type", MIMEProblem)
+	ctx.JSON(p.Status, p)
+}
+
+//
+// invalid builds a problem (400) for an invalid request.
+// Validation (binding) and JSON type errors are reported by field.
+func (h *BaseHandler) invalid(err error) (p *Problem) {
+	p = NewProblem(http.StatusBadRequest, CodeInvalid, err.Error())
+	var invalid validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &invalid):
+		p.Detail = "validation failed."
+		for _, e := range invalid {
+			detail := fmt.Sprintf("must satisfy: %s.", e.Tag())
+			if e.Param() != "" {
+				detail = fmt.Sprintf("must satisfy: %s=%s.", e.Tag(), e.Param())
+			}
+			p.Errors = append(
+				p.Errors,
+				FieldError{
+					Field:  fieldPath(e.Namespace()),
+					Rule:   e.Tag(),
+					Param:  e.Param(),
+					Detail: detail,
+				})
+		}
+	case errors.As(err, &typeErr):
+		p.Detail = "validation failed."
+		p.Errors = append(
+			p.Errors,
+			FieldError{
+				Field:  typeErr.Field,
+				Rule:   "type",
+				Param:  typeErr.Type.String(),
+				Detail: fmt.Sprintf("%s expected.", typeErr.Type.String()),
+			})
+	}
+	return
+}
+
+//
+// requestID returns the ID of the request.
+func (h *BaseHandler) requestID(ctx *gin.Context) (id string) {
+	id = ctx.GetString(RequestID)
+	if id == "" {
+		id = ctx.GetHeader(RequestID)
+	}
+	return
+}
+
+//
+// RequestIdentifier (middleware) identifies requests.
+// The ID is propagated (when specified) or generated and
+// returned in the X-Request-ID header.
+func RequestIdentifier(ctx *gin.Context) {
+	id := ctx.GetHeader(RequestID)
+	if id == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	ctx.Set(RequestID, id)
+	ctx.Header(RequestID, id)
+	ctx.Next()
+}
+
+//
+// Recovered (middleware) reports (500) a recovered panic.
+func Recovered(ctx *gin.Context, recovered interface{}) {
+	h := BaseHandler{}
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusInternalServerError,
+			CodeInternal,
+			"internal error."))
+	log.Error(
+		fmt.Errorf("%v", recovered),
+		"Panic recovered.",
+		"url",
+		ctx.Request.URL.String())
+	ctx.Abort()
+}
+
+//
+// NoRoute reports (404) a route not found.
+func NoRoute(ctx *gin.Context) {
+	h := BaseHandler{}
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusNotFound,
+			CodeNotFound,
+			"route not found."))
+}
+
+//
+// fieldPath returns the field path for the validation namespace.
+// The (root) struct name is omitted.
+func fieldPath(namespace string) (path string) {
+	path = namespace
+	part := strings.SplitN(namespace, ".", 2)
+	if len(part) == 2 {
+		path = part[1]
+	}
+	return
+}
+
+//
+// jsonName returns the (JSON) name of the struct field.
+func jsonName(field reflect.StructField) (name string) {
+	name = strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" {
+		name = field.Name
+	}
+	return
+}
+
+func init() {
+	v, cast := binding.Validator.Engine().(*validator.Validate)
+	if cast {
+		v.RegisterTagNameFunc(
+			func(field reflect.StructField) (name string) {
+				name = jsonName(field)
+				if name == "-" {
+					name = ""
+				}
+				return
+			})
+	}
+}
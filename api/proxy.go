@@ -3,9 +3,16 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
 	"net/http"
 )
 
+//
+// Kind
+const (
+	ProxyKind = "proxy"
+)
+
 //
 // Routes
 const (
@@ -13,6 +20,14 @@ const (
 	ProxyRoot   = ProxiesRoot + "/" + ID
 )
 
+//
+// ProxyFilter filterable fields.
+var ProxyFilter = FilterFields{
+	"id":   {Column: "ID", Kind: FilterInteger},
+	"kind": {Column: "Kind", Kind: FilterString},
+	"host": {Column: "Host", Kind: FilterString},
+}
+
 //
 // ProxyHandler handles proxy resource routes.
 type ProxyHandler struct {
@@ -25,6 +40,7 @@ func (h ProxyHandler) AddRoutes(e *gin.Engine) {
 	e.POST(ProxiesRoot, h.Create)
 	e.GET(ProxyRoot, h.Get)
 	e.PUT(ProxyRoot, h.Update)
+	e.PATCH(ProxyRoot, h.Patch)
 	e.DELETE(ProxyRoot, h.Delete)
 }
 
@@ -34,6 +50,7 @@ func (h ProxyHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} Proxy
+// @header 200 {string} ETag "Revision"
 // @router /proxies/{id} [get]
 // @param id path string true "Proxy ID"
 func (h ProxyHandler) Get(ctx *gin.Context) {
@@ -47,6 +64,7 @@ func (h ProxyHandler) Get(ctx *gin.Context) {
 	r := Proxy{}
 	r.With(proxy)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -57,14 +75,28 @@ func (h ProxyHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []Proxy
 // @router /proxies [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h ProxyHandler) List(ctx *gin.Context) {
+	var count int64
 	var list []model.Proxy
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, ProxyFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, ProxyFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
 	kind := ctx.Query("kind")
 	if kind != "" {
-		db = db.Where("kind", kind)
+		db = db.Where("Kind", kind)
 	}
+	db = db.Session(&gorm.Session{})
+	db.Model(&model.Proxy{}).Count(&count)
+	db = pagination.apply(db)
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -77,7 +109,7 @@ func (h ProxyHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, list)
+	h.listResponse(ctx, ProxyKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -91,8 +123,9 @@ func (h ProxyHandler) List(ctx *gin.Context) {
 // @param proxy body Proxy true "Proxy data"
 func (h ProxyHandler) Create(ctx *gin.Context) {
 	proxy := &Proxy{}
-	err := ctx.BindJSON(proxy)
+	err := ctx.ShouldBindJSON(proxy)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
 	m := proxy.Model()
@@ -111,8 +144,10 @@ func (h ProxyHandler) Create(ctx *gin.Context) {
 // @description Delete an proxy.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /proxies/{id} [delete]
 // @param id path string true "Proxy ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h ProxyHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	proxy := &model.Proxy{}
@@ -121,11 +156,19 @@ func (h ProxyHandler) Delete(ctx *gin.Context) {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
-	result = h.DB.Delete(proxy, id)
+	p, matched := h.precondition(ctx, proxy, id)
+	if !matched {
+		return
+	}
+	result = p.Where(h.DB).Delete(proxy, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -136,26 +179,87 @@ func (h ProxyHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /proxies/{id} [put]
 // @param id path string true "Proxy ID"
 // @param proxy body Proxy true "Proxy data"
+// @param If-Match header string false "Revision (ETag)"
 func (h ProxyHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &Proxy{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.Proxy{}, id)
+	if !matched {
+		return
+	}
 	m := r.Model()
-	db := h.DB.Model(&model.Proxy{})
-	db = db.Where("id", id)
+	db := p.Where(h.DB).Model(&model.Proxy{})
+	db = db.Where("ID", id)
 	db = db.Omit("id")
 	result := db.Updates(m)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a proxy.
+// @description Patch a proxy using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /proxies/{id} [patch]
+// @param id path string true "Proxy ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h ProxyHandler) Patch(ctx *gin.Context) {
+	m := &model.Proxy{}
+	result := h.DB.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Proxy{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	fields := []string{
+		"Kind",
+		"Host",
+		"Port",
+		"IdentityID",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -20,6 +20,13 @@ const (
 	BulkRoot    = ReviewsRoot + "/bulk"
 )
 
+//
+// ReviewFilter filterable fields.
+var ReviewFilter = FilterFields{
+	"id":             {Column: "ID", Kind: FilterInteger},
+	"application.id": {Column: "ApplicationID", Kind: FilterInteger},
+}
+
 //
 // ReviewHandler handles review routes.
 type ReviewHandler struct {
@@ -34,6 +41,7 @@ func (h ReviewHandler) AddRoutes(e *gin.Engine) {
 	e.POST(ReviewsRoot, h.Create)
 	e.GET(ReviewRoot, h.Get)
 	e.PUT(ReviewRoot, h.Update)
+	e.PATCH(ReviewRoot, h.Patch)
 	e.DELETE(ReviewRoot, h.Delete)
 	e.POST(BulkRoot, h.CopyReview)
 }
@@ -44,6 +52,7 @@ func (h ReviewHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} []api.Review
+// @header 200 {string} ETag "Revision"
 // @router /application-inventory/review/{id} [get]
 // @param id path string true "Review ID"
 func (h ReviewHandler) Get(ctx *gin.Context) {
@@ -58,6 +67,7 @@ func (h ReviewHandler) Get(ctx *gin.Context) {
 	r := Review{}
 	r.With(m)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -68,12 +78,23 @@ func (h ReviewHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Review
 // @router /application-inventory/review [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h ReviewHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.Review
-	h.DB.Model(&model.Review{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, ReviewFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(&model.Review{}).Count(&count)
+	pagination, err := NewPagination(ctx, ReviewFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "Application")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -87,7 +108,7 @@ func (h ReviewHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, ReviewKind, resources, int(count))
+	h.listResponse(ctx, ReviewKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -101,8 +122,9 @@ func (h ReviewHandler) List(ctx *gin.Context) {
 // @param review body api.Review true "Review data"
 func (h ReviewHandler) Create(ctx *gin.Context) {
 	review := Review{}
-	err := ctx.BindJSON(&review)
+	err := ctx.ShouldBindJSON(&review)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
 	m := review.Model()
@@ -121,15 +143,25 @@ func (h ReviewHandler) Create(ctx *gin.Context) {
 // @description Delete a review.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /application-inventory/review/{id} [delete]
 // @param id path string true "Review ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h ReviewHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
-	result := h.DB.Delete(&Review{}, id)
+	p, matched := h.precondition(ctx, &Review{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Delete(&Review{}, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -140,21 +172,86 @@ func (h ReviewHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /application-inventory/review/{id} [put]
 // @param id path string true "Review ID"
 // @param review body api.Review true "Review data"
+// @param If-Match header string false "Revision (ETag)"
 func (h ReviewHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	updates := Review{}
-	err := ctx.BindJSON(&updates)
+	err := ctx.ShouldBindJSON(&updates)
 	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	p, matched := h.precondition(ctx, &Review{}, id)
+	if !matched {
 		return
 	}
-	result := h.DB.Model(&Review{}).Where("id = ?", id).Omit("id").Updates(updates)
+	result := p.Where(h.DB).Model(&Review{}).Where("ID", id).Omit("id").Updates(updates)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a review.
+// @description Patch a review using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /application-inventory/review/{id} [patch]
+// @param id path string true "Review ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h ReviewHandler) Patch(ctx *gin.Context) {
+	m := &model.Review{}
+	db := h.preLoad(h.DB, "Application")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Review{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	fields := []string{
+		"BusinessCriticality",
+		"EffortEstimate",
+		"ProposedAction",
+		"WorkPriority",
+		"Comments",
+		"ApplicationID",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -169,8 +266,9 @@ func (h ReviewHandler) Update(ctx *gin.Context) {
 // @param copy_request body api.CopyRequest true "Review copy request data"
 func (h ReviewHandler) CopyReview(ctx *gin.Context) {
 	c := CopyRequest{}
-	err := ctx.BindJSON(&c)
+	err := ctx.ShouldBindJSON(&c)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
 
@@ -190,7 +288,7 @@ func (h ReviewHandler) CopyReview(ctx *gin.Context) {
 			ApplicationID:       id,
 		}
 		existing := []model.Review{}
-		result = h.DB.Find(&existing, "applicationid = ?", id)
+		result = h.DB.Find(&existing, "ApplicationID", id)
 		if result.Error != nil {
 			h.createFailed(ctx, result.Error)
 			return
@@ -204,7 +302,7 @@ func (h ReviewHandler) CopyReview(ctx *gin.Context) {
 			}
 			// if the application already has a review, replace it with the copied review.
 		} else {
-			result = h.DB.Model(&model.Review{}).Where("id = ?", existing[0].ID).Updates(&copied)
+			result = h.DB.Model(&model.Review{}).Where("ID", existing[0].ID).Updates(&copied)
 			if result.Error != nil {
 				h.createFailed(ctx, result.Error)
 				return
@@ -9,6 +9,12 @@ import (
 	"strings"
 )
 
+//
+// Kind
+const (
+	SettingKind = "setting"
+)
+
 //
 // Routes
 const (
@@ -16,6 +22,13 @@ const (
 	SettingRoot  = SettingsRoot + "/:" + Key
 )
 
+//
+// SettingFilter filterable fields.
+var SettingFilter = FilterFields{
+	"id":  {Column: "ID", Kind: FilterInteger},
+	"key": {Column: "Key", Kind: FilterString},
+}
+
 //
 // SettingHandler handles setting routes.
 type SettingHandler struct {
@@ -30,6 +43,7 @@ func (h SettingHandler) AddRoutes(e *gin.Engine) {
 	e.GET(SettingRoot, h.Get)
 	e.POST(SettingsRoot, h.Create)
 	e.PUT(SettingRoot, h.Update)
+	e.PATCH(SettingRoot, h.Patch)
 	e.DELETE(SettingRoot, h.Delete)
 }
 
@@ -62,9 +76,16 @@ func (h SettingHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 array api.Setting
 // @router /settings [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h SettingHandler) List(ctx *gin.Context) {
 	var list []model.Setting
-	result := h.DB.Find(&list)
+	filter, err := NewFilter(ctx, SettingFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
 		return
@@ -76,7 +97,7 @@ func (h SettingHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, SettingKind, resources, len(resources), "")
 }
 
 // Create godoc
@@ -90,19 +111,14 @@ func (h SettingHandler) List(ctx *gin.Context) {
 // @param setting body api.Setting true "Setting data"
 func (h SettingHandler) Create(ctx *gin.Context) {
 	setting := Setting{}
-	err := ctx.BindJSON(&setting)
+	err := ctx.ShouldBindJSON(&setting)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
 
 	if strings.HasPrefix(setting.Key, ".") {
-		ctx.JSON(
-			http.StatusForbidden,
-			gin.H{
-				"error": fmt.Sprintf("%s is read-only.", setting.Key),
-			})
-
+		h.readOnly(ctx, setting.Key)
 		return
 	}
 
@@ -129,17 +145,12 @@ func (h SettingHandler) Create(ctx *gin.Context) {
 func (h SettingHandler) Update(ctx *gin.Context) {
 	key := ctx.Param(Key)
 	if strings.HasPrefix(key, ".") {
-		ctx.JSON(
-			http.StatusForbidden,
-			gin.H{
-				"error": fmt.Sprintf("%s is read-only.", key),
-			})
-
+		h.readOnly(ctx, key)
 		return
 	}
 
 	updates := &Setting{}
-	err := ctx.BindJSON(updates)
+	err := ctx.ShouldBindJSON(updates)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -157,6 +168,47 @@ func (h SettingHandler) Update(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// Patch godoc
+// @summary Patch a setting.
+// @description Patch a setting using a JSON merge patch (RFC 7386).
+// @description Object values are merged; fields set to null are cleared.
+// @tags update, setting
+// @accept application/merge-patch+json
+// @success 204
+// @router /settings/{key} [patch]
+// @param key path string true "Key"
+// @param patch body object true "Merge patch"
+func (h SettingHandler) Patch(ctx *gin.Context) {
+	key := ctx.Param(Key)
+	if strings.HasPrefix(key, ".") {
+		h.readOnly(ctx, key)
+		return
+	}
+	m := &model.Setting{}
+	result := h.DB.Where(&model.Setting{Key: key}).First(m)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	r := &Setting{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	r.Key = key
+	patched := r.Model()
+	patched.ID = m.ID
+	result = h.DB.Select("Value").Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 // Delete godoc
 // @summary Delete a setting.
 // @description Delete a setting.
@@ -167,12 +219,7 @@ func (h SettingHandler) Update(ctx *gin.Context) {
 func (h SettingHandler) Delete(ctx *gin.Context) {
 	key := ctx.Param(Key)
 	if strings.HasPrefix(key, ".") {
-		ctx.JSON(
-			http.StatusForbidden,
-			gin.H{
-				"error": fmt.Sprintf("%s is read-only.", key),
-			})
-
+		h.readOnly(ctx, key)
 		return
 	}
 
@@ -203,3 +250,14 @@ func (r *Setting) Model() (m *model.Setting) {
 	m.Value, _ = json.Marshal(r.Value)
 	return
 }
+
+//
+// readOnly reports (403) a read-only setting.
+func (h SettingHandler) readOnly(ctx *gin.Context, key string) {
+	h.problem(
+		ctx,
+		NewProblem(
+			http.StatusForbidden,
+			CodeReadOnly,
+			fmt.Sprintf("%s is read-only.", key)))
+}
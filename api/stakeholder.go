@@ -20,6 +20,21 @@ const (
 	StakeholderRoot  = StakeholdersRoot + "/:" + ID
 )
 
+//
+// StakeholderFilter filterable fields.
+var StakeholderFilter = FilterFields{
+	"id":             {Column: "ID", Kind: FilterInteger},
+	"displayName":    {Column: "DisplayName", Kind: FilterString},
+	"email":          {Column: "Email", Kind: FilterString},
+	"jobFunction.id": {Column: "JobFunctionID", Kind: FilterInteger},
+	"group.id": {
+		Column: "StakeholderGroupID",
+		Kind:   FilterInteger,
+		Table:  "sgStakeholder",
+		Key:    "StakeholderID",
+	},
+}
+
 //
 // StakeholderHandler handles stakeholder routes.
 type StakeholderHandler struct {
@@ -34,6 +49,7 @@ func (h StakeholderHandler) AddRoutes(e *gin.Engine) {
 	e.POST(StakeholdersRoot, h.Create)
 	e.GET(StakeholderRoot, h.Get)
 	e.PUT(StakeholderRoot, h.Update)
+	e.PATCH(StakeholderRoot, h.Patch)
 	e.DELETE(StakeholderRoot, h.Delete)
 }
 
@@ -43,6 +59,7 @@ func (h StakeholderHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.Stakeholder
+// @header 200 {string} ETag "Revision"
 // @router /controls/stakeholder/{id} [get]
 // @param id path string true "Stakeholder ID"
 func (h StakeholderHandler) Get(ctx *gin.Context) {
@@ -61,6 +78,7 @@ func (h StakeholderHandler) Get(ctx *gin.Context) {
 
 	resource := Stakeholder{}
 	resource.With(m)
+	h.etag(ctx, resource.Revision)
 	ctx.JSON(http.StatusOK, resource)
 }
 
@@ -71,12 +89,23 @@ func (h StakeholderHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Stakeholder
 // @router /controls/stakeholder [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h StakeholderHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.Stakeholder
-	h.DB.Model(model.Stakeholder{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, StakeholderFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.Stakeholder{}).Count(&count)
+	pagination, err := NewPagination(ctx, StakeholderFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(
 		db,
 		"JobFunction",
@@ -94,7 +123,7 @@ func (h StakeholderHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, StakeholderKind, resources, int(count))
+	h.listResponse(ctx, StakeholderKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -108,7 +137,7 @@ func (h StakeholderHandler) List(ctx *gin.Context) {
 // @param stakeholder body api.Stakeholder true "Stakeholder data"
 func (h StakeholderHandler) Create(ctx *gin.Context) {
 	r := &Stakeholder{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -129,17 +158,27 @@ func (h StakeholderHandler) Create(ctx *gin.Context) {
 // @description Delete a stakeholder.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /controls/stakeholder/{id} [delete]
 // @param id path string true "Stakeholder ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h StakeholderHandler) Delete(ctx *gin.Context) {
 	id, _ := strconv.Atoi(ctx.Param(ID))
 	m := &model.Stakeholder{}
 	m.ID = uint(id)
-	result := h.DB.Select("Groups").Delete(m)
+	p, matched := h.precondition(ctx, &model.Stakeholder{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Select("Groups").Delete(m)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -150,23 +189,33 @@ func (h StakeholderHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /controls/stakeholder/{id} [put]
 // @param id path string true "Stakeholder ID"
 // @param stakeholder body api.Stakeholder true "Stakeholder data"
+// @param If-Match header string false "Revision (ETag)"
 func (h StakeholderHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	resource := Stakeholder{}
-	err := ctx.BindJSON(&resource)
+	err := ctx.ShouldBindJSON(&resource)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.Stakeholder{}, id)
+	if !matched {
+		return
+	}
 	updates := resource.Model()
-	result := h.DB.Model(&model.Stakeholder{}).Where("id = ?", id).Updates(updates)
+	result := p.Where(h.DB).Model(&model.Stakeholder{}).Where("ID", id).Updates(updates)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 	err = h.DB.Model(updates).Association("Groups").Replace("Groups", updates.Groups)
 	if err != nil {
 		h.updateFailed(ctx, err)
@@ -176,6 +225,62 @@ func (h StakeholderHandler) Update(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// Patch godoc
+// @summary Patch a stakeholder.
+// @description Patch a stakeholder using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /controls/stakeholder/{id} [patch]
+// @param id path string true "Stakeholder ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h StakeholderHandler) Patch(ctx *gin.Context) {
+	m := &model.Stakeholder{}
+	db := h.preLoad(h.DB, "JobFunction", "BusinessServices", "Groups")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Stakeholder{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	fields := []string{
+		"DisplayName",
+		"Email",
+		"JobFunctionID",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+	err = h.DB.Model(patched).Association("Groups").Replace(patched.Groups)
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 //
 // Stakeholder REST resource.
 type Stakeholder struct {
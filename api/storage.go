@@ -0,0 +1,83 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle2-hub/storage"
+	"net/http"
+)
+
+//
+// Routes
+const (
+	StorageRoot       = "/storage"
+	StorageReportRoot = StorageRoot + "/report"
+)
+
+//
+// StorageHandler handles bucket storage routes.
+type StorageHandler struct {
+	BaseHandler
+}
+
+//
+// AddRoutes adds routes.
+func (h StorageHandler) AddRoutes(e *gin.Engine) {
+	e.GET(StorageReportRoot, h.Report)
+}
+
+// Report godoc
+// @summary Get the bucket storage reconcile report.
+// @description Get the (dry-run) bucket storage reconcile report.
+// @description Lists the expired and unowned buckets and the orphan
+// @description roots that will be deleted by the next reconcile.
+// @tags get
+// @produce json
+// @success 200 {object} api.StorageReport
+// @router /storage/report [get]
+func (h StorageHandler) Report(ctx *gin.Context) {
+	if !h.admin(ctx) {
+		h.forbidden(ctx, "storage report requires authorization.")
+		return
+	}
+	manager := storage.Manager{DB: h.DB}
+	report, err := manager.Reconcile(true)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	r := StorageReport{}
+	r.With(&report)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+//
+// StorageReport REST resource.
+type StorageReport struct {
+	Expired []Bucket      `json:"expired"`
+	Unowned []Bucket      `json:"unowned"`
+	Orphans []BucketEntry `json:"orphans"`
+}
+
+//
+// With updates the resource with the report.
+func (r *StorageReport) With(report *storage.Report) {
+	r.Expired = []Bucket{}
+	for i := range report.Expired {
+		b := Bucket{}
+		b.With(&report.Expired[i])
+		r.Expired = append(r.Expired, b)
+	}
+	r.Unowned = []Bucket{}
+	for i := range report.Unowned {
+		b := Bucket{}
+		b.With(&report.Unowned[i])
+		r.Unowned = append(r.Unowned, b)
+	}
+	r.Orphans = []BucketEntry{}
+	for i := range report.Orphans {
+		entry := BucketEntry{}
+		entry.With(&report.Orphans[i])
+		r.Orphans = append(r.Orphans, entry)
+	}
+}
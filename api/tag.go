@@ -19,6 +19,14 @@ const (
 	TagRoot  = TagsRoot + "/:" + ID
 )
 
+//
+// TagFilter filterable fields.
+var TagFilter = FilterFields{
+	"id":         {Column: "ID", Kind: FilterInteger},
+	"name":       {Column: "Name", Kind: FilterString},
+	"tagType.id": {Column: "TagTypeID", Kind: FilterInteger},
+}
+
 //
 // TagHandler handles tag routes.
 type TagHandler struct {
@@ -33,6 +41,7 @@ func (h TagHandler) AddRoutes(e *gin.Engine) {
 	e.POST(TagsRoot, h.Create)
 	e.GET(TagRoot, h.Get)
 	e.PUT(TagRoot, h.Update)
+	e.PATCH(TagRoot, h.Patch)
 	e.DELETE(TagRoot, h.Delete)
 }
 
@@ -42,6 +51,7 @@ func (h TagHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.Tag
+// @header 200 {string} ETag "Revision"
 // @router /controls/tag/{id} [get]
 // @param id path string true "Tag ID"
 func (h TagHandler) Get(ctx *gin.Context) {
@@ -56,6 +66,7 @@ func (h TagHandler) Get(ctx *gin.Context) {
 
 	resource := Tag{}
 	resource.With(m)
+	h.etag(ctx, resource.Revision)
 	ctx.JSON(http.StatusOK, resource)
 }
 
@@ -66,12 +77,23 @@ func (h TagHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Tag
 // @router /controls/tag [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h TagHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.Tag
-	h.DB.Model(model.Tag{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, TagFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.Tag{}).Count(&count)
+	pagination, err := NewPagination(ctx, TagFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "TagType")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -85,7 +107,7 @@ func (h TagHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, TagKind, resources, int(count))
+	h.listResponse(ctx, TagKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -99,7 +121,7 @@ func (h TagHandler) List(ctx *gin.Context) {
 // @param tag body Tag true "Tag data"
 func (h TagHandler) Create(ctx *gin.Context) {
 	r := &Tag{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -120,15 +142,25 @@ func (h TagHandler) Create(ctx *gin.Context) {
 // @description Delete a tag.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /controls/tag/{id} [delete]
 // @param id path string true "Tag ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h TagHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
-	result := h.DB.Delete(&model.Tag{}, id)
+	p, matched := h.precondition(ctx, &model.Tag{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Delete(&model.Tag{}, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -139,23 +171,79 @@ func (h TagHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /controls/tag/{id} [put]
 // @param id path string true "Tag ID"
 // @param tag body api.Tag true "Tag data"
+// @param If-Match header string false "Revision (ETag)"
 func (h TagHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &Tag{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.Tag{}, id)
+	if !matched {
+		return
+	}
 	m := r.Model()
-	result := h.DB.Model(&model.Tag{}).Where("id = ?", id).Omit("id").Updates(m)
+	result := p.Where(h.DB).Model(&model.Tag{}).Where("ID", id).Omit("id").Updates(m)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a tag.
+// @description Patch a tag using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /controls/tag/{id} [patch]
+// @param id path string true "Tag ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h TagHandler) Patch(ctx *gin.Context) {
+	m := &model.Tag{}
+	db := h.preLoad(h.DB, "TagType")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Tag{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	result = p.Where(h.DB).Select("Name", "TagTypeID").Updates(patched)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -19,6 +19,15 @@ const (
 	TagTypeRoot  = TagTypesRoot + "/:" + ID
 )
 
+//
+// TagTypeFilter filterable fields.
+var TagTypeFilter = FilterFields{
+	"id":    {Column: "ID", Kind: FilterInteger},
+	"name":  {Column: "Name", Kind: FilterString},
+	"rank":  {Column: "Rank", Kind: FilterInteger},
+	"color": {Column: "Color", Kind: FilterString},
+}
+
 //
 // TagTypeHandler handles the tag-type route.
 type TagTypeHandler struct {
@@ -33,6 +42,7 @@ func (h TagTypeHandler) AddRoutes(e *gin.Engine) {
 	e.POST(TagTypesRoot, h.Create)
 	e.GET(TagTypeRoot, h.Get)
 	e.PUT(TagTypeRoot, h.Update)
+	e.PATCH(TagTypeRoot, h.Patch)
 	e.DELETE(TagTypeRoot, h.Delete)
 }
 
@@ -42,6 +52,7 @@ func (h TagTypeHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.TagType
+// @header 200 {string} ETag "Revision"
 // @router /controls/tag-type/{id} [get]
 // @param id path string true "Tag Type ID"
 func (h TagTypeHandler) Get(ctx *gin.Context) {
@@ -56,6 +67,7 @@ func (h TagTypeHandler) Get(ctx *gin.Context) {
 
 	resource := TagType{}
 	resource.With(m)
+	h.etag(ctx, resource.Revision)
 	ctx.JSON(http.StatusOK, resource)
 }
 
@@ -66,12 +78,23 @@ func (h TagTypeHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.TagType
 // @router /controls/tag-type [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h TagTypeHandler) List(ctx *gin.Context) {
 	var count int64
 	var list []model.TagType
-	h.DB.Model(model.TagType{}).Count(&count)
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, TagTypeFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
+	db.Model(model.TagType{}).Count(&count)
+	pagination, err := NewPagination(ctx, TagTypeFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db = pagination.apply(db)
 	db = h.preLoad(db, "Tags")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -85,7 +108,7 @@ func (h TagTypeHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	h.listResponse(ctx, TagTypeKind, resources, int(count))
+	h.listResponse(ctx, TagTypeKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -99,7 +122,7 @@ func (h TagTypeHandler) List(ctx *gin.Context) {
 // @param tag_type body api.TagType true "Tag Type data"
 func (h TagTypeHandler) Create(ctx *gin.Context) {
 	r := TagType{}
-	err := ctx.BindJSON(&r)
+	err := ctx.ShouldBindJSON(&r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
@@ -120,15 +143,25 @@ func (h TagTypeHandler) Create(ctx *gin.Context) {
 // @description Delete a tag type.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /controls/tag-type/{id} [delete]
 // @param id path string true "Tag Type ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h TagTypeHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
-	result := h.DB.Delete(&model.TagType{}, id)
+	p, matched := h.precondition(ctx, &model.TagType{}, id)
+	if !matched {
+		return
+	}
+	result := p.Where(h.DB).Delete(&model.TagType{}, id)
 	if result.Error != nil {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -139,23 +172,85 @@ func (h TagTypeHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /controls/tag-type/{id} [put]
 // @param id path string true "Tag Type ID"
 // @param tag_type body api.TagType true "Tag Type data"
+// @param If-Match header string false "Revision (ETag)"
 func (h TagTypeHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	r := &TagType{}
-	err := ctx.BindJSON(r)
+	err := ctx.ShouldBindJSON(r)
 	if err != nil {
 		h.bindFailed(ctx, err)
 		return
 	}
+	p, matched := h.precondition(ctx, &model.TagType{}, id)
+	if !matched {
+		return
+	}
 	m := r.Model()
-	result := h.DB.Model(&TagType{}).Where("id = ?", id).Omit("id").Updates(m)
+	result := p.Where(h.DB).Model(&TagType{}).Where("ID", id).Omit("id").Updates(m)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a tag type.
+// @description Patch a tag type using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /controls/tag-type/{id} [patch]
+// @param id path string true "Tag Type ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h TagTypeHandler) Patch(ctx *gin.Context) {
+	m := &model.TagType{}
+	db := h.preLoad(h.DB, "Tags")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &TagType{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	fields := []string{
+		"Name",
+		"Username",
+		"Rank",
+		"Color",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
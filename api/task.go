@@ -6,8 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 	crd "github.com/konveyor/tackle2-hub/k8s/api/tackle/v1alpha1"
 	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
 	batch "k8s.io/api/batch/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
 	"path"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -15,6 +15,12 @@ import (
 	"time"
 )
 
+//
+// Kind
+const (
+	TaskKind = "task"
+)
+
 //
 // Routes
 const (
@@ -24,6 +30,17 @@ const (
 	AddonTasksRoot = AddonRoot + "/tasks"
 )
 
+//
+// TaskFilter filterable fields.
+var TaskFilter = FilterFields{
+	"id":        {Column: "ID", Kind: FilterInteger},
+	"name":      {Column: "Name", Kind: FilterString},
+	"addon":     {Column: "Addon", Kind: FilterString},
+	"locator":   {Column: "Locator", Kind: FilterString},
+	"status":    {Column: "Status", Kind: FilterString},
+	"bucket.id": {Column: "BucketID", Kind: FilterInteger},
+}
+
 const (
 	LocatorParam = "locator"
 )
@@ -42,6 +59,7 @@ func (h TaskHandler) AddRoutes(e *gin.Engine) {
 	e.POST(TasksRoot, h.Create)
 	e.GET(TaskRoot, h.Get)
 	e.PUT(TaskRoot, h.Update)
+	e.PATCH(TaskRoot, h.Patch)
 	e.POST(TaskReportRoot, h.CreateReport)
 	e.PUT(TaskReportRoot, h.UpdateReport)
 	e.POST(AddonTasksRoot, h.AddonCreate)
@@ -55,6 +73,7 @@ func (h TaskHandler) AddRoutes(e *gin.Engine) {
 // @tags get
 // @produce json
 // @success 200 {object} api.Task
+// @header 200 {string} ETag "Revision"
 // @router /tasks/{id} [get]
 // @param id path string true "Task ID"
 func (h TaskHandler) Get(ctx *gin.Context) {
@@ -69,6 +88,7 @@ func (h TaskHandler) Get(ctx *gin.Context) {
 	r := Task{}
 	r.With(task)
 
+	h.etag(ctx, r.Revision)
 	ctx.JSON(http.StatusOK, r)
 }
 
@@ -79,14 +99,28 @@ func (h TaskHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Task
 // @router /tasks [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h TaskHandler) List(ctx *gin.Context) {
+	var count int64
 	var list []model.Task
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, TaskFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, TaskFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
 	locator := ctx.Query(LocatorParam)
 	if locator != "" {
-		db = db.Where("locator", locator)
+		db = db.Where("Locator", locator)
 	}
+	db = db.Session(&gorm.Session{})
+	db.Model(&model.Task{}).Count(&count)
+	db = pagination.apply(db)
 	db = db.Preload("Report")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -100,7 +134,7 @@ func (h TaskHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, TaskKind, resources, int(count), pagination.Next(list))
 }
 
 // Create godoc
@@ -114,9 +148,9 @@ func (h TaskHandler) List(ctx *gin.Context) {
 // @param task body api.Task true "Task data"
 func (h TaskHandler) Create(ctx *gin.Context) {
 	task := Task{}
-	err := ctx.BindJSON(&task)
+	err := ctx.ShouldBindJSON(&task)
 	if err != nil {
-		h.createFailed(ctx, err)
+		h.bindFailed(ctx, err)
 		return
 	}
 
@@ -137,8 +171,10 @@ func (h TaskHandler) Create(ctx *gin.Context) {
 // @description Delete a task.
 // @tags delete
 // @success 204
+// @failure 412
 // @router /tasks/{id} [delete]
 // @param id path string true "Task ID"
+// @param If-Match header string false "Revision (ETag)"
 func (h TaskHandler) Delete(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	task := &model.Task{}
@@ -147,6 +183,19 @@ func (h TaskHandler) Delete(ctx *gin.Context) {
 		h.deleteFailed(ctx, result.Error)
 		return
 	}
+	p, matched := h.precondition(ctx, task, id)
+	if !matched {
+		return
+	}
+	result = p.Where(h.DB).Delete(task, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 	if task.Job != "" {
 		job := &batch.Job{}
 		job.Namespace = path.Dir(task.Job)
@@ -155,14 +204,10 @@ func (h TaskHandler) Delete(ctx *gin.Context) {
 			context.TODO(),
 			job)
 		if err != nil {
-			h.deleteFailed(ctx, result.Error)
+			h.deleteFailed(ctx, err)
+			return
 		}
 	}
-	result = h.DB.Delete(task, id)
-	if result.Error != nil {
-		h.deleteFailed(ctx, result.Error)
-		return
-	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -173,22 +218,87 @@ func (h TaskHandler) Delete(ctx *gin.Context) {
 // @tags update
 // @accept json
 // @success 204
+// @failure 412
 // @router /tasks/{id} [put]
 // @param id path string true "Task ID"
 // @param task body Task true "Task data"
+// @param If-Match header string false "Revision (ETag)"
 func (h TaskHandler) Update(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	updates := &Task{}
-	err := ctx.BindJSON(updates)
+	err := ctx.ShouldBindJSON(updates)
 	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	p, matched := h.precondition(ctx, &model.Task{}, id)
+	if !matched {
 		return
 	}
 	m := updates.Model()
-	result := h.DB.Model(&Task{}).Where("id", id).Omit("id").Updates(m)
+	result := p.Where(h.DB).Model(&Task{}).Where("ID", id).Omit("id").Updates(m)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Patch godoc
+// @summary Patch a task.
+// @description Patch a task using a JSON merge patch (RFC 7386).
+// @description Fields set to null are cleared.
+// @tags update
+// @accept application/merge-patch+json
+// @success 204
+// @failure 412
+// @router /tasks/{id} [patch]
+// @param id path string true "Task ID"
+// @param patch body object true "Merge patch"
+// @param If-Match header string false "Revision (ETag)"
+func (h TaskHandler) Patch(ctx *gin.Context) {
+	m := &model.Task{}
+	db := h.preLoad(h.DB, "Report")
+	result := db.First(m, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	p, matched := h.precondition(ctx, m, m.ID)
+	if !matched {
+		return
+	}
+	r := &Task{}
+	r.With(m)
+	err := h.mergePatch(ctx, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	patched := r.Model()
+	patched.ID = m.ID
+	fields := []string{
+		"Name",
+		"Addon",
+		"Locator",
+		"Isolated",
+		"Data",
+		"BucketID",
+	}
+	result = p.Where(h.DB).Select(fields).Updates(patched)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
 	}
+	if p.Failed(result) {
+		h.preconditionFailed(ctx, "revision changed.")
+		return
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -206,8 +316,9 @@ func (h TaskHandler) Update(ctx *gin.Context) {
 func (h TaskHandler) CreateReport(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	report := &TaskReport{}
-	err := ctx.BindJSON(report)
+	err := ctx.ShouldBindJSON(report)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
 	task, _ := strconv.Atoi(id)
@@ -235,15 +346,16 @@ func (h TaskHandler) CreateReport(ctx *gin.Context) {
 func (h TaskHandler) UpdateReport(ctx *gin.Context) {
 	id := ctx.Param(ID)
 	report := &TaskReport{}
-	err := ctx.BindJSON(report)
+	err := ctx.ShouldBindJSON(report)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
 	task, _ := strconv.Atoi(id)
 	report.TaskID = uint(task)
 	m := report.Model()
 	db := h.DB.Model(&model.TaskReport{})
-	db = db.Where("taskid", task)
+	db = db.Where("TaskID", task)
 	result := db.Updates(
 		map[string]interface{}{
 			"status":    m.Status,
@@ -280,17 +392,16 @@ func (h TaskHandler) AddonCreate(ctx *gin.Context) {
 		},
 		addon)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			ctx.Status(http.StatusNotFound)
-			return
-		}
+		h.getFailed(ctx, err)
+		return
 	}
 	task := Task{}
 	task.Name = addon.Name
 	task.Addon = addon.Name
 	task.Image = addon.Spec.Image
-	err = ctx.BindJSON(&task.Data)
+	err = ctx.ShouldBindJSON(&task.Data)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
 	m := task.Model()
@@ -311,16 +422,30 @@ func (h TaskHandler) AddonCreate(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Task
 // @router /addons/{name}/tasks [get]
+// @param filter query string false "Filter expression (see: api.Filter)"
 func (h TaskHandler) AddonList(ctx *gin.Context) {
+	var count int64
 	var list []model.Task
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
+	filter, err := NewFilter(ctx, TaskFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	pagination, err := NewPagination(ctx, TaskFilter)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.Where(h.DB)
 	name := ctx.Param(Name)
-	db = db.Where("addon", name)
+	db = db.Where("Addon", name)
 	locator := ctx.Query(LocatorParam)
 	if locator != "" {
-		db = db.Where("locator", locator)
+		db = db.Where("Locator", locator)
 	}
+	db = db.Session(&gorm.Session{})
+	db.Model(&model.Task{}).Count(&count)
+	db = pagination.apply(db)
 	db = db.Preload("Report")
 	result := db.Find(&list)
 	if result.Error != nil {
@@ -334,7 +459,7 @@ func (h TaskHandler) AddonList(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, TaskKind, resources, int(count), pagination.Next(list))
 }
 
 //
@@ -363,6 +488,7 @@ type Task struct {
 	Error      string      `json:"error"`
 	Job        string      `json:"job"`
 	Report     *TaskReport `json:"report"`
+	Bucket     *uint       `json:"bucket,omitempty"`
 }
 
 //
@@ -379,6 +505,7 @@ func (r *Task) With(m *model.Task) {
 	r.Status = m.Status
 	r.Error = m.Error
 	r.Job = m.Job
+	r.Bucket = m.BucketID
 	_ = json.Unmarshal(m.Data, &r.Data)
 	if m.Report != nil {
 		report := &TaskReport{}
@@ -395,6 +522,7 @@ func (r *Task) Model() (m *model.Task) {
 		Addon:    r.Addon,
 		Locator:  r.Locator,
 		Isolated: r.Isolated,
+		BucketID: r.Bucket,
 	}
 	m.Data, _ = json.Marshal(r.Data)
 	m.ID = r.ID
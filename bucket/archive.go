@@ -0,0 +1,389 @@
+package bucket
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	pathlib "path"
+	"strings"
+)
+
+//
+// Archive formats.
+const (
+	Tar   = "tar"
+	TarGz = "tar.gz"
+	Zip   = "zip"
+)
+
+//
+// Archive MIME types.
+const (
+	MIMETar  = "application/x-tar"
+	MIMEGzip = "application/gzip"
+	MIMEZip  = "application/zip"
+)
+
+//
+// Errors.
+var (
+	ErrFormat = errors.New("bucket: archive format not supported")
+	ErrLimit  = errors.New("bucket: archive limit exceeded")
+	ErrEntry  = errors.New("bucket: archive entry not valid")
+)
+
+//
+// Format returns the archive format for the MIME type.
+// Returns "" when not an archive.
+func Format(mime string) (format string) {
+	switch mime {
+	case MIMETar:
+		format = Tar
+	case MIMEGzip, "application/x-gzip", "application/x-tar+gzip":
+		format = TarGz
+	case MIMEZip, "application/x-zip-compressed":
+		format = Zip
+	}
+	return
+}
+
+//
+// MIME returns the MIME type for the archive format.
+func MIME(format string) (mime string) {
+	switch format {
+	case Tar:
+		mime = MIMETar
+	case TarGz:
+		mime = MIMEGzip
+	case Zip:
+		mime = MIMEZip
+	}
+	return
+}
+
+//
+// Limits on extracted archives.
+type Limits struct {
+	// Bytes max (extracted) content bytes.
+	Bytes int64
+	// Entries max (extracted) entries.
+	Entries int64
+}
+
+//
+// Opener opens (file) content by path.
+type Opener func(path string) (reader io.ReadCloser, err error)
+
+//
+// Sink receives extracted archive entries.
+// Paths are cleaned and relative to the archive root.
+type Sink interface {
+	// Mkdir creates a directory.
+	Mkdir(path string) (err error)
+	// Put creates a file.
+	Put(path string, reader io.Reader) (err error)
+}
+
+//
+// Write an archive (in the specified format) of the entries.
+// The entry paths are relative to the archive root. File content
+// is opened (by path) using the opener.
+func Write(format string, writer io.Writer, entries []Entry, open Opener) (err error) {
+	switch format {
+	case Tar:
+		err = writeTar(writer, entries, open)
+	case TarGz:
+		zipper := gzip.NewWriter(writer)
+		err = writeTar(zipper, entries, open)
+		if err != nil {
+			return
+		}
+		err = zipper.Close()
+	case Zip:
+		err = writeZip(writer, entries, open)
+	default:
+		err = fmt.Errorf("%w: %s", ErrFormat, format)
+	}
+	return
+}
+
+//
+// Extract the archive (in the specified format) into the sink.
+// Entries must be (relative) paths within the archive root. Only
+// regular files and directories are supported.
+func Extract(reader io.Reader, format string, sink Sink, limits Limits) (err error) {
+	extractor := &extractor{sink: sink, limits: limits}
+	switch format {
+	case Tar:
+		err = extractor.tar(reader)
+	case TarGz:
+		var unzipper *gzip.Reader
+		unzipper, err = gzip.NewReader(reader)
+		if err != nil {
+			return
+		}
+		err = extractor.tar(unzipper)
+	case Zip:
+		err = extractor.zip(reader)
+	default:
+		err = fmt.Errorf("%w: %s", ErrFormat, format)
+	}
+	return
+}
+
+//
+// writeTar writes a tar archive.
+func writeTar(writer io.Writer, entries []Entry, open Opener) (err error) {
+	tarWriter := tar.NewWriter(writer)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:    entry.Path,
+			Mode:    int64(entry.Mode.Perm()),
+			ModTime: entry.ModTime,
+		}
+		if entry.IsDir {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = entry.Size
+		}
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return
+		}
+		if entry.IsDir {
+			continue
+		}
+		err = copyContent(tarWriter, entry.Path, open)
+		if err != nil {
+			return
+		}
+	}
+	err = tarWriter.Close()
+	return
+}
+
+//
+// writeZip writes a zip archive.
+func writeZip(writer io.Writer, entries []Entry, open Opener) (err error) {
+	zipWriter := zip.NewWriter(writer)
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.Path,
+			Modified: entry.ModTime,
+		}
+		header.SetMode(entry.Mode)
+		if entry.IsDir {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+		var content io.Writer
+		content, err = zipWriter.CreateHeader(header)
+		if err != nil {
+			return
+		}
+		if entry.IsDir {
+			continue
+		}
+		err = copyContent(content, entry.Path, open)
+		if err != nil {
+			return
+		}
+	}
+	err = zipWriter.Close()
+	return
+}
+
+//
+// copyContent copies the (opened) content to the writer.
+func copyContent(writer io.Writer, path string, open Opener) (err error) {
+	reader, err := open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	_, err = io.Copy(writer, reader)
+	return
+}
+
+//
+// extractor extracts archive entries within limits.
+type extractor struct {
+	// sink receives entries.
+	sink Sink
+	// limits.
+	limits Limits
+	// bytes extracted.
+	bytes int64
+	// entries extracted.
+	entries int64
+}
+
+//
+// tar extracts a tar archive.
+func (r *extractor) tar(reader io.Reader) (err error) {
+	tarReader := tar.NewReader(reader)
+	for {
+		var header *tar.Header
+		header, err = tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = r.dir(header.Name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = r.file(header.Name, tarReader)
+		case tar.TypeXGlobalHeader:
+		default:
+			err = fmt.Errorf("%w: %s (type) not supported", ErrEntry, header.Name)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+//
+// zip extracts a zip archive.
+// The archive is spooled to a temporary file for random access.
+func (r *extractor) zip(reader io.Reader) (err error) {
+	spool, err := os.CreateTemp("", "bucket-*.zip")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+	size, err := io.Copy(spool, reader)
+	if err != nil {
+		return
+	}
+	zipReader, err := zip.NewReader(spool, size)
+	if err != nil {
+		return
+	}
+	for _, entry := range zipReader.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			err = r.dir(entry.Name)
+		case mode.IsRegular():
+			var content io.ReadCloser
+			content, err = entry.Open()
+			if err != nil {
+				return
+			}
+			err = r.file(entry.Name, content)
+			_ = content.Close()
+		default:
+			err = fmt.Errorf("%w: %s (type) not supported", ErrEntry, entry.Name)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// dir extracts a directory entry.
+func (r *extractor) dir(name string) (err error) {
+	path, err := r.path(name)
+	if err != nil || path == "" {
+		return
+	}
+	err = r.sink.Mkdir(path)
+	return
+}
+
+//
+// file extracts a file entry.
+// The content is limited to the remaining bytes.
+func (r *extractor) file(name string, reader io.Reader) (err error) {
+	path, err := r.path(name)
+	if err != nil {
+		return
+	}
+	if path == "" {
+		err = fmt.Errorf("%w: %s (path) not valid", ErrEntry, name)
+		return
+	}
+	limited := &limitedReader{
+		reader:    reader,
+		remaining: r.limits.Bytes - r.bytes,
+	}
+	err = r.sink.Put(path, limited)
+	r.bytes += limited.read
+	if limited.exceeded {
+		err = fmt.Errorf("%w: (%d) bytes", ErrLimit, r.limits.Bytes)
+		return
+	}
+	return
+}
+
+//
+// path returns the (cleaned) path for the entry.
+// The entry must be a relative path within the archive root.
+func (r *extractor) path(name string) (path string, err error) {
+	r.entries++
+	if r.entries > r.limits.Entries {
+		err = fmt.Errorf("%w: (%d) entries", ErrLimit, r.limits.Entries)
+		return
+	}
+	path = strings.ReplaceAll(name, "\\", "/")
+	if pathlib.IsAbs(path) {
+		err = fmt.Errorf("%w: %s (path) not within the bucket", ErrEntry, name)
+		return
+	}
+	path, err = Clean(path)
+	if err != nil {
+		err = fmt.Errorf("%w: %s (path) not within the bucket", ErrEntry, name)
+		return
+	}
+	return
+}
+
+//
+// limitedReader reads up to the remaining bytes.
+// Reading beyond the remaining bytes is an error.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	read      int64
+	exceeded  bool
+	// err reported when exceeded.
+	err error
+}
+
+//
+// Read bytes.
+func (r *limitedReader) Read(b []byte) (n int, err error) {
+	left := r.remaining - r.read
+	if left < int64(len(b)) {
+		b = b[:left+1]
+	}
+	n, err = r.reader.Read(b)
+	r.read += int64(n)
+	if r.read > r.remaining {
+		r.exceeded = true
+		r.read = r.remaining
+		err = r.err
+		if err == nil {
+			err = ErrLimit
+		}
+	}
+	return
+}
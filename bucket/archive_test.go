@@ -0,0 +1,95 @@
+package bucket
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"github.com/onsi/gomega"
+	"os"
+	pathlib "path"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	root := t.TempDir()
+	content := map[string]string{
+		"a.txt":     "AAA",
+		"d1/b.txt":  "BBB",
+		"d1/d2/c":   "CCC",
+		"d3/.empty": "",
+	}
+	for name, s := range content {
+		path := pathlib.Join(root, name)
+		_ = os.MkdirAll(pathlib.Dir(path), 0777)
+		err := os.WriteFile(path, []byte(s), 0666)
+		g.Expect(err).To(gomega.BeNil())
+	}
+	store := &FSStore{}
+	limits := Limits{Bytes: 1024, Entries: 100}
+	for _, format := range []string{Tar, TarGz, Zip} {
+		archive := &bytes.Buffer{}
+		err := store.Archive(root, "", format, archive)
+		g.Expect(err).To(gomega.BeNil())
+		extracted := t.TempDir()
+		err = store.Extract(extracted, "", format, archive, limits)
+		g.Expect(err).To(gomega.BeNil())
+		for name, s := range content {
+			b, err := os.ReadFile(pathlib.Join(extracted, name))
+			g.Expect(err).To(gomega.BeNil())
+			g.Expect(string(b)).To(gomega.Equal(s))
+		}
+	}
+	//
+	// Limits.
+	archive := &bytes.Buffer{}
+	err := store.Archive(root, "", TarGz, archive)
+	g.Expect(err).To(gomega.BeNil())
+	b := archive.Bytes()
+	err = store.Extract(t.TempDir(), "", TarGz, bytes.NewReader(b), Limits{Bytes: 5, Entries: 100})
+	g.Expect(errors.Is(err, ErrLimit)).To(gomega.BeTrue())
+	err = store.Extract(t.TempDir(), "", TarGz, bytes.NewReader(b), Limits{Bytes: 1024, Entries: 2})
+	g.Expect(errors.Is(err, ErrLimit)).To(gomega.BeTrue())
+	//
+	// Format.
+	err = store.Extract(t.TempDir(), "", "rar", bytes.NewReader(b), limits)
+	g.Expect(errors.Is(err, ErrFormat)).To(gomega.BeTrue())
+}
+
+func TestExtractSanitized(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	entries := []*tar.Header{
+		{Name: "../escaped", Typeflag: tar.TypeReg},
+		{Name: "/etc/passwd", Typeflag: tar.TypeReg},
+		{Name: "d/../../escaped", Typeflag: tar.TypeReg},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+		{Name: "hard", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"},
+	}
+	store := &FSStore{}
+	parent := t.TempDir()
+	root := pathlib.Join(parent, "root")
+	_ = os.MkdirAll(root, 0777)
+	for _, header := range entries {
+		archive := &bytes.Buffer{}
+		writer := tar.NewWriter(archive)
+		err := writer.WriteHeader(header)
+		g.Expect(err).To(gomega.BeNil())
+		_ = writer.Close()
+		err = store.Extract(root, "", Tar, archive, Limits{Bytes: 1024, Entries: 100})
+		g.Expect(errors.Is(err, ErrEntry)).To(gomega.BeTrue(), header.Name)
+	}
+	_, err := os.Stat(pathlib.Join(parent, "escaped"))
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+	//
+	// Cleaned within the root.
+	archive := &bytes.Buffer{}
+	writer := tar.NewWriter(archive)
+	_ = writer.WriteHeader(&tar.Header{Name: "./d/../a.txt", Typeflag: tar.TypeReg})
+	_ = writer.Close()
+	err = store.Extract(root, "", Tar, archive, Limits{Bytes: 1024, Entries: 100})
+	g.Expect(err).To(gomega.BeNil())
+	_, err = os.Stat(pathlib.Join(root, "a.txt"))
+	g.Expect(err).To(gomega.BeNil())
+}
@@ -0,0 +1,328 @@
+package bucket
+
+import (
+	"github.com/google/uuid"
+	"io"
+	"os"
+	pathlib "path"
+)
+
+//
+// FSStore filesystem store.
+// The bucket root is the (absolute) path to the bucket directory.
+type FSStore struct {
+	// Path to the store directory.
+	Path string
+}
+
+//
+// Kind of store.
+func (r *FSStore) Kind() (kind string) {
+	kind = Filesystem
+	return
+}
+
+//
+// Create a bucket.
+func (r *FSStore) Create() (root string, err error) {
+	root = pathlib.Join(r.Path, uuid.New().String())
+	err = os.MkdirAll(root, 0777)
+	return
+}
+
+//
+// Remove the bucket and its content.
+func (r *FSStore) Remove(root string) (err error) {
+	err = os.RemoveAll(root)
+	return
+}
+
+//
+// Roots lists the roots of the buckets in the store.
+// The entry path is the root and the ModTime is the
+// modification time of the bucket directory.
+func (r *FSStore) Roots() (roots []Entry, err error) {
+	list, err := os.ReadDir(r.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	for _, ent := range list {
+		if !ent.IsDir() {
+			continue
+		}
+		_, pErr := uuid.Parse(ent.Name())
+		if pErr != nil {
+			continue
+		}
+		info, iErr := ent.Info()
+		if iErr != nil {
+			continue
+		}
+		roots = append(
+			roots,
+			Entry{
+				Name:    ent.Name(),
+				Path:    pathlib.Join(r.Path, ent.Name()),
+				Mode:    info.Mode(),
+				ModTime: info.ModTime(),
+				IsDir:   true,
+			})
+	}
+	return
+}
+
+//
+// Stat the content at the path.
+func (r *FSStore) Stat(root, path string) (entry *Entry, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	real, err := Resolve(root, cleaned)
+	if err != nil {
+		return
+	}
+	st, err := os.Stat(real)
+	if err != nil {
+		return
+	}
+	entry = &Entry{
+		Name:    st.Name(),
+		Path:    cleaned,
+		Size:    st.Size(),
+		Mode:    st.Mode(),
+		ModTime: st.ModTime(),
+		IsDir:   st.IsDir(),
+	}
+	return
+}
+
+//
+// Get the (file) content at the path.
+func (r *FSStore) Get(root, path string) (reader io.ReadSeekCloser, err error) {
+	real, err := Resolve(root, path)
+	if err != nil {
+		return
+	}
+	reader, err = os.Open(real)
+	return
+}
+
+//
+// Put the (file) content at the path.
+// The content is written to a temporary file (in the same
+// directory) which is renamed when complete.
+func (r *FSStore) Put(root, path string, reader io.Reader) (err error) {
+	real, err := Resolve(root, path)
+	if err != nil {
+		return
+	}
+	dir := pathlib.Dir(real)
+	err = os.MkdirAll(dir, 0777)
+	if err != nil {
+		return
+	}
+	file, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return
+	}
+	err = file.Close()
+	if err != nil {
+		return
+	}
+	err = os.Chmod(file.Name(), 0666)
+	if err != nil {
+		return
+	}
+	err = os.Rename(file.Name(), real)
+	return
+}
+
+//
+// List the content of the directory at the path.
+func (r *FSStore) List(root, path string, options ListOptions) (entries []Entry, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	realRoot, err := Resolve(root, "")
+	if err != nil {
+		return
+	}
+	real, err := Resolve(realRoot, cleaned)
+	if err != nil {
+		return
+	}
+	if real == pathlib.Join(realRoot, cleaned) {
+		entries, err = List(realRoot, cleaned, options)
+		return
+	}
+	// Linked (within the bucket).
+	entries, err = List(real, "", options)
+	for i := range entries {
+		entries[i].Path = pathlib.Join(cleaned, entries[i].Path)
+	}
+	return
+}
+
+//
+// Delete the content (file or directory) at the path.
+// Links are deleted rather than followed.
+func (r *FSStore) Delete(root, path string) (err error) {
+	realRoot, err := Resolve(root, "")
+	if err != nil {
+		return
+	}
+	real, err := ResolveNoFollow(realRoot, path)
+	if err != nil {
+		return
+	}
+	_, err = os.Lstat(real)
+	if err != nil {
+		return
+	}
+	if real != realRoot {
+		err = os.RemoveAll(real)
+		return
+	}
+	entries, err := os.ReadDir(real)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		err = os.RemoveAll(pathlib.Join(real, entry.Name()))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// Archive the directory at the path.
+func (r *FSStore) Archive(root, path, format string, writer io.Writer) (err error) {
+	dir, err := Resolve(root, path)
+	if err != nil {
+		return
+	}
+	entries, err := List(dir, "", ListOptions{})
+	if err != nil {
+		return
+	}
+	err = Write(
+		format,
+		writer,
+		entries,
+		func(path string) (io.ReadCloser, error) {
+			return os.Open(pathlib.Join(dir, path))
+		})
+	return
+}
+
+//
+// Extract the archive into the directory at the path.
+// The archive is extracted into a staging directory (within
+// the bucket) and merged when complete.
+func (r *FSStore) Extract(root, path, format string, reader io.Reader, limits Limits) (err error) {
+	realRoot, err := Resolve(root, "")
+	if err != nil {
+		return
+	}
+	dir, err := Resolve(realRoot, path)
+	if err != nil {
+		return
+	}
+	staging, err := os.MkdirTemp(realRoot, ".extract-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+	err = Extract(reader, format, &fsSink{dir: staging}, limits)
+	if err != nil {
+		return
+	}
+	err = r.merge(staging, dir)
+	return
+}
+
+//
+// merge moves the content of the source directory
+// into the destination directory. Links in the destination
+// are replaced rather than followed.
+func (r *FSStore) merge(source, destination string) (err error) {
+	err = os.MkdirAll(destination, 0777)
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		src := pathlib.Join(source, entry.Name())
+		dst := pathlib.Join(destination, entry.Name())
+		st, stErr := os.Lstat(dst)
+		if entry.IsDir() && stErr == nil && st.IsDir() {
+			err = r.merge(src, dst)
+		} else {
+			if stErr == nil {
+				err = os.RemoveAll(dst)
+				if err != nil {
+					return
+				}
+			}
+			err = os.Rename(src, dst)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// fsSink extracts into a directory.
+type fsSink struct {
+	dir string
+}
+
+//
+// Mkdir creates a directory.
+func (r *fsSink) Mkdir(path string) (err error) {
+	err = os.MkdirAll(pathlib.Join(r.dir, path), 0777)
+	return
+}
+
+//
+// Put creates a file.
+func (r *fsSink) Put(path string, reader io.Reader) (err error) {
+	path = pathlib.Join(r.dir, path)
+	err = os.MkdirAll(pathlib.Dir(path), 0777)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	_, err = io.Copy(file, reader)
+	return
+}
@@ -0,0 +1,134 @@
+package bucket
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	pathlib "path"
+	"strings"
+	"time"
+)
+
+//
+// Entry a directory entry.
+type Entry struct {
+	// Name (base) name.
+	Name string
+	// Path relative to the root.
+	Path string
+	// Size in bytes.
+	Size int64
+	// Mode file mode.
+	Mode os.FileMode
+	// ModTime last modified.
+	ModTime time.Time
+	// IsDir is a directory.
+	IsDir bool
+	// Checksum (sha256) of a file.
+	Checksum string
+}
+
+//
+// ListOptions directory listing options.
+type ListOptions struct {
+	// Depth of the listing; 1 = directory content only.
+	// Unlimited when < 1.
+	Depth int
+	// Checksum files.
+	Checksum bool
+}
+
+//
+// List the content of the directory (path) relative to the root.
+// Entries are listed in (depth first) name order. Only regular
+// files and directories are listed.
+func List(root, path string, options ListOptions) (entries []Entry, err error) {
+	entries = []Entry{}
+	err = list(root, path, 1, options, &entries)
+	return
+}
+
+//
+// list the directory content (recursively) to the depth.
+func list(root, path string, depth int, options ListOptions, entries *[]Entry) (err error) {
+	dir := pathlib.Join(root, path)
+	content, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, dirEntry := range content {
+		var info os.FileInfo
+		info, err = dirEntry.Info()
+		if err != nil {
+			return
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			continue
+		}
+		entry := Entry{
+			Name:    info.Name(),
+			Path:    pathlib.Join(path, info.Name()),
+			Size:    info.Size(),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+			IsDir:   info.IsDir(),
+		}
+		if !entry.IsDir && options.Checksum {
+			entry.Checksum, err = Checksum(pathlib.Join(root, entry.Path))
+			if err != nil {
+				return
+			}
+		}
+		*entries = append(*entries, entry)
+		if entry.IsDir && (options.Depth < 1 || depth < options.Depth) {
+			err = list(root, entry.Path, depth+1, options, entries)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+//
+// Checksum returns the (hex encoded) sha256 checksum of the file.
+func Checksum(path string) (sum string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	sum, err = Sum(file)
+	return
+}
+
+//
+// Sum returns the (hex encoded) sha256 checksum of the content.
+func Sum(reader io.Reader) (sum string, err error) {
+	hash := sha256.New()
+	_, err = io.Copy(hash, reader)
+	if err != nil {
+		return
+	}
+	sum = hex.EncodeToString(hash.Sum(nil))
+	return
+}
+
+//
+// less compares paths by component.
+// Orders entries as listed by the filesystem store.
+func less(pathA, pathB string) (b bool) {
+	partA := strings.Split(pathA, "/")
+	partB := strings.Split(pathB, "/")
+	for i := 0; i < len(partA) && i < len(partB); i++ {
+		if partA[i] != partB[i] {
+			b = partA[i] < partB[i]
+			return
+		}
+	}
+	b = len(partA) < len(partB)
+	return
+}
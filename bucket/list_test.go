@@ -0,0 +1,53 @@
+package bucket
+
+import (
+	"github.com/onsi/gomega"
+	"os"
+	pathlib "path"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	root := t.TempDir()
+	_ = os.MkdirAll(pathlib.Join(root, "d1/d2"), 0777)
+	_ = os.WriteFile(pathlib.Join(root, "a.txt"), []byte("AAA"), 0666)
+	_ = os.WriteFile(pathlib.Join(root, "d1/b.txt"), []byte("BB"), 0666)
+	_ = os.WriteFile(pathlib.Join(root, "d1/d2/c.txt"), []byte("C"), 0666)
+	_ = os.Symlink("/etc", pathlib.Join(root, "link"))
+	paths := func(entries []Entry) (list []string) {
+		for _, entry := range entries {
+			list = append(list, entry.Path)
+		}
+		return
+	}
+	//
+	// Depth: 1.
+	entries, err := List(root, "", ListOptions{Depth: 1})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal([]string{"a.txt", "d1"}))
+	g.Expect(entries[0].Size).To(gomega.Equal(int64(3)))
+	g.Expect(entries[0].Checksum).To(gomega.BeEmpty())
+	g.Expect(entries[1].IsDir).To(gomega.BeTrue())
+	//
+	// Depth: 3.
+	entries, err = List(root, "", ListOptions{Depth: 3})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal(
+		[]string{
+			"a.txt",
+			"d1",
+			"d1/b.txt",
+			"d1/d2",
+			"d1/d2/c.txt",
+		}))
+	//
+	// Subdirectory with checksum.
+	entries, err = List(root, "d1", ListOptions{Depth: 1, Checksum: true})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal([]string{"d1/b.txt", "d1/d2"}))
+	g.Expect(entries[0].Checksum).To(gomega.Equal(
+		"fc686c314491e1f68bf1899fc54b2327353c44dd1ab4ed56538ef623edd1e866"))
+	g.Expect(entries[1].Checksum).To(gomega.BeEmpty())
+}
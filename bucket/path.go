@@ -0,0 +1,105 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	pathlib "path"
+	"path/filepath"
+	"strings"
+)
+
+//
+// Errors.
+var (
+	ErrPath = errors.New("bucket: path not within the bucket")
+)
+
+//
+// Resolve the (relative) path within the bucket root.
+// The path is cleaned and symbolic links are resolved. Paths
+// (or links) that reference content outside the root are rejected.
+// Components that do not (yet) exist are not resolved.
+// Returns the (real) path.
+func Resolve(root, rPath string) (path string, err error) {
+	path, err = resolve(root, rPath, true)
+	return
+}
+
+//
+// ResolveNoFollow resolves the (relative) path within the bucket
+// root as Resolve() but the last component is not followed.
+// Used to reference the link itself.
+func ResolveNoFollow(root, rPath string) (path string, err error) {
+	path, err = resolve(root, rPath, false)
+	return
+}
+
+//
+// Clean the (relative) path within the bucket.
+// Returns the cleaned path relative to the root; "" for the root.
+// Paths that reference content outside the root are rejected.
+func Clean(rPath string) (cleaned string, err error) {
+	if strings.ContainsRune(rPath, 0) {
+		err = fmt.Errorf("%w: %q", ErrPath, rPath)
+		return
+	}
+	cleaned = pathlib.Clean(strings.TrimLeft(rPath, "/"))
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		err = fmt.Errorf("%w: %s", ErrPath, rPath)
+		return
+	}
+	if cleaned == "." {
+		cleaned = ""
+	}
+	return
+}
+
+//
+// resolve the path.
+func resolve(root, rPath string, follow bool) (path string, err error) {
+	cleaned, err := Clean(rPath)
+	if err != nil {
+		return
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return
+	}
+	path = realRoot
+	if cleaned == "" {
+		return
+	}
+	part := strings.Split(cleaned, "/")
+	for i, name := range part {
+		next := pathlib.Join(path, name)
+		last := i == len(part)-1
+		st, lErr := os.Lstat(next)
+		if lErr != nil {
+			if !os.IsNotExist(lErr) {
+				err = lErr
+				return
+			}
+			path = pathlib.Join(append([]string{path}, part[i:]...)...)
+			return
+		}
+		if st.Mode()&os.ModeSymlink == 0 || (last && !follow) {
+			path = next
+			continue
+		}
+		resolved, eErr := filepath.EvalSymlinks(next)
+		if eErr != nil || !within(realRoot, resolved) {
+			err = fmt.Errorf("%w: %s (link)", ErrPath, rPath)
+			return
+		}
+		path = resolved
+	}
+	return
+}
+
+//
+// within returns true when the path is the root or within the root.
+func within(root, path string) (b bool) {
+	b = path == root || strings.HasPrefix(path, root+"/")
+	return
+}
@@ -0,0 +1,80 @@
+package bucket
+
+import (
+	"errors"
+	"github.com/onsi/gomega"
+	"os"
+	pathlib "path"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	parent, _ := filepath.EvalSymlinks(t.TempDir())
+	root := pathlib.Join(parent, "root")
+	outside := pathlib.Join(parent, "outside")
+	_ = os.MkdirAll(pathlib.Join(root, "d1/d2"), 0777)
+	_ = os.MkdirAll(outside, 0777)
+	_ = os.WriteFile(pathlib.Join(root, "d1/a.txt"), []byte("A"), 0666)
+	_ = os.WriteFile(pathlib.Join(outside, "secret"), []byte("S"), 0666)
+	_ = os.Symlink(pathlib.Join(root, "d1"), pathlib.Join(root, "inside"))
+	_ = os.Symlink("d1/a.txt", pathlib.Join(root, "relative"))
+	_ = os.Symlink(outside, pathlib.Join(root, "escape"))
+	_ = os.Symlink("../outside/secret", pathlib.Join(root, "d1/escape"))
+	_ = os.Symlink("/etc/passwd", pathlib.Join(root, "absolute"))
+	_ = os.Symlink(pathlib.Join(outside, "missing"), pathlib.Join(root, "dangling"))
+	_ = os.Symlink("loop", pathlib.Join(root, "loop"))
+
+	cases := []struct {
+		path     string
+		expected string
+		err      error
+	}{
+		// Clean.
+		{path: "", expected: root},
+		{path: "/", expected: root},
+		{path: ".", expected: root},
+		{path: "/d1/a.txt", expected: root + "/d1/a.txt"},
+		{path: "d1//a.txt", expected: root + "/d1/a.txt"},
+		{path: "/d1/./d2/../a.txt", expected: root + "/d1/a.txt"},
+		{path: "/new/file.txt", expected: root + "/new/file.txt"},
+		// Traversal.
+		{path: "..", err: ErrPath},
+		{path: "/..", err: ErrPath},
+		{path: "/../outside/secret", err: ErrPath},
+		{path: "/d1/../../outside/secret", err: ErrPath},
+		{path: "/d1/d2/../../..", err: ErrPath},
+		{path: "/d1/\x00", err: ErrPath},
+		// Encoded separators (literal names).
+		{path: "/..%2F..%2Foutside%2Fsecret", expected: root + "/..%2F..%2Foutside%2Fsecret"},
+		{path: "/%2e%2e/outside/secret", expected: root + "/%2e%2e/outside/secret"},
+		{path: "/..\\..\\outside\\secret", expected: root + "/..\\..\\outside\\secret"},
+		// Links.
+		{path: "/inside/a.txt", expected: root + "/d1/a.txt"},
+		{path: "/relative", expected: root + "/d1/a.txt"},
+		{path: "/escape", err: ErrPath},
+		{path: "/escape/secret", err: ErrPath},
+		{path: "/d1/escape", err: ErrPath},
+		{path: "/absolute", err: ErrPath},
+		{path: "/dangling", err: ErrPath},
+		{path: "/loop", err: ErrPath},
+	}
+	for _, c := range cases {
+		path, err := Resolve(root, c.path)
+		if c.err != nil {
+			g.Expect(errors.Is(err, c.err)).To(gomega.BeTrue(), c.path)
+			continue
+		}
+		g.Expect(err).To(gomega.BeNil(), c.path)
+		g.Expect(path).To(gomega.Equal(c.expected), c.path)
+	}
+	//
+	// Link not followed.
+	path, err := ResolveNoFollow(root, "/escape")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(path).To(gomega.Equal(root + "/escape"))
+	_, err = ResolveNoFollow(root, "/escape/secret")
+	g.Expect(errors.Is(err, ErrPath)).To(gomega.BeTrue())
+}
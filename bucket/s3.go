@@ -0,0 +1,588 @@
+package bucket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"net/url"
+	"os"
+	pathlib "path"
+	"sort"
+	"strings"
+)
+
+//
+// DefaultPartSize (bytes) of multipart uploads.
+const DefaultPartSize = 16 << 20
+
+//
+// S3Options object store options.
+type S3Options struct {
+	// Endpoint host:port or URL.
+	Endpoint string
+	// Bucket (object store) name.
+	Bucket string
+	// Region.
+	Region string
+	// AccessKey credentials.
+	AccessKey string
+	// SecretKey credentials.
+	SecretKey string
+	// TLS enabled.
+	TLS bool
+}
+
+//
+// S3Store S3 (compatible) object store.
+// The bucket root is the (object) key prefix. Object stores
+// have no directories; a directory is the key prefix of the
+// objects it contains. Empty directories are not stored.
+type S3Store struct {
+	// Bucket (object store) name.
+	Bucket string
+	// PartSize (bytes) of multipart uploads.
+	PartSize uint64
+	// client.
+	client *minio.Client
+}
+
+//
+// NewS3Store returns an object store.
+func NewS3Store(options S3Options) (store *S3Store, err error) {
+	endpoint := options.Endpoint
+	secure := options.TLS
+	if u, pErr := url.Parse(endpoint); pErr == nil && u.Host != "" {
+		endpoint = u.Host
+		secure = u.Scheme == "https"
+	}
+	client, err := minio.New(
+		endpoint,
+		&minio.Options{
+			Creds: credentials.NewStaticV4(
+				options.AccessKey,
+				options.SecretKey,
+				""),
+			Secure: secure,
+			Region: options.Region,
+		})
+	if err != nil {
+		return
+	}
+	store = &S3Store{
+		Bucket:   options.Bucket,
+		PartSize: DefaultPartSize,
+		client:   client,
+	}
+	return
+}
+
+//
+// Kind of store.
+func (r *S3Store) Kind() (kind string) {
+	kind = S3
+	return
+}
+
+//
+// Create a bucket.
+func (r *S3Store) Create() (root string, err error) {
+	root = uuid.New().String()
+	return
+}
+
+//
+// Remove the bucket and its content.
+func (r *S3Store) Remove(root string) (err error) {
+	_, err = r.removeAll(r.prefix(root, ""))
+	return
+}
+
+//
+// Roots lists the roots of the buckets in the store.
+// Empty buckets are not stored and are not listed. The
+// ModTime is the latest modification of the bucket objects.
+func (r *S3Store) Roots() (roots []Entry, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	found := make(map[string]*Entry)
+	for object := range r.client.ListObjects(
+		ctx,
+		r.Bucket,
+		minio.ListObjectsOptions{
+			Recursive: true,
+		}) {
+		if object.Err != nil {
+			err = object.Err
+			return
+		}
+		root := strings.SplitN(object.Key, "/", 2)[0]
+		_, pErr := uuid.Parse(root)
+		if pErr != nil {
+			continue
+		}
+		entry, listed := found[root]
+		if !listed {
+			entry = r.dir(root, "")
+			entry.Path = root
+			found[root] = entry
+		}
+		if object.LastModified.After(entry.ModTime) {
+			entry.ModTime = object.LastModified
+		}
+	}
+	for _, entry := range found {
+		roots = append(roots, *entry)
+	}
+	sort.Slice(
+		roots,
+		func(i, j int) bool {
+			return roots[i].Path < roots[j].Path
+		})
+	return
+}
+
+//
+// Stat the content at the path.
+func (r *S3Store) Stat(root, path string) (entry *Entry, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	if cleaned == "" {
+		entry = r.dir(root, cleaned)
+		return
+	}
+	key := r.key(root, cleaned)
+	info, err := r.client.StatObject(
+		context.Background(),
+		r.Bucket,
+		key,
+		minio.StatObjectOptions{})
+	if err == nil {
+		entry = r.file(cleaned, &info)
+		return
+	}
+	if !r.missing(err) {
+		return
+	}
+	found, err := r.exists(r.prefix(root, cleaned))
+	if err != nil {
+		return
+	}
+	if !found {
+		err = fmt.Errorf("%w: %s", os.ErrNotExist, cleaned)
+		return
+	}
+	entry = r.dir(root, cleaned)
+	return
+}
+
+//
+// Get the (file) content at the path.
+func (r *S3Store) Get(root, path string) (reader io.ReadSeekCloser, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	object, err := r.client.GetObject(
+		context.Background(),
+		r.Bucket,
+		r.key(root, cleaned),
+		minio.GetObjectOptions{})
+	if err != nil {
+		return
+	}
+	_, err = object.Stat()
+	if err != nil {
+		_ = object.Close()
+		if r.missing(err) {
+			err = fmt.Errorf("%w: %s", os.ErrNotExist, cleaned)
+		}
+		return
+	}
+	reader = object
+	return
+}
+
+//
+// Put the (file) content at the path.
+// Content smaller than the part size is uploaded using a
+// single request. Otherwise, a multipart upload is used.
+func (r *S3Store) Put(root, path string, reader io.Reader) (err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	if cleaned == "" {
+		err = fmt.Errorf("%w: %s (root) not a file", ErrPath, path)
+		return
+	}
+	partSize := r.PartSize
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+	options := minio.PutObjectOptions{
+		PartSize: partSize,
+	}
+	part, err := io.ReadAll(io.LimitReader(reader, int64(partSize)))
+	if err != nil {
+		return
+	}
+	content := io.Reader(bytes.NewReader(part))
+	size := int64(len(part))
+	if uint64(size) == partSize {
+		content = io.MultiReader(content, reader)
+		size = -1
+	}
+	_, err = r.client.PutObject(
+		context.Background(),
+		r.Bucket,
+		r.key(root, cleaned),
+		content,
+		size,
+		options)
+	return
+}
+
+//
+// List the content of the directory at the path.
+// Directories are derived from the object keys.
+func (r *S3Store) List(root, path string, options ListOptions) (entries []Entry, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries = []Entry{}
+	dirs := make(map[string]int)
+	prefix := r.prefix(root, cleaned)
+	for object := range r.client.ListObjects(
+		ctx,
+		r.Bucket,
+		minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+		}) {
+		if object.Err != nil {
+			err = object.Err
+			return
+		}
+		part := strings.Split(strings.TrimPrefix(object.Key, prefix), "/")
+		for i := 1; i < len(part); i++ {
+			if options.Depth > 0 && i > options.Depth {
+				break
+			}
+			dirPath := pathlib.Join(cleaned, pathlib.Join(part[:i]...))
+			if index, found := dirs[dirPath]; found {
+				if object.LastModified.After(entries[index].ModTime) {
+					entries[index].ModTime = object.LastModified
+				}
+				continue
+			}
+			entry := r.dir(root, dirPath)
+			entry.ModTime = object.LastModified
+			dirs[dirPath] = len(entries)
+			entries = append(entries, *entry)
+		}
+		if options.Depth > 0 && len(part) > options.Depth {
+			continue
+		}
+		entry := r.file(pathlib.Join(cleaned, pathlib.Join(part...)), &object)
+		if options.Checksum {
+			entry.Checksum, err = r.checksum(object.Key)
+			if err != nil {
+				return
+			}
+		}
+		entries = append(entries, *entry)
+	}
+	if cleaned != "" && len(entries) == 0 {
+		err = fmt.Errorf("%w: %s", os.ErrNotExist, cleaned)
+		return
+	}
+	sort.SliceStable(
+		entries,
+		func(i, j int) bool {
+			return less(entries[i].Path, entries[j].Path)
+		})
+	return
+}
+
+//
+// Delete the content (file or directory) at the path.
+// Deleting the root empties the bucket.
+func (r *S3Store) Delete(root, path string) (err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	if cleaned != "" {
+		key := r.key(root, cleaned)
+		_, err = r.client.StatObject(
+			context.Background(),
+			r.Bucket,
+			key,
+			minio.StatObjectOptions{})
+		if err == nil {
+			err = r.client.RemoveObject(
+				context.Background(),
+				r.Bucket,
+				key,
+				minio.RemoveObjectOptions{})
+			return
+		}
+		if !r.missing(err) {
+			return
+		}
+	}
+	n, err := r.removeAll(r.prefix(root, cleaned))
+	if err != nil {
+		return
+	}
+	if n == 0 && cleaned != "" {
+		err = fmt.Errorf("%w: %s", os.ErrNotExist, cleaned)
+	}
+	return
+}
+
+//
+// Archive the directory at the path.
+func (r *S3Store) Archive(root, path, format string, writer io.Writer) (err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	entries, err := r.List(root, cleaned, ListOptions{})
+	if err != nil {
+		return
+	}
+	for i := range entries {
+		entry := &entries[i]
+		entry.Path = strings.TrimPrefix(entry.Path, cleaned)
+		entry.Path = strings.TrimPrefix(entry.Path, "/")
+	}
+	err = Write(
+		format,
+		writer,
+		entries,
+		func(path string) (io.ReadCloser, error) {
+			return r.client.GetObject(
+				context.Background(),
+				r.Bucket,
+				r.key(root, pathlib.Join(cleaned, path)),
+				minio.GetObjectOptions{})
+		})
+	return
+}
+
+//
+// Extract the archive into the directory at the path.
+// The archive is extracted into a staging prefix (within the
+// bucket) and copied (server side) when complete.
+func (r *S3Store) Extract(root, path, format string, reader io.Reader, limits Limits) (err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	staging := ".extract-" + uuid.New().String()
+	stagingPrefix := r.prefix(root, staging)
+	defer func() {
+		_, _ = r.removeAll(stagingPrefix)
+	}()
+	sink := &s3Sink{
+		store: r,
+		root:  root,
+		dir:   staging,
+	}
+	err = Extract(reader, format, sink, limits)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for object := range r.client.ListObjects(
+		ctx,
+		r.Bucket,
+		minio.ListObjectsOptions{
+			Prefix:    stagingPrefix,
+			Recursive: true,
+		}) {
+		if object.Err != nil {
+			err = object.Err
+			return
+		}
+		rel := strings.TrimPrefix(object.Key, stagingPrefix)
+		_, err = r.client.CopyObject(
+			ctx,
+			minio.CopyDestOptions{
+				Bucket: r.Bucket,
+				Object: r.key(root, pathlib.Join(cleaned, rel)),
+			},
+			minio.CopySrcOptions{
+				Bucket: r.Bucket,
+				Object: object.Key,
+			})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// key returns the object key for the (cleaned) path.
+func (r *S3Store) key(root, path string) (key string) {
+	key = pathlib.Join(root, path)
+	return
+}
+
+//
+// prefix returns the key prefix of the (cleaned) directory path.
+func (r *S3Store) prefix(root, path string) (prefix string) {
+	prefix = r.key(root, path) + "/"
+	return
+}
+
+//
+// dir returns a directory entry.
+func (r *S3Store) dir(root, path string) (entry *Entry) {
+	name := pathlib.Base(path)
+	if path == "" {
+		name = root
+	}
+	entry = &Entry{
+		Name:  name,
+		Path:  path,
+		Mode:  os.ModeDir | 0777,
+		IsDir: true,
+	}
+	return
+}
+
+//
+// file returns a file entry.
+func (r *S3Store) file(path string, info *minio.ObjectInfo) (entry *Entry) {
+	entry = &Entry{
+		Name:    pathlib.Base(path),
+		Path:    path,
+		Size:    info.Size,
+		Mode:    0666,
+		ModTime: info.LastModified,
+	}
+	return
+}
+
+//
+// exists returns true when objects exist with the prefix.
+func (r *S3Store) exists(prefix string) (found bool, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for object := range r.client.ListObjects(
+		ctx,
+		r.Bucket,
+		minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+			MaxKeys:   1,
+		}) {
+		err = object.Err
+		found = err == nil
+		break
+	}
+	return
+}
+
+//
+// removeAll removes the objects with the prefix.
+// Returns the number of objects removed.
+func (r *S3Store) removeAll(prefix string) (n int, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var listErr error
+	objects := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objects)
+		for object := range r.client.ListObjects(
+			ctx,
+			r.Bucket,
+			minio.ListObjectsOptions{
+				Prefix:    prefix,
+				Recursive: true,
+			}) {
+			if object.Err != nil {
+				listErr = object.Err
+				return
+			}
+			n++
+			objects <- object
+		}
+	}()
+	for removeErr := range r.client.RemoveObjects(
+		ctx,
+		r.Bucket,
+		objects,
+		minio.RemoveObjectsOptions{}) {
+		if err == nil {
+			err = removeErr.Err
+		}
+	}
+	if err == nil {
+		err = listErr
+	}
+	return
+}
+
+//
+// checksum returns the (sha256) checksum of the object.
+func (r *S3Store) checksum(key string) (sum string, err error) {
+	object, err := r.client.GetObject(
+		context.Background(),
+		r.Bucket,
+		key,
+		minio.GetObjectOptions{})
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = object.Close()
+	}()
+	sum, err = Sum(object)
+	return
+}
+
+//
+// missing returns true when the error reports the object not found.
+func (r *S3Store) missing(err error) (b bool) {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		b = true
+	}
+	return
+}
+
+//
+// s3Sink extracts into a key prefix.
+type s3Sink struct {
+	store *S3Store
+	root  string
+	dir   string
+}
+
+//
+// Mkdir creates a directory.
+// Object stores have no directories.
+func (r *s3Sink) Mkdir(path string) (err error) {
+	return
+}
+
+//
+// Put creates a file.
+func (r *s3Sink) Put(path string, reader io.Reader) (err error) {
+	err = r.store.Put(r.root, pathlib.Join(r.dir, path), reader)
+	return
+}
@@ -0,0 +1,232 @@
+package bucket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/onsi/gomega"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestS3Store(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	fake := &fakeS3{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store, err := NewS3Store(
+		S3Options{
+			Endpoint:  server.URL,
+			Bucket:    "hub",
+			Region:    "us-east-1",
+			AccessKey: "access",
+			SecretKey: "secret",
+		})
+	g.Expect(err).To(gomega.BeNil())
+	testStore(t, store)
+	//
+	// Multipart.
+	store.PartSize = 5 << 20
+	root, _ := store.Create()
+	content := bytes.Repeat([]byte("0123456789"), 600000)
+	err = store.Put(root, "large", bytes.NewReader(content))
+	g.Expect(err).To(gomega.BeNil())
+	reader, err := store.Get(root, "large")
+	g.Expect(err).To(gomega.BeNil())
+	b, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	g.Expect(bytes.Equal(b, content)).To(gomega.BeTrue())
+	err = store.Remove(root)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(fake.objects).To(gomega.BeEmpty())
+}
+
+//
+// fakeModTime object last modified.
+var fakeModTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//
+// fakeS3 a (minimal) S3 compatible object store.
+// Supports path style object, list, delete, copy and
+// multipart requests for a single bucket.
+type fakeS3 struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+	parts   map[string]map[int][]byte
+}
+
+//
+// ServeHTTP handles requests.
+func (r *fakeS3) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.objects == nil {
+		r.objects = make(map[string][]byte)
+		r.parts = make(map[string]map[int][]byte)
+	}
+	part := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	key := ""
+	if len(part) > 1 {
+		key = part[1]
+	}
+	query := req.URL.Query()
+	switch {
+	case key == "" && req.Method == http.MethodGet:
+		r.list(w, query.Get("prefix"))
+	case key == "" && req.Method == http.MethodPost:
+		r.delete(w, req)
+	case req.Method == http.MethodPost && has(query, "uploads"):
+		uploadID := strconv.Itoa(len(r.parts) + 1)
+		r.parts[uploadID] = make(map[int][]byte)
+		r.reply(w, "InitiateMultipartUploadResult", "<UploadId>"+uploadID+"</UploadId>")
+	case req.Method == http.MethodPost && has(query, "uploadId"):
+		parts := r.parts[query.Get("uploadId")]
+		numbers := []int{}
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		content := []byte{}
+		for _, n := range numbers {
+			content = append(content, parts[n]...)
+		}
+		r.objects[key] = content
+		delete(r.parts, query.Get("uploadId"))
+		r.reply(
+			w,
+			"CompleteMultipartUploadResult",
+			"<Bucket>"+part[0]+"</Bucket><Key>"+key+"</Key><ETag>\"etag\"</ETag>")
+	case req.Method == http.MethodPut && has(query, "uploadId"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		r.parts[query.Get("uploadId")][n] = r.body(req)
+		w.Header().Set("ETag", fmt.Sprintf("\"part%d\"", n))
+	case req.Method == http.MethodPut && req.Header.Get("X-Amz-Copy-Source") != "":
+		source, _ := url.PathUnescape(req.Header.Get("X-Amz-Copy-Source"))
+		source = strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)[1]
+		content, found := r.objects[source]
+		if !found {
+			r.notFound(w, source)
+			return
+		}
+		r.objects[key] = content
+		r.reply(
+			w,
+			"CopyObjectResult",
+			"<LastModified>"+time.Now().UTC().Format(time.RFC3339)+"</LastModified><ETag>\"etag\"</ETag>")
+	case req.Method == http.MethodPut:
+		r.objects[key] = r.body(req)
+		w.Header().Set("ETag", "\"etag\"")
+	case req.Method == http.MethodDelete:
+		delete(r.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodHead || req.Method == http.MethodGet:
+		content, found := r.objects[key]
+		if !found {
+			if req.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				r.notFound(w, key)
+			}
+			return
+		}
+		w.Header().Set("ETag", "\"etag\"")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, req, key, fakeModTime, bytes.NewReader(content))
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+//
+// list objects (V2) with the prefix.
+func (r *fakeS3) list(w http.ResponseWriter, prefix string) {
+	keys := []string{}
+	for key := range r.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	body := "<IsTruncated>false</IsTruncated>"
+	for _, key := range keys {
+		body += fmt.Sprintf(
+			"<Contents><Key>%s</Key><LastModified>%s</LastModified><Size>%d</Size></Contents>",
+			key,
+			fakeModTime.Format(time.RFC3339),
+			len(r.objects[key]))
+	}
+	r.reply(w, "ListBucketResult", body)
+}
+
+//
+// delete (multiple) objects.
+func (r *fakeS3) delete(w http.ResponseWriter, req *http.Request) {
+	request := struct {
+		Object []struct {
+			Key string
+		}
+	}{}
+	_ = xml.NewDecoder(req.Body).Decode(&request)
+	for _, object := range request.Object {
+		delete(r.objects, object.Key)
+	}
+	r.reply(w, "DeleteResult", "")
+}
+
+//
+// body returns the request body.
+// Streaming (signed) chunks are decoded.
+func (r *fakeS3) body(req *http.Request) (content []byte) {
+	if !strings.HasPrefix(req.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		content, _ = io.ReadAll(req.Body)
+		return
+	}
+	reader := bufio.NewReader(req.Body)
+	buffer := &bytes.Buffer{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		size, _ := strconv.ParseInt(strings.SplitN(strings.TrimSpace(line), ";", 2)[0], 16, 64)
+		if size == 0 {
+			break
+		}
+		_, _ = io.CopyN(buffer, reader, size)
+		_, _ = reader.ReadString('\n')
+	}
+	content = buffer.Bytes()
+	return
+}
+
+//
+// reply with an XML document.
+func (r *fakeS3) reply(w http.ResponseWriter, kind, body string) {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = io.WriteString(w, "<"+kind+">"+body+"</"+kind+">")
+}
+
+//
+// notFound replies NoSuchKey.
+func (r *fakeS3) notFound(w http.ResponseWriter, key string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code><Key>"+key+"</Key></Error>")
+}
+
+//
+// has returns true when the query contains the parameter.
+func has(query url.Values, name string) (found bool) {
+	_, found = query[name]
+	return
+}
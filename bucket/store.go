@@ -0,0 +1,48 @@
+package bucket
+
+import (
+	"io"
+)
+
+//
+// Store kinds.
+const (
+	Filesystem = "filesystem"
+	S3         = "s3"
+)
+
+//
+// Store of bucket content.
+// A bucket is identified (within the store) by its root. Content
+// paths are relative to the root and are cleaned by the store.
+// Missing content is reported as os.ErrNotExist and paths not
+// within the bucket as ErrPath.
+type Store interface {
+	// Kind of store.
+	Kind() (kind string)
+	// Create a bucket.
+	// Returns the bucket root.
+	Create() (root string, err error)
+	// Remove the bucket and its content.
+	Remove(root string) (err error)
+	// Roots lists the roots of the buckets in the store.
+	// Only roots created by the store are listed.
+	Roots() (roots []Entry, err error)
+	// Stat the content at the path.
+	Stat(root, path string) (entry *Entry, err error)
+	// Get the (file) content at the path.
+	Get(root, path string) (reader io.ReadSeekCloser, err error)
+	// Put the (file) content at the path.
+	// Parent directories are created as needed.
+	Put(root, path string, reader io.Reader) (err error)
+	// List the content of the directory at the path.
+	List(root, path string, options ListOptions) (entries []Entry, err error)
+	// Delete the content (file or directory) at the path.
+	// Deleting the root empties the bucket.
+	Delete(root, path string) (err error)
+	// Archive the directory at the path.
+	Archive(root, path, format string, writer io.Writer) (err error)
+	// Extract the archive into the directory at the path.
+	// The archive is staged and merged when complete.
+	Extract(root, path, format string, reader io.Reader, limits Limits) (err error)
+}
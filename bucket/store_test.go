@@ -0,0 +1,173 @@
+package bucket
+
+import (
+	"bytes"
+	"errors"
+	"github.com/onsi/gomega"
+	"io"
+	"os"
+	pathlib "path"
+	"strings"
+	"testing"
+)
+
+func TestFSStore(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := &FSStore{Path: t.TempDir()}
+	testStore(t, store)
+	//
+	// Roots.
+	err := os.Mkdir(pathlib.Join(store.Path, "lost+found"), 0777)
+	g.Expect(err).To(gomega.BeNil())
+	root, err := store.Create()
+	g.Expect(err).To(gomega.BeNil())
+	roots, err := store.Roots()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(roots)).To(gomega.Equal(1))
+	g.Expect(roots[0].Path).To(gomega.Equal(root))
+	g.Expect(roots[0].IsDir).To(gomega.BeTrue())
+	roots, err = (&FSStore{Path: pathlib.Join(store.Path, "missing")}).Roots()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(roots).To(gomega.BeEmpty())
+}
+
+//
+// testStore tests the store contract.
+func testStore(t *testing.T, store Store) {
+	g := gomega.NewGomegaWithT(t)
+
+	root, err := store.Create()
+	g.Expect(err).To(gomega.BeNil())
+	content := map[string]string{
+		"a.txt":       "AAA",
+		"d1/b.txt":    "BB",
+		"d1/d2/c.txt": "C",
+	}
+	for path, s := range content {
+		err = store.Put(root, "/"+path, strings.NewReader(s))
+		g.Expect(err).To(gomega.BeNil())
+	}
+	//
+	// Roots.
+	roots, err := store.Roots()
+	g.Expect(err).To(gomega.BeNil())
+	listed := false
+	for _, entry := range roots {
+		if entry.Path == root {
+			listed = true
+		}
+	}
+	g.Expect(listed).To(gomega.BeTrue())
+	//
+	// Stat.
+	entry, err := store.Stat(root, "/")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entry.IsDir).To(gomega.BeTrue())
+	entry, err = store.Stat(root, "d1")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entry.IsDir).To(gomega.BeTrue())
+	entry, err = store.Stat(root, "/d1/b.txt")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entry.IsDir).To(gomega.BeFalse())
+	g.Expect(entry.Name).To(gomega.Equal("b.txt"))
+	g.Expect(entry.Path).To(gomega.Equal("d1/b.txt"))
+	g.Expect(entry.Size).To(gomega.Equal(int64(2)))
+	_, err = store.Stat(root, "missing")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+	_, err = store.Stat(root, "../escaped")
+	g.Expect(errors.Is(err, ErrPath)).To(gomega.BeTrue())
+	//
+	// Get.
+	reader, err := store.Get(root, "a.txt")
+	g.Expect(err).To(gomega.BeNil())
+	_, err = reader.Seek(1, io.SeekStart)
+	g.Expect(err).To(gomega.BeNil())
+	b, err := io.ReadAll(reader)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(string(b)).To(gomega.Equal("AA"))
+	_ = reader.Close()
+	_, err = store.Get(root, "missing")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+	//
+	// Replace.
+	err = store.Put(root, "a.txt", strings.NewReader("A"))
+	g.Expect(err).To(gomega.BeNil())
+	entry, err = store.Stat(root, "a.txt")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entry.Size).To(gomega.Equal(int64(1)))
+	//
+	// List.
+	paths := func(entries []Entry) (list []string) {
+		for _, entry := range entries {
+			list = append(list, entry.Path)
+		}
+		return
+	}
+	entries, err := store.List(root, "", ListOptions{Depth: 1})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal([]string{"a.txt", "d1"}))
+	entries, err = store.List(root, "", ListOptions{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal(
+		[]string{
+			"a.txt",
+			"d1",
+			"d1/b.txt",
+			"d1/d2",
+			"d1/d2/c.txt",
+		}))
+	entries, err = store.List(root, "/d1", ListOptions{Depth: 1, Checksum: true})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal([]string{"d1/b.txt", "d1/d2"}))
+	g.Expect(entries[0].Checksum).To(gomega.Equal(
+		"fc686c314491e1f68bf1899fc54b2327353c44dd1ab4ed56538ef623edd1e866"))
+	//
+	// Archive and extract.
+	archive := &bytes.Buffer{}
+	err = store.Archive(root, "d1", TarGz, archive)
+	g.Expect(err).To(gomega.BeNil())
+	err = store.Extract(root, "copy", TarGz, archive, Limits{Bytes: 1024, Entries: 100})
+	g.Expect(err).To(gomega.BeNil())
+	entries, err = store.List(root, "copy", ListOptions{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal(
+		[]string{
+			"copy/b.txt",
+			"copy/d2",
+			"copy/d2/c.txt",
+		}))
+	entries, err = store.List(root, "", ListOptions{Depth: 1})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(paths(entries)).To(gomega.Equal([]string{"a.txt", "copy", "d1"}))
+	archive.Reset()
+	err = store.Archive(root, "d1", Tar, archive)
+	g.Expect(err).To(gomega.BeNil())
+	err = store.Extract(root, "limited", Tar, archive, Limits{Bytes: 2, Entries: 100})
+	g.Expect(errors.Is(err, ErrLimit)).To(gomega.BeTrue())
+	_, err = store.Stat(root, "limited")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+	//
+	// Delete.
+	err = store.Delete(root, "d1/d2")
+	g.Expect(err).To(gomega.BeNil())
+	_, err = store.Stat(root, "d1/d2/c.txt")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+	err = store.Delete(root, "a.txt")
+	g.Expect(err).To(gomega.BeNil())
+	err = store.Delete(root, "a.txt")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+	err = store.Delete(root, "/")
+	g.Expect(err).To(gomega.BeNil())
+	entries, err = store.List(root, "", ListOptions{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entries).To(gomega.BeEmpty())
+	//
+	// Remove.
+	err = store.Put(root, "a.txt", strings.NewReader("A"))
+	g.Expect(err).To(gomega.BeNil())
+	err = store.Remove(root)
+	g.Expect(err).To(gomega.BeNil())
+	_, err = store.Stat(root, "a.txt")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+}
@@ -0,0 +1,51 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+//
+// Errors.
+var (
+	ErrQuota = errors.New("bucket: quota exceeded")
+)
+
+//
+// Usage of a bucket.
+type Usage struct {
+	// Bytes (file) content bytes.
+	Bytes int64
+	// Files number of files.
+	Files int64
+}
+
+//
+// Measure returns the usage of the bucket.
+func Measure(store Store, root string) (usage Usage, err error) {
+	entries, err := store.List(root, "", ListOptions{})
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		usage.Bytes += entry.Size
+		usage.Files++
+	}
+	return
+}
+
+//
+// QuotaReader returns a reader that reads up to the remaining
+// (quota) bytes. Reading beyond the remaining bytes is reported
+// as ErrQuota.
+func QuotaReader(reader io.Reader, remaining int64) io.Reader {
+	return &limitedReader{
+		reader:    reader,
+		remaining: remaining,
+		err:       fmt.Errorf("%w: (%d) bytes remaining", ErrQuota, remaining),
+	}
+}
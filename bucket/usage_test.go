@@ -0,0 +1,46 @@
+package bucket
+
+import (
+	"errors"
+	"github.com/onsi/gomega"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := &FSStore{Path: t.TempDir()}
+	root, err := store.Create()
+	g.Expect(err).To(gomega.BeNil())
+	usage, err := Measure(store, root)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(usage).To(gomega.Equal(Usage{}))
+	_ = store.Put(root, "a.txt", strings.NewReader("AAA"))
+	_ = store.Put(root, "d1/b.txt", strings.NewReader("BB"))
+	_ = store.Put(root, "d1/d2/c.txt", strings.NewReader("C"))
+	usage, err = Measure(store, root)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(usage).To(gomega.Equal(Usage{Bytes: 6, Files: 3}))
+}
+
+func TestQuotaReader(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	b, err := io.ReadAll(QuotaReader(strings.NewReader("AAA"), 3))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(string(b)).To(gomega.Equal("AAA"))
+	_, err = io.ReadAll(QuotaReader(strings.NewReader("AAAA"), 3))
+	g.Expect(errors.Is(err, ErrQuota)).To(gomega.BeTrue())
+	_, err = io.ReadAll(QuotaReader(strings.NewReader("A"), 0))
+	g.Expect(errors.Is(err, ErrQuota)).To(gomega.BeTrue())
+	//
+	// Store write rejected.
+	store := &FSStore{Path: t.TempDir()}
+	root, _ := store.Create()
+	err = store.Put(root, "a.txt", QuotaReader(strings.NewReader("AAAA"), 3))
+	g.Expect(errors.Is(err, ErrQuota)).To(gomega.BeTrue())
+	_, err = store.Stat(root, "a.txt")
+	g.Expect(err).ToNot(gomega.BeNil())
+}
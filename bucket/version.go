@@ -0,0 +1,367 @@
+package bucket
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	pathlib "path"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// ErrChanged reports content changed while copied.
+var ErrChanged = errors.New("bucket: content changed")
+
+//
+// Object a (content addressed) file within a snapshot.
+type Object struct {
+	// Path relative to the bucket root.
+	Path string
+	// Size in bytes.
+	Size int64
+	// Mode file mode.
+	Mode os.FileMode
+	// ModTime last modified.
+	ModTime time.Time
+	// Digest (sha256) of the content.
+	Digest string
+}
+
+//
+// Digester returns the (sha256) digest of the file content.
+type Digester func(entry *Entry) (digest string, err error)
+
+//
+// Snapshot the bucket content.
+// Each file is copied (once) into the object root at a path
+// determined by the digest of its content. The (optional) digester
+// may provide known digests; otherwise the content is read.
+// Returns the manifest of the snapshot.
+func Snapshot(store Store, root, objects string, digest Digester) (manifest Manifest, err error) {
+	entries, err := store.List(root, "", ListOptions{})
+	if err != nil {
+		return
+	}
+	manifest = Manifest{}
+	for i := range entries {
+		entry := &entries[i]
+		if entry.IsDir {
+			continue
+		}
+		object := Object{
+			Path:    entry.Path,
+			Size:    entry.Size,
+			Mode:    entry.Mode,
+			ModTime: entry.ModTime,
+		}
+		if digest != nil {
+			object.Digest, err = digest(entry)
+		} else {
+			object.Digest, err = readDigest(store, root, entry.Path)
+		}
+		if err != nil {
+			return
+		}
+		err = copyObject(store, root, objects, &object)
+		if err != nil {
+			return
+		}
+		manifest = append(manifest, object)
+	}
+	sort.SliceStable(
+		manifest,
+		func(i, j int) bool {
+			return less(manifest[i].Path, manifest[j].Path)
+		})
+	return
+}
+
+//
+// ObjectPath returns the path (within the object root) of
+// the content with the digest.
+func ObjectPath(digest string) (path string) {
+	path = digest
+	if len(digest) > 2 {
+		path = pathlib.Join(digest[:2], digest)
+	}
+	return
+}
+
+//
+// readDigest returns the digest of the file content.
+func readDigest(store Store, root, path string) (digest string, err error) {
+	reader, err := store.Get(root, path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	digest, err = Sum(reader)
+	return
+}
+
+//
+// copyObject copies the file content into the object root.
+// Content already stored is not copied. The copied content
+// must match the digest.
+func copyObject(store Store, root, objects string, object *Object) (err error) {
+	path := ObjectPath(object.Digest)
+	_, err = store.Stat(objects, path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	reader, err := store.Get(root, object.Path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	hash := sha256.New()
+	err = store.Put(objects, path, io.TeeReader(reader, hash))
+	if err != nil {
+		return
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != object.Digest {
+		_ = store.Delete(objects, path)
+		err = fmt.Errorf("%w: %s", ErrChanged, object.Path)
+	}
+	return
+}
+
+//
+// Manifest lists the (file) objects of a snapshot.
+// Objects are listed in path order.
+type Manifest []Object
+
+//
+// Digest returns the (sha256) digest of the manifest.
+// Snapshots with the same content have the same digest.
+func (m Manifest) Digest() (digest string) {
+	hash := sha256.New()
+	for _, object := range m {
+		_, _ = fmt.Fprintf(hash, "%s %d %s\n", object.Digest, object.Size, object.Path)
+	}
+	digest = hex.EncodeToString(hash.Sum(nil))
+	return
+}
+
+//
+// Bytes returns the total size of the objects.
+func (m Manifest) Bytes() (n int64) {
+	for _, object := range m {
+		n += object.Size
+	}
+	return
+}
+
+//
+// Find the object at the (cleaned) path.
+func (m Manifest) Find(path string) (object *Object, found bool) {
+	i := sort.Search(
+		len(m),
+		func(i int) bool {
+			return !less(m[i].Path, path)
+		})
+	if i < len(m) && m[i].Path == path {
+		object = &m[i]
+		found = true
+	}
+	return
+}
+
+//
+// Stat the content at the path.
+func (m Manifest) Stat(path string) (entry *Entry, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	if object, found := m.Find(cleaned); found {
+		entry = m.file(object)
+		return
+	}
+	entries, err := m.List(cleaned, ListOptions{Depth: 1})
+	if err != nil {
+		return
+	}
+	entry = m.dir(cleaned)
+	for _, listed := range entries {
+		if listed.ModTime.After(entry.ModTime) {
+			entry.ModTime = listed.ModTime
+		}
+	}
+	return
+}
+
+//
+// List the content of the directory at the path.
+// Directories are derived from the object paths.
+func (m Manifest) List(path string, options ListOptions) (entries []Entry, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	entries = []Entry{}
+	dirs := make(map[string]int)
+	prefix := ""
+	if cleaned != "" {
+		prefix = cleaned + "/"
+	}
+	for i := range m {
+		object := &m[i]
+		if !strings.HasPrefix(object.Path, prefix) {
+			continue
+		}
+		part := strings.Split(strings.TrimPrefix(object.Path, prefix), "/")
+		for n := 1; n < len(part); n++ {
+			if options.Depth > 0 && n > options.Depth {
+				break
+			}
+			dirPath := pathlib.Join(cleaned, pathlib.Join(part[:n]...))
+			if index, found := dirs[dirPath]; found {
+				if object.ModTime.After(entries[index].ModTime) {
+					entries[index].ModTime = object.ModTime
+				}
+				continue
+			}
+			entry := m.dir(dirPath)
+			entry.ModTime = object.ModTime
+			dirs[dirPath] = len(entries)
+			entries = append(entries, *entry)
+		}
+		if options.Depth > 0 && len(part) > options.Depth {
+			continue
+		}
+		entry := m.file(object)
+		if !options.Checksum {
+			entry.Checksum = ""
+		}
+		entries = append(entries, *entry)
+	}
+	if cleaned != "" && len(entries) == 0 {
+		err = fmt.Errorf("%w: %s", os.ErrNotExist, cleaned)
+		return
+	}
+	sort.SliceStable(
+		entries,
+		func(i, j int) bool {
+			return less(entries[i].Path, entries[j].Path)
+		})
+	return
+}
+
+//
+// Get the (file) content at the path.
+func (m Manifest) Get(store Store, objects, path string) (reader io.ReadSeekCloser, err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	object, found := m.Find(cleaned)
+	if !found {
+		err = fmt.Errorf("%w: %s", os.ErrNotExist, cleaned)
+		return
+	}
+	reader, err = store.Get(objects, ObjectPath(object.Digest))
+	return
+}
+
+//
+// Archive the directory at the path.
+func (m Manifest) Archive(store Store, objects, path, format string, writer io.Writer) (err error) {
+	cleaned, err := Clean(path)
+	if err != nil {
+		return
+	}
+	entries, err := m.List(cleaned, ListOptions{})
+	if err != nil {
+		return
+	}
+	prefix := ""
+	if cleaned != "" {
+		prefix = cleaned + "/"
+	}
+	for i := range entries {
+		entries[i].Path = strings.TrimPrefix(entries[i].Path, prefix)
+	}
+	err = Write(
+		format,
+		writer,
+		entries,
+		func(path string) (io.ReadCloser, error) {
+			return m.Get(store, objects, pathlib.Join(cleaned, path))
+		})
+	return
+}
+
+//
+// file returns a file entry.
+func (m Manifest) file(object *Object) (entry *Entry) {
+	entry = &Entry{
+		Name:     pathlib.Base(object.Path),
+		Path:     object.Path,
+		Size:     object.Size,
+		Mode:     object.Mode,
+		ModTime:  object.ModTime,
+		Checksum: object.Digest,
+	}
+	return
+}
+
+//
+// dir returns a directory entry.
+func (m Manifest) dir(path string) (entry *Entry) {
+	entry = &Entry{
+		Name:  pathlib.Base(path),
+		Path:  path,
+		Mode:  os.ModeDir | 0777,
+		IsDir: true,
+	}
+	if path == "" {
+		entry.Name = "/"
+	}
+	return
+}
+
+//
+// Diff lists the files added, removed and changed (content)
+// between snapshots.
+type Diff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+//
+// Compare the manifest (to) with the previous (from) manifest.
+func (m Manifest) Compare(from Manifest) (diff Diff) {
+	diff = Diff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+	for i := range m {
+		object := &m[i]
+		previous, found := from.Find(object.Path)
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, object.Path)
+		case previous.Digest != object.Digest:
+			diff.Changed = append(diff.Changed, object.Path)
+		}
+	}
+	for i := range from {
+		object := &from[i]
+		if _, found := m.Find(object.Path); !found {
+			diff.Removed = append(diff.Removed, object.Path)
+		}
+	}
+	return
+}
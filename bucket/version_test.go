@@ -0,0 +1,104 @@
+package bucket
+
+import (
+	"bytes"
+	"errors"
+	"github.com/onsi/gomega"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := &FSStore{Path: t.TempDir()}
+	root, _ := store.Create()
+	objects, _ := store.Create()
+	content := map[string]string{
+		"a.txt":       "AAA",
+		"d1/b.txt":    "BB",
+		"d1/d2/c.txt": "C",
+		"d1/d2/d.txt": "AAA",
+	}
+	for path, s := range content {
+		err := store.Put(root, path, strings.NewReader(s))
+		g.Expect(err).To(gomega.BeNil())
+	}
+	v1, err := Snapshot(store, root, objects, nil)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(v1)).To(gomega.Equal(4))
+	g.Expect(v1.Bytes()).To(gomega.Equal(int64(9)))
+	g.Expect(v1[0].Path).To(gomega.Equal("a.txt"))
+	g.Expect(v1[1].Path).To(gomega.Equal("d1/b.txt"))
+	//
+	// Content addressed (duplicates stored once).
+	stored, err := store.List(objects, "", ListOptions{})
+	g.Expect(err).To(gomega.BeNil())
+	files := 0
+	for _, entry := range stored {
+		if !entry.IsDir {
+			files++
+		}
+	}
+	g.Expect(files).To(gomega.Equal(3))
+	//
+	// Stat, list and get.
+	entry, err := v1.Stat("d1")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entry.IsDir).To(gomega.BeTrue())
+	entry, err = v1.Stat("/d1/b.txt")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entry.Size).To(gomega.Equal(int64(2)))
+	_, err = v1.Stat("missing")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(gomega.BeTrue())
+	entries, err := v1.List("", ListOptions{Depth: 1})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(entries)).To(gomega.Equal(2))
+	g.Expect(entries[0].Path).To(gomega.Equal("a.txt"))
+	g.Expect(entries[1].Path).To(gomega.Equal("d1"))
+	entries, err = v1.List("d1", ListOptions{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(entries)).To(gomega.Equal(4))
+	reader, err := v1.Get(store, objects, "d1/d2/d.txt")
+	g.Expect(err).To(gomega.BeNil())
+	b, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	g.Expect(string(b)).To(gomega.Equal("AAA"))
+	//
+	// Immutable.
+	err = store.Put(root, "a.txt", strings.NewReader("changed"))
+	g.Expect(err).To(gomega.BeNil())
+	err = store.Delete(root, "d1/d2")
+	g.Expect(err).To(gomega.BeNil())
+	err = store.Put(root, "e.txt", strings.NewReader("E"))
+	g.Expect(err).To(gomega.BeNil())
+	reader, err = v1.Get(store, objects, "a.txt")
+	g.Expect(err).To(gomega.BeNil())
+	b, _ = io.ReadAll(reader)
+	_ = reader.Close()
+	g.Expect(string(b)).To(gomega.Equal("AAA"))
+	//
+	// Archive.
+	archive := &bytes.Buffer{}
+	err = v1.Archive(store, objects, "d1", Tar, archive)
+	g.Expect(err).To(gomega.BeNil())
+	copied, _ := store.Create()
+	err = store.Extract(copied, "", Tar, archive, Limits{Bytes: 1024, Entries: 100})
+	g.Expect(err).To(gomega.BeNil())
+	_, err = store.Stat(copied, "d2/c.txt")
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Compare.
+	v2, err := Snapshot(store, root, objects, nil)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(v2.Digest()).ToNot(gomega.Equal(v1.Digest()))
+	diff := v2.Compare(v1)
+	g.Expect(diff.Added).To(gomega.Equal([]string{"e.txt"}))
+	g.Expect(diff.Removed).To(gomega.Equal([]string{"d1/d2/c.txt", "d1/d2/d.txt"}))
+	g.Expect(diff.Changed).To(gomega.Equal([]string{"a.txt"}))
+	v3, err := Snapshot(store, root, objects, nil)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(v3.Digest()).To(gomega.Equal(v2.Digest()))
+}
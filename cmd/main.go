@@ -2,106 +2,53 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/controller/pkg/logging"
 	"github.com/konveyor/tackle2-hub/api"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/database"
 	"github.com/konveyor/tackle2-hub/importer"
 	"github.com/konveyor/tackle2-hub/k8s"
 	crd "github.com/konveyor/tackle2-hub/k8s/api"
+	"github.com/konveyor/tackle2-hub/migration"
 	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/secret"
 	"github.com/konveyor/tackle2-hub/settings"
+	"github.com/konveyor/tackle2-hub/storage"
 	"github.com/konveyor/tackle2-hub/task"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/schema"
-	"io/ioutil"
 	"k8s.io/client-go/kubernetes/scheme"
-	"os"
-	"path"
-	"reflect"
-	"strings"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"syscall"
 )
 
-//
-// DB constants
-const (
-	ConnectionString = "file:%s?_foreign_keys=yes"
-)
-
 var Settings = &settings.Settings
 
 var log = logging.WithName("hub")
 
 func init() {
-	_ = Settings.Load()
-}
-
-//
-// Setup the DB and models.
-func Setup() (db *gorm.DB, err error) {
-	db, err = open()
-	if err != nil {
-		return
-	}
-	if seeded(db) {
-		log.Info("Database already seeded, skipping.")
-		return
-	}
-
-	var sqlDB *sql.DB
-	sqlDB, err = db.DB()
-	if err != nil {
-		return
-	}
-	_ = sqlDB.Close()
-	err = os.Remove(Settings.DB.Path)
-	if err != nil {
-		return
-	}
-	db, err = open()
-	if err != nil {
-		return
-	}
-	err = seed(db, model.All())
+	err := Settings.Load()
 	if err != nil {
-		return
+		panic(err)
 	}
-
-	return
 }
 
 //
-// Open and automigrate the DB.
-func open() (db *gorm.DB, err error) {
-	db, err = gorm.Open(
-		sqlite.Open(fmt.Sprintf(ConnectionString, Settings.DB.Path)),
-		&gorm.Config{
-			NamingStrategy: &schema.NamingStrategy{
-				SingularTable: true,
-				NoLowerCase:   true,
-			},
-		})
+// Setup the DB and models.
+// The schema is migrated. The hub refuses to start when the
+// schema is newer than supported.
+func Setup() (db *gorm.DB, err error) {
+	db, err = database.Open()
 	if err != nil {
 		return
 	}
-	err = db.AutoMigrate(append(model.All())...)
+	err = migration.Migrate(db)
 	if err != nil {
 		return
 	}
 	return
 }
 
-//
-// Check whether the DB has been seeded.
-func seeded(db *gorm.DB) (seeded bool) {
-	result := db.Find(&model.Setting{Key: ".hub.db.seeded"})
-	return result.RowsAffected > 0
-}
-
 //
 // buildScheme adds CRDs to the k8s scheme.
 func buildScheme() (err error) {
@@ -112,13 +59,17 @@ func buildScheme() (err error) {
 //
 // main.
 func main() {
-	log.Info("Started", "settings", Settings)
+	log.Info("Started", "settings", Settings.Redacted())
 	var err error
 	defer func() {
 		if err != nil {
 			log.Trace(err)
 		}
 	}()
+	err = Settings.Hub.Validate()
+	if err != nil {
+		panic(err)
+	}
 	syscall.Umask(0)
 	err = buildScheme()
 	if err != nil {
@@ -128,13 +79,21 @@ func main() {
 	if err != nil {
 		return
 	}
+	model.Stores = secretStores(client)
+	model.Store = model.Stores[Settings.Hub.Secret.Store]
+	model.BucketStore, err = bucketStore()
+	if err != nil {
+		panic(err)
+	}
 	db, err := Setup()
 	if err != nil {
 		panic(err)
 	}
 	router := gin.Default()
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(gin.CustomRecovery(api.Recovered))
+	router.Use(api.RequestIdentifier)
+	router.NoRoute(api.NoRoute)
 	for _, h := range api.All() {
 		h.With(db, client)
 		h.AddRoutes(router)
@@ -148,55 +107,55 @@ func main() {
 		DB: db,
 	}
 	importManager.Run(context.Background())
+	storageManager := storage.Manager{
+		DB: db,
+	}
+	storageManager.Run(context.Background())
 	err = router.Run()
 }
 
 //
-// Seed the database with the contents of json
-// files contained in DB_SEED_PATH.
-func seed(db *gorm.DB, models []interface{}) (err error) {
-	for _, m := range models {
-		err = func() (err error) {
-			kind := reflect.TypeOf(m).Name()
-			fileName := strings.ToLower(kind) + ".json"
-			filePath := path.Join(settings.Settings.DB.SeedPath, fileName)
-			file, err := os.Open(filePath)
-			if err != nil {
-				err = nil
-				return
-			}
-			defer file.Close()
-			jsonBytes, err := ioutil.ReadAll(file)
-			if err != nil {
-				return
-			}
+// secretStores builds the identity secret stores by kind.
+// All stores are built so secrets stored before the configured
+// store was changed may be read (and moved).
+func secretStores(client client.Client) (stores map[string]secret.Store) {
+	stores = map[string]secret.Store{
+		secret.DB: &secret.DBStore{
+			Keyring: model.Keyring(),
+		},
+		secret.File: &secret.FileStore{
+			Path:    Settings.Hub.Secret.Path,
+			Keyring: model.Keyring(),
+		},
+		secret.Kubernetes: &secret.KubernetesStore{
+			Client:    client,
+			Namespace: Settings.Hub.Namespace,
+		},
+	}
 
-			var unmarshalled []map[string]interface{}
-			err = json.Unmarshal(jsonBytes, &unmarshalled)
-			if err != nil {
-				return
-			}
-			for i := range unmarshalled {
-				result := db.Model(&m).Create(unmarshalled[i])
-				if result.Error != nil {
-					err = result.Error
-					return
-				}
-			}
-			return
-		}()
-		if err != nil {
-			return
+	return
+}
+
+//
+// bucketStore builds the configured bucket content store.
+func bucketStore() (store bucket.Store, err error) {
+	switch Settings.Hub.Bucket.Store {
+	case bucket.S3:
+		s3 := Settings.Hub.Bucket.S3
+		store, err = bucket.NewS3Store(
+			bucket.S3Options{
+				Endpoint:  s3.Endpoint,
+				Bucket:    s3.Bucket,
+				Region:    s3.Region,
+				AccessKey: s3.AccessKey,
+				SecretKey: s3.SecretKey,
+				TLS:       s3.TLS,
+			})
+	default:
+		store = &bucket.FSStore{
+			Path: Settings.Hub.Bucket.Path,
 		}
 	}
 
-	seeded, _ := json.Marshal(true)
-	setting := model.Setting{Key: ".hub.db.seeded", Value: seeded}
-	result := db.Create(&setting)
-	if result.Error != nil {
-		err = result.Error
-		return
-	}
-	log.Info("Database seeded.")
 	return
 }
@@ -0,0 +1,262 @@
+package credential
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"net"
+	"net/http"
+	liburl "net/url"
+	"strings"
+)
+
+//
+// Git smart protocol.
+const (
+	UploadPack        = "git-upload-pack"
+	AdvertisementMIME = "application/x-" + UploadPack + "-advertisement"
+)
+
+//
+// Git credentials tester.
+// Authentication is tested using the smart-HTTP
+// or SSH (upload-pack) handshake.
+type Git struct {
+	Credentials
+	// KnownHosts (file) path used to verify (SSH) host keys.
+	KnownHosts string
+}
+
+//
+// Test authentication with the repository.
+// Supported URLs:
+//
+//	http|https://host/path
+//	ssh://[user@]host[:port]/path
+//	[user@]host:path
+func (r *Git) Test(url string) (err error) {
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		err = r.http(url)
+		return
+	}
+	err = r.ssh(url)
+	return
+}
+
+//
+// http tests the smart-HTTP handshake.
+// The ref advertisement is requested using basic auth.
+func (r *Git) http(url string) (err error) {
+	if r.User == "" && r.Key != "" {
+		err = fmt.Errorf("%w: key (SSH) not supported for: %s", ErrScheme, url)
+		return
+	}
+	url = strings.TrimSuffix(url, "/") + "/info/refs?service=" + UploadPack
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	if r.User != "" {
+		request.SetBasicAuth(r.User, r.Password)
+	}
+	client := http.Client{Timeout: Timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode != http.StatusOK {
+		err = statusError(response.StatusCode)
+		return
+	}
+	mime := response.Header.Get("Content-Type")
+	if mime != AdvertisementMIME {
+		err = fmt.Errorf("credential: not a git (smart-HTTP) repository: %s", url)
+		return
+	}
+	return
+}
+
+//
+// ssh tests the SSH handshake.
+// The upload-pack is run to verify the repository is accessible.
+func (r *Git) ssh(url string) (err error) {
+	user, host, path, err := r.sshURL(url)
+	if err != nil {
+		return
+	}
+	auth, err := r.sshAuth()
+	if err != nil {
+		return
+	}
+	hostKey, err := r.hostKey()
+	if err != nil {
+		return
+	}
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+		Timeout:         Timeout,
+	}
+	client, err := ssh.Dial("tcp", host, config)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), ErrHostKey.Error()):
+			err = fmt.Errorf("%w: %s", ErrHostKey, err.Error())
+		case strings.Contains(err.Error(), "unable to authenticate"):
+			err = fmt.Errorf("%w: %s", ErrAuth, err.Error())
+		}
+		return
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+	stderr := &bytes.Buffer{}
+	session.Stderr = stderr
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return
+	}
+	err = session.Start(uploadPack(path))
+	if err != nil {
+		return
+	}
+	// The advertisement begins with a pkt-line length.
+	pktLen := make([]byte, 4)
+	_, err = io.ReadFull(stdout, pktLen)
+	if err != nil {
+		err = fmt.Errorf(
+			"%w: %s",
+			ErrNotFound,
+			strings.TrimSpace(stderr.String()))
+		return
+	}
+	return
+}
+
+//
+// uploadPack returns the (remote shell) upload-pack command.
+// The path is single quoted; embedded quotes are escaped
+// so the path cannot break out of the quoting.
+func uploadPack(path string) (command string) {
+	quoted := "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	command = UploadPack + " " + quoted
+	return
+}
+
+//
+// sshAuth returns the SSH auth methods.
+// The password is the passphrase when the key is protected.
+func (r *Git) sshAuth() (auth []ssh.AuthMethod, err error) {
+	if r.Key != "" {
+		var signer ssh.Signer
+		signer, err = ssh.ParsePrivateKey([]byte(r.Key))
+		if err != nil {
+			var missing *ssh.PassphraseMissingError
+			if !errors.As(err, &missing) {
+				return
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(
+				[]byte(r.Key),
+				[]byte(r.Password))
+			if err != nil {
+				return
+			}
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+		return
+	}
+	if r.Password != "" {
+		auth = append(auth, ssh.Password(r.Password))
+		return
+	}
+	err = errors.New("credential: (user and password) or key required")
+	return
+}
+
+//
+// hostKey returns the host key callback.
+// Host keys are verified using the known hosts (file). When not
+// configured, the host key is not verified and only key (SSH)
+// authentication is permitted; the password is never sent to
+// a host that has not been verified.
+func (r *Git) hostKey() (callback ssh.HostKeyCallback, err error) {
+	if r.KnownHosts != "" {
+		var verify ssh.HostKeyCallback
+		verify, err = knownhosts.New(r.KnownHosts)
+		if err != nil {
+			return
+		}
+		callback = func(host string, remote net.Addr, key ssh.PublicKey) (err error) {
+			err = verify(host, remote, key)
+			if err != nil {
+				err = fmt.Errorf("%w: %s", ErrHostKey, err.Error())
+			}
+			return
+		}
+		return
+	}
+	if r.Key == "" {
+		err = fmt.Errorf(
+			"%w: password authentication requires known hosts.",
+			ErrHostKey)
+		return
+	}
+	callback = ssh.InsecureIgnoreHostKey()
+	return
+}
+
+//
+// sshURL parses the SSH URL.
+// The user defaults to the identity user or `git`.
+func (r *Git) sshURL(url string) (user, host, path string, err error) {
+	if strings.HasPrefix(url, "ssh://") || strings.HasPrefix(url, "git+ssh://") {
+		var parsed *liburl.URL
+		parsed, err = liburl.Parse(url)
+		if err != nil {
+			return
+		}
+		user = parsed.User.Username()
+		host = parsed.Host
+		path = parsed.Path
+	} else {
+		if strings.Contains(url, "://") {
+			err = fmt.Errorf("%w: %s", ErrScheme, url)
+			return
+		}
+		part := strings.SplitN(url, ":", 2)
+		if len(part) != 2 {
+			err = fmt.Errorf("credential: URL not valid: %s", url)
+			return
+		}
+		host = part[0]
+		path = part[1]
+		if n := strings.LastIndex(host, "@"); n != -1 {
+			user = host[:n]
+			host = host[n+1:]
+		}
+	}
+	if user == "" {
+		user = r.User
+	}
+	if user == "" {
+		user = "git"
+	}
+	if _, _, splitErr := net.SplitHostPort(host); splitErr != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	return
+}
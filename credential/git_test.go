@@ -0,0 +1,262 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	pathlib "path"
+	"testing"
+)
+
+func TestGitHTTP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/repo.git/info/refs" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			user, password, _ := r.BasicAuth()
+			if user != "user" || password != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", AdvertisementMIME)
+			_, _ = w.Write([]byte("001e# service=git-upload-pack\n0000"))
+		}))
+	defer server.Close()
+	//
+	// Authenticated.
+	git := &Git{Credentials: Credentials{User: "user", Password: "token"}}
+	err := git.Test(server.URL + "/repo.git")
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Wrong password.
+	git.Password = "wrong"
+	err = git.Test(server.URL + "/repo.git")
+	g.Expect(errors.Is(err, ErrAuth)).To(gomega.BeTrue())
+	//
+	// Not found.
+	git.Password = "token"
+	err = git.Test(server.URL + "/other.git")
+	g.Expect(errors.Is(err, ErrNotFound)).To(gomega.BeTrue())
+	//
+	// Key not supported.
+	git = &Git{Credentials: Credentials{Key: "KEY"}}
+	err = git.Test(server.URL + "/repo.git")
+	g.Expect(errors.Is(err, ErrScheme)).To(gomega.BeTrue())
+}
+
+func TestGitSSH(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	key, authorized := sshKey(t)
+	other, _ := sshKey(t)
+	host, _ := sshServer(t, authorized)
+	//
+	// Authenticated.
+	git := &Git{Credentials: Credentials{Key: key}}
+	err := git.Test("ssh://git@" + host + "/repo.git")
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Wrong key.
+	git = &Git{Credentials: Credentials{Key: other}}
+	err = git.Test("ssh://git@" + host + "/repo.git")
+	g.Expect(errors.Is(err, ErrAuth)).To(gomega.BeTrue())
+	//
+	// Not found.
+	git = &Git{Credentials: Credentials{Key: key}}
+	err = git.Test("ssh://git@" + host + "/other.git")
+	g.Expect(errors.Is(err, ErrNotFound)).To(gomega.BeTrue())
+}
+
+func TestGitSSHHostKey(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, authorized := sshKey(t)
+	host, hostKey := sshServer(t, authorized)
+	_, otherKey := sshKey(t)
+	knownHosts := func(key ssh.PublicKey) (path string) {
+		path = pathlib.Join(t.TempDir(), "known_hosts")
+		line := knownhosts.Line([]string{knownhosts.Normalize(host)}, key)
+		err := os.WriteFile(path, []byte(line+"\n"), 0600)
+		g.Expect(err).To(gomega.BeNil())
+		return
+	}
+	url := "ssh://git@" + host + "/repo.git"
+	//
+	// Password (host key not verified).
+	git := &Git{Credentials: Credentials{Password: "secret"}}
+	err := git.Test(url)
+	g.Expect(errors.Is(err, ErrHostKey)).To(gomega.BeTrue())
+	//
+	// Password (host key verified).
+	git.KnownHosts = knownHosts(hostKey)
+	err = git.Test(url)
+	g.Expect(err).To(gomega.BeNil())
+	git.Password = "wrong"
+	err = git.Test(url)
+	g.Expect(errors.Is(err, ErrAuth)).To(gomega.BeTrue())
+	//
+	// Host key mismatch.
+	git = &Git{
+		Credentials: Credentials{Password: "secret"},
+		KnownHosts:  knownHosts(otherKey),
+	}
+	err = git.Test(url)
+	g.Expect(errors.Is(err, ErrHostKey)).To(gomega.BeTrue())
+	//
+	// Host unknown.
+	git.KnownHosts = pathlib.Join(t.TempDir(), "empty")
+	err = os.WriteFile(git.KnownHosts, []byte{}, 0600)
+	g.Expect(err).To(gomega.BeNil())
+	err = git.Test(url)
+	g.Expect(errors.Is(err, ErrHostKey)).To(gomega.BeTrue())
+}
+
+func TestGitSSHURL(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	git := &Git{Credentials: Credentials{User: "elmer"}}
+	cases := []struct {
+		url  string
+		user string
+		host string
+		path string
+	}{
+		{"ssh://git@github.com/org/repo.git", "git", "github.com:22", "/org/repo.git"},
+		{"ssh://github.com:2222/org/repo.git", "elmer", "github.com:2222", "/org/repo.git"},
+		{"git@github.com:org/repo.git", "git", "github.com:22", "org/repo.git"},
+		{"github.com:org/repo.git", "elmer", "github.com:22", "org/repo.git"},
+	}
+	for _, c := range cases {
+		user, host, path, err := git.sshURL(c.url)
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(user).To(gomega.Equal(c.user))
+		g.Expect(host).To(gomega.Equal(c.host))
+		g.Expect(path).To(gomega.Equal(c.path))
+	}
+	_, _, _, err := git.sshURL("ftp://github.com/org/repo.git")
+	g.Expect(errors.Is(err, ErrScheme)).To(gomega.BeTrue())
+}
+
+func TestGitSSHCommand(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(uploadPack("/org/repo.git")).To(
+		gomega.Equal(UploadPack + " '/org/repo.git'"))
+	g.Expect(uploadPack("/repo'; touch /tmp/x; '.git")).To(
+		gomega.Equal(UploadPack + ` '/repo'\''; touch /tmp/x; '\''.git'`))
+}
+
+//
+// sshKey returns a (PEM encoded) private key and the public key.
+func sshKey(t *testing.T) (key string, public ssh.PublicKey) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key = string(
+		pem.EncodeToMemory(
+			&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(private),
+			}))
+	public, err = ssh.NewPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+//
+// sshServer starts an SSH server that serves the upload-pack
+// for /repo.git to the authorized key (or password: secret).
+// Returns the host:port and the host key.
+func sshServer(t *testing.T, authorized ssh.PublicKey) (host string, hostKey ssh.PublicKey) {
+	private, hostKey := sshKey(t)
+	signer, err := ssh.ParsePrivateKey([]byte(private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (p *ssh.Permissions, err error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				err = errors.New("not authorized")
+			}
+			return
+		},
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (p *ssh.Permissions, err error) {
+			if string(password) != "secret" {
+				err = errors.New("not authorized")
+			}
+			return
+		},
+	}
+	config.AddHostKey(signer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sshServe(conn, config)
+		}
+	}()
+	host = listener.Addr().String()
+	return
+}
+
+//
+// sshServe serves an SSH connection.
+func sshServe(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer channel.Close()
+			for request := range requests {
+				if request.Type != "exec" {
+					_ = request.Reply(false, nil)
+					continue
+				}
+				_ = request.Reply(true, nil)
+				command := string(request.Payload[4:])
+				status := make([]byte, 4)
+				if command == UploadPack+" '/repo.git'" {
+					_, _ = channel.Write([]byte("0000"))
+				} else {
+					_, _ = channel.Stderr().Write([]byte("repository not found."))
+					binary.BigEndian.PutUint32(status, 128)
+				}
+				_, _ = channel.SendRequest("exit-status", false, status)
+				return
+			}
+		}()
+	}
+}
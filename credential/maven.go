@@ -0,0 +1,154 @@
+package credential
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//
+// Maven credentials tester.
+// Authentication is tested using an (authenticated)
+// HEAD request against the repository.
+type Maven struct {
+	Credentials
+}
+
+//
+// Test authentication with the repository.
+// The credentials are the server (in the settings) associated
+// with a repository or mirror with a matching URL. The user
+// and password are used when no server is matched.
+func (r *Maven) Test(url string) (err error) {
+	settings, err := r.settings()
+	if err != nil {
+		return
+	}
+	user, password := r.User, r.Password
+	server, found := settings.Server(url)
+	if found {
+		user, password = server.User, server.Password
+	}
+	request, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return
+	}
+	if user != "" {
+		request.SetBasicAuth(user, password)
+	}
+	client := http.Client{Timeout: Timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return
+	}
+	_ = response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusUnauthorized,
+		http.StatusForbidden,
+		http.StatusProxyAuthRequired:
+		err = statusError(response.StatusCode)
+	default:
+		// Authenticated. The repository root may not be browsable.
+		if response.StatusCode >= http.StatusInternalServerError {
+			err = statusError(response.StatusCode)
+		}
+	}
+	return
+}
+
+//
+// Targets returns the URLs of repositories and mirrors
+// with server credentials.
+func (r *Maven) Targets() (urls []string, err error) {
+	settings, err := r.settings()
+	if err != nil {
+		return
+	}
+	for _, repository := range settings.Repositories() {
+		if _, found := settings.server(repository.ID); found {
+			urls = append(urls, repository.URL)
+		}
+	}
+	return
+}
+
+//
+// settings parses the settings.
+func (r *Maven) settings() (settings *MavenSettings, err error) {
+	settings = &MavenSettings{}
+	if r.Settings == "" {
+		return
+	}
+	err = xml.Unmarshal([]byte(r.Settings), settings)
+	if err != nil {
+		err = fmt.Errorf("credential: settings not valid: %w", err)
+		return
+	}
+	return
+}
+
+//
+// MavenSettings maven settings.xml (subset).
+type MavenSettings struct {
+	XMLName  xml.Name          `xml:"settings"`
+	Servers  []MavenServer     `xml:"servers>server"`
+	Mirrors  []MavenRepository `xml:"mirrors>mirror"`
+	Profiles []struct {
+		Repositories []MavenRepository `xml:"repositories>repository"`
+	} `xml:"profiles>profile"`
+}
+
+//
+// MavenRepository maven repository or mirror.
+type MavenRepository struct {
+	ID  string `xml:"id"`
+	URL string `xml:"url"`
+}
+
+//
+// MavenServer maven server credentials.
+type MavenServer struct {
+	ID       string `xml:"id"`
+	User     string `xml:"username"`
+	Password string `xml:"password"`
+}
+
+//
+// Repositories returns the mirrors and repositories.
+func (r *MavenSettings) Repositories() (list []MavenRepository) {
+	list = append(list, r.Mirrors...)
+	for _, profile := range r.Profiles {
+		list = append(list, profile.Repositories...)
+	}
+	return
+}
+
+//
+// Server returns the server credentials for the URL.
+func (r *MavenSettings) Server(url string) (server MavenServer, found bool) {
+	url = strings.TrimSuffix(url, "/")
+	for _, repository := range r.Repositories() {
+		if !strings.HasPrefix(url, strings.TrimSuffix(repository.URL, "/")) {
+			continue
+		}
+		server, found = r.server(repository.ID)
+		if found {
+			return
+		}
+	}
+	return
+}
+
+//
+// server returns the server credentials by ID.
+func (r *MavenSettings) server(id string) (server MavenServer, found bool) {
+	for _, server = range r.Servers {
+		if server.ID == id {
+			found = true
+			return
+		}
+	}
+	server = MavenServer{}
+	return
+}
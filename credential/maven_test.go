@@ -0,0 +1,66 @@
+package credential
+
+import (
+	"errors"
+	"github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaven(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g.Expect(r.Method).To(gomega.Equal(http.MethodHead))
+			user, password, _ := r.BasicAuth()
+			if user != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	defer server.Close()
+	settings := `
+<settings>
+  <servers>
+    <server>
+      <id>central</id>
+      <username>user</username>
+      <password>PASSWORD</password>
+    </server>
+  </servers>
+  <mirrors>
+    <mirror>
+      <id>central</id>
+      <url>URL/maven2</url>
+    </mirror>
+  </mirrors>
+</settings>`
+	settings = strings.Replace(settings, "URL", server.URL, 1)
+	//
+	// Authenticated.
+	maven := &Maven{
+		Credentials{
+			Settings: strings.Replace(settings, "PASSWORD", "secret", 1),
+		},
+	}
+	targets, err := maven.Targets()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(targets).To(gomega.Equal([]string{server.URL + "/maven2"}))
+	err = maven.Test(targets[0])
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Wrong password.
+	maven.Settings = strings.Replace(settings, "PASSWORD", "wrong", 1)
+	err = maven.Test(targets[0])
+	g.Expect(errors.Is(err, ErrAuth)).To(gomega.BeTrue())
+	//
+	// User and password when server not matched.
+	maven.User = "user"
+	maven.Password = "secret"
+	err = maven.Test(server.URL + "/other")
+	g.Expect(err).To(gomega.BeNil())
+}
@@ -0,0 +1,56 @@
+package credential
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//
+// Errors.
+var (
+	ErrAuth     = errors.New("credential: authentication failed")
+	ErrNotFound = errors.New("credential: target not found")
+	ErrScheme   = errors.New("credential: URL scheme not supported")
+	ErrHostKey  = errors.New("credential: host key not verified")
+)
+
+//
+// Timeout for connections and requests.
+var Timeout = 30 * time.Second
+
+//
+// Credentials to be tested.
+type Credentials struct {
+	User     string
+	Password string
+	Key      string
+	Settings string
+}
+
+//
+// Tester tests credentials against a target.
+type Tester interface {
+	// Test authentication with the target URL.
+	Test(url string) (err error)
+}
+
+//
+// statusError returns the error for an HTTP response status.
+//
+//	401|403|407 = ErrAuth.
+//	404 = ErrNotFound.
+func statusError(status int) (err error) {
+	switch status {
+	case http.StatusUnauthorized,
+		http.StatusForbidden,
+		http.StatusProxyAuthRequired:
+		err = fmt.Errorf("%w: HTTP %d", ErrAuth, status)
+	case http.StatusNotFound:
+		err = fmt.Errorf("%w: HTTP %d", ErrNotFound, status)
+	default:
+		err = fmt.Errorf("credential: HTTP %d", status)
+	}
+	return
+}
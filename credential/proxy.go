@@ -0,0 +1,60 @@
+package credential
+
+import (
+	"net"
+	"net/http"
+	liburl "net/url"
+	"strconv"
+	"strings"
+)
+
+//
+// Proxy credentials tester.
+// Authentication is tested using a (HEAD) request
+// sent through the proxy.
+type Proxy struct {
+	Credentials
+	// Kind (http|https).
+	Kind string
+	// Host proxy host.
+	Host string
+	// Port proxy port.
+	Port int
+}
+
+//
+// Test authentication with the proxy.
+// The URL is requested through the proxy.
+func (r *Proxy) Test(url string) (err error) {
+	proxy := &liburl.URL{
+		Scheme: r.Kind,
+		Host:   net.JoinHostPort(r.Host, strconv.Itoa(r.Port)),
+	}
+	if r.User != "" {
+		proxy.User = liburl.UserPassword(r.User, r.Password)
+	}
+	request, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return
+	}
+	client := http.Client{
+		Timeout: Timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(proxy),
+		},
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		// CONNECT (tunnel) failures are reported as errors.
+		if strings.Contains(err.Error(), http.StatusText(http.StatusProxyAuthRequired)) {
+			err = statusError(http.StatusProxyAuthRequired)
+		}
+		return
+	}
+	_ = response.Body.Close()
+	if response.StatusCode == http.StatusProxyAuthRequired {
+		err = statusError(response.StatusCode)
+		return
+	}
+	return
+}
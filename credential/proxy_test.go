@@ -0,0 +1,48 @@
+package credential
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/onsi/gomega"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Proxy-Authorization") != expected {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	//
+	// Authenticated.
+	proxy := &Proxy{
+		Credentials: Credentials{User: "user", Password: "secret"},
+		Kind:        "http",
+		Host:        host,
+		Port:        n,
+	}
+	err := proxy.Test("http://example.com")
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Wrong password.
+	proxy.Password = "wrong"
+	err = proxy.Test("http://example.com")
+	g.Expect(errors.Is(err, ErrAuth)).To(gomega.BeTrue())
+	//
+	// Wrong password (tunnel).
+	err = proxy.Test("https://example.com")
+	g.Expect(errors.Is(err, ErrAuth)).To(gomega.BeTrue())
+}
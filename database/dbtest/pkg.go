@@ -0,0 +1,58 @@
+package dbtest
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+//
+// Marker the (required) test database name marker.
+const Marker = "test"
+
+//
+// Reset (empty) the test DB.
+// PostgreSQL: all tables (in the current schema) are dropped. Fails
+// unless the database name contains the (test) marker. SQLite: the
+// test DB (file) is expected to be (temporary) and is not changed.
+func Reset(db *gorm.DB) (err error) {
+	if db.Dialector.Name() != "postgres" {
+		return
+	}
+	var name string
+	err = db.Raw("SELECT current_database()").Scan(&name).Error
+	if err != nil {
+		return
+	}
+	if !strings.Contains(strings.ToLower(name), Marker) {
+		err = fmt.Errorf(
+			"database: '%s' not a test database; name must contain: '%s'.",
+			name,
+			Marker)
+		return
+	}
+	var tables []string
+	err = db.Raw(
+		"SELECT tablename FROM pg_tables WHERE schemaname = current_schema()").
+		Scan(&tables).Error
+	if err != nil {
+		return
+	}
+	for _, table := range tables {
+		err = db.Exec("DROP TABLE IF EXISTS ? CASCADE", clause.Table{Name: table}).Error
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// Close the DB.
+func Close(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		_ = sqlDB.Close()
+	}
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"fmt"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/settings"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+//
+// Drivers.
+const (
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
+//
+// DB constants
+const (
+	ConnectionString = "file:%s?_foreign_keys=yes"
+)
+
+var Settings = &settings.Settings
+
+//
+// Open the DB.
+// The DB is opened using the (configured) driver. The schema
+// is migrated (separately) by versioned migrations.
+func Open() (db *gorm.DB, err error) {
+	dialector, err := Dialector()
+	if err != nil {
+		return
+	}
+	db, err = gorm.Open(
+		dialector,
+		&gorm.Config{
+			NamingStrategy: &schema.NamingStrategy{
+				SingularTable: true,
+				NoLowerCase:   true,
+			},
+		})
+	if err != nil {
+		return
+	}
+	err = model.Register(db)
+	if err != nil {
+		return
+	}
+	return
+}
+
+//
+// Dialector returns the dialector for the (configured) driver.
+func Dialector() (dialector gorm.Dialector, err error) {
+	switch Settings.DB.Driver {
+	case SQLite, "":
+		dialector = sqlite.Open(
+			fmt.Sprintf(ConnectionString, Settings.DB.Path))
+	case Postgres:
+		dialector = postgres.Open(Settings.DB.DSN)
+	default:
+		err = fmt.Errorf("db driver: '%s' not supported.", Settings.DB.Driver)
+	}
+	return
+}
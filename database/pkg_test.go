@@ -0,0 +1,354 @@
+package database
+
+import (
+	"errors"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/database/dbtest"
+	"github.com/konveyor/tackle2-hub/migration"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/secret"
+	"github.com/onsi/gomega"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestConstraint(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	tagType := &model.TagType{Name: "A"}
+	err := db.Create(tagType).Error
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Unique.
+	err = db.Create(&model.TagType{Name: "A"}).Error
+	g.Expect(errors.Is(err, model.ErrUnique)).To(gomega.BeTrue())
+	ce := model.Classify(err)
+	g.Expect(ce.Table).To(gomega.Equal("TagType"))
+	g.Expect(ce.Fields).To(gomega.Equal([]string{"Name"}))
+	//
+	// Foreign key (not found).
+	err = db.Create(&model.Tag{Name: "B", TagTypeID: 99}).Error
+	g.Expect(errors.Is(err, model.ErrForeignKey)).To(gomega.BeTrue())
+	ce = model.Classify(err)
+	g.Expect(ce.Table).To(gomega.Equal("Tag"))
+	g.Expect(ce.Fields).To(gomega.Equal([]string{"TagTypeID"}))
+	//
+	// Foreign key (referenced).
+	err = db.Create(&model.Tag{Name: "B", TagTypeID: tagType.ID}).Error
+	g.Expect(err).To(gomega.BeNil())
+	err = db.Delete(tagType).Error
+	g.Expect(errors.Is(err, model.ErrForeignKey)).To(gomega.BeTrue())
+	ce = model.Classify(err)
+	g.Expect(ce.Table).To(gomega.Equal("TagType"))
+	g.Expect(ce.Fields).To(gomega.BeEmpty())
+}
+
+func TestCreate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	//
+	// ID (auto-increment) and defaults set.
+	m := &model.TagType{Name: "A"}
+	err := db.Create(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(m.ID).ToNot(gomega.BeZero())
+	g.Expect(m.Revision).To(gomega.Equal(uint(1)))
+	//
+	// IDs (batch) set in order.
+	list := []model.TagType{{Name: "B"}, {Name: "C"}, {Name: "D"}}
+	err = db.Create(&list).Error
+	g.Expect(err).To(gomega.BeNil())
+	for i := range list {
+		found := &model.TagType{}
+		err = db.First(found, list[i].ID).Error
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(found.Name).To(gomega.Equal(list[i].Name))
+		g.Expect(found.Revision).To(gomega.Equal(uint(1)))
+		g.Expect(list[i].ID).To(gomega.Equal(m.ID + uint(i) + 1))
+	}
+	//
+	// Returning (requested).
+	returned := &model.TagType{Name: "E"}
+	err = db.Clauses(clause.Returning{}).Create(returned).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(returned.ID).To(gomega.Equal(list[2].ID + 1))
+	g.Expect(returned.Revision).To(gomega.Equal(uint(1)))
+}
+
+func TestRevision(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	m := &model.TagType{Name: "A"}
+	err := db.Create(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(m.Revision).To(gomega.Equal(uint(1)))
+	revision := func() uint {
+		found := &model.TagType{}
+		err := db.First(found, m.ID).Error
+		g.Expect(err).To(gomega.BeNil())
+		return found.Revision
+	}
+	//
+	// Updated (by the user).
+	m.Name = "B"
+	err = db.Save(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(revision()).To(gomega.Equal(uint(2)))
+	err = db.Model(m).Update("Rank", 4).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(revision()).To(gomega.Equal(uint(3)))
+	//
+	// Bookkeeping.
+	bookkeeping := model.Bookkeeping(db)
+	for i := 0; i < 3; i++ {
+		m.Name = "C"
+		err = bookkeeping.Save(m).Error
+		g.Expect(err).To(gomega.BeNil())
+	}
+	err = bookkeeping.Model(m).Update("Rank", 5).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(revision()).To(gomega.Equal(uint(3)))
+	found := &model.TagType{}
+	err = db.First(found, m.ID).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(found.Name).To(gomega.Equal("C"))
+	g.Expect(found.Rank).To(gomega.Equal(uint(5)))
+}
+
+func TestBucketDelete(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	store := &bucket.FSStore{Path: t.TempDir()}
+	model.BucketStore = store
+	t.Cleanup(func() {
+		model.BucketStore = nil
+	})
+	root, err := store.Create()
+	g.Expect(err).To(gomega.BeNil())
+	m := &model.Bucket{Name: "B", Path: root}
+	err = db.Create(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Conditional (stale revision) not matched.
+	result := db.Where("Revision", m.Revision+1).Delete(m)
+	g.Expect(result.Error).To(gomega.BeNil())
+	g.Expect(result.RowsAffected).To(gomega.BeZero())
+	_, err = os.Stat(root)
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Matched.
+	result = db.Where("Revision", m.Revision).Delete(m)
+	g.Expect(result.Error).To(gomega.BeNil())
+	g.Expect(result.RowsAffected).To(gomega.Equal(int64(1)))
+	_, err = os.Stat(root)
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+}
+
+func TestQuery(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	tagType := &model.TagType{Name: "T"}
+	err := db.Create(tagType).Error
+	g.Expect(err).To(gomega.BeNil())
+	for _, name := range []string{"Alpha", "beta", "Gamma_1", "Gamma%2"} {
+		err = db.Create(&model.Tag{Name: name, TagTypeID: tagType.ID}).Error
+		g.Expect(err).To(gomega.BeNil())
+	}
+	names := func(db *gorm.DB) (names []string) {
+		err := db.Model(&model.Tag{}).Pluck("Name", &names).Error
+		g.Expect(err).To(gomega.BeNil())
+		return
+	}
+	//
+	// Quoted (mixed case) column.
+	g.Expect(names(db.Where("Name", "beta"))).To(gomega.Equal([]string{"beta"}))
+	//
+	// Case insensitive (escaped) LIKE.
+	like := "LOWER(?) LIKE LOWER(?) ESCAPE '\\'"
+	g.Expect(names(db.Where(like, clause.Column{Name: "Name"}, "ALPHA"))).To(
+		gomega.Equal([]string{"Alpha"}))
+	g.Expect(names(db.Where(like, clause.Column{Name: "Name"}, "gamma\\_%"))).To(
+		gomega.Equal([]string{"Gamma_1"}))
+	//
+	// Keyset (ordered).
+	tx := db.Where(clause.Gt{Column: "ID", Value: 2})
+	tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: "ID"}, Desc: true})
+	g.Expect(names(tx)).To(gomega.Equal([]string{"Gamma%2", "Gamma_1"}))
+	//
+	// Aggregate and increment.
+	bucket := &model.Bucket{Name: "B", Bytes: 10, Files: 1}
+	err = db.Create(bucket).Error
+	g.Expect(err).To(gomega.BeNil())
+	err = db.Model(bucket).Updates(
+		map[string]interface{}{
+			"Bytes": gorm.Expr("? + ?", clause.Column{Name: "Bytes"}, 5),
+			"Files": gorm.Expr("? + ?", clause.Column{Name: "Files"}, 1),
+		}).Error
+	g.Expect(err).To(gomega.BeNil())
+	var bytes int64
+	err = db.Model(&model.Bucket{}).
+		Select("COALESCE(SUM(?), 0)", clause.Column{Name: "Bytes"}).
+		Scan(&bytes).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(bytes).To(gomega.Equal(int64(15)))
+	found := &model.Bucket{}
+	err = db.First(found, bucket.ID).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(found.Files).To(gomega.Equal(int64(2)))
+}
+
+func TestIdentitySecret(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	keyring := model.Keyring()
+	files := &secret.FileStore{Path: t.TempDir(), Keyring: keyring}
+	dbStore := &secret.DBStore{Keyring: keyring}
+	model.Stores = map[string]secret.Store{
+		secret.DB:   dbStore,
+		secret.File: files,
+	}
+	model.Store = files
+	t.Cleanup(func() {
+		model.Store = nil
+		model.Stores = map[string]secret.Store{}
+	})
+	stored := func() (names []string) {
+		entries, err := os.ReadDir(files.Path)
+		g.Expect(err).To(gomega.BeNil())
+		for _, ent := range entries {
+			names = append(names, ent.Name())
+		}
+		return
+	}
+	decrypted := func(id uint) (m *model.Identity) {
+		m = &model.Identity{}
+		err := db.First(m, id).Error
+		g.Expect(err).To(gomega.BeNil())
+		err = m.Decrypt(model.Store)
+		g.Expect(err).To(gomega.BeNil())
+		return
+	}
+	//
+	// Stored (file).
+	identity := &model.Identity{Kind: "git", Name: "A", User: "admin", Password: "secret"}
+	err := db.Create(identity).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(identity.Secret).To(gomega.HavePrefix(secret.File + ":"))
+	g.Expect(identity.Encrypted).To(gomega.BeEmpty())
+	g.Expect(stored()).To(gomega.HaveLen(1))
+	//
+	// Indicated (persisted) without the secret.
+	listed := &model.Identity{}
+	err = db.First(listed, identity.ID).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(listed.HasPassword).To(gomega.BeTrue())
+	g.Expect(listed.HasKey).To(gomega.BeFalse())
+	g.Expect(listed.HasSettings).To(gomega.BeFalse())
+	//
+	// Not orphaned when the create fails.
+	duplicate := &model.Identity{Kind: "git", Name: "B", Password: "secret"}
+	duplicate.ID = identity.ID
+	err = db.Create(duplicate).Error
+	g.Expect(err).ToNot(gomega.BeNil())
+	g.Expect(stored()).To(gomega.HaveLen(1))
+	//
+	// Not orphaned when the transaction is rolled back.
+	rollback := errors.New("rollback")
+	err = model.Transaction(db, func(tx *gorm.DB) (err error) {
+		err = tx.Create(&model.Identity{Kind: "git", Name: "C", Password: "secret"}).Error
+		if err != nil {
+			return
+		}
+		err = rollback
+		return
+	})
+	g.Expect(err).To(gomega.Equal(rollback))
+	g.Expect(stored()).To(gomega.HaveLen(1))
+	//
+	// Not deleted when the delete is not matched (stale revision)
+	// or rolled back.
+	m := decrypted(identity.ID)
+	err = db.Where("Revision", m.Revision+1).Delete(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(stored()).To(gomega.HaveLen(1))
+	err = model.Transaction(db, func(tx *gorm.DB) (err error) {
+		err = tx.Delete(m).Error
+		if err != nil {
+			return
+		}
+		err = rollback
+		return
+	})
+	g.Expect(err).To(gomega.Equal(rollback))
+	g.Expect(stored()).To(gomega.HaveLen(1))
+	//
+	// Not moved when rolled back.
+	model.Store = dbStore
+	err = model.Transaction(db, func(tx *gorm.DB) (err error) {
+		m := &model.Identity{}
+		err = tx.First(m, identity.ID).Error
+		if err != nil {
+			return
+		}
+		err = tx.Save(m).Error
+		if err != nil {
+			return
+		}
+		err = rollback
+		return
+	})
+	g.Expect(err).To(gomega.Equal(rollback))
+	g.Expect(stored()).To(gomega.HaveLen(1))
+	//
+	// Store switched (db).
+	m = decrypted(identity.ID)
+	g.Expect(m.Password).To(gomega.Equal("secret"))
+	m = &model.Identity{}
+	err = db.First(m, identity.ID).Error
+	g.Expect(err).To(gomega.BeNil())
+	m.Description = "moved"
+	err = db.Save(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(m.Secret).To(gomega.BeEmpty())
+	g.Expect(m.Encrypted).ToNot(gomega.BeEmpty())
+	g.Expect(stored()).To(gomega.BeEmpty())
+	m = decrypted(identity.ID)
+	g.Expect(m.User).To(gomega.Equal("admin"))
+	g.Expect(m.Password).To(gomega.Equal("secret"))
+	//
+	// Store (kubernetes) not configured.
+	err = db.Model(m).Update("Secret", "kubernetes:identity-a").Error
+	g.Expect(err).To(gomega.BeNil())
+	m = &model.Identity{}
+	err = db.First(m, identity.ID).Error
+	g.Expect(err).To(gomega.BeNil())
+	err = m.Decrypt(model.Store)
+	g.Expect(errors.Is(err, secret.ErrStore)).To(gomega.BeTrue())
+}
+
+//
+// testDB opens (empties) and migrates the DB.
+// The driver is selected by the DB_DRIVER (and DB_DSN)
+// environment variables. SQLite (temporary file) by default.
+func testDB(t *testing.T, g *gomega.WithT) (db *gorm.DB) {
+	err := Settings.Hub.Load()
+	g.Expect(err).To(gomega.BeNil())
+	if Settings.DB.Driver == SQLite {
+		Settings.DB.Path = path.Join(t.TempDir(), "test.db")
+	}
+	Settings.DB.SeedPath = t.TempDir()
+	db, err = Open()
+	g.Expect(err).To(gomega.BeNil())
+	err = dbtest.Reset(db)
+	g.Expect(err).To(gomega.BeNil())
+	err = migration.Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	t.Cleanup(func() {
+		_ = dbtest.Reset(db)
+		dbtest.Close(db)
+	})
+	return
+}
@@ -5,50 +5,188 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"golang.org/x/crypto/scrypt"
 	"io"
+	"strings"
+	"sync"
 )
 
+//
+// Ciphertext versions.
+// The version prefix is omitted for (legacy) v1 ciphertext.
+const (
+	// V1 AES/CFB; key zero-padded passphrase.
+	V1 = "v1"
+	// V2 AES/GCM; key derived using scrypt.
+	V2 = "v2"
+	// V3 AES/GCM; key derived using scrypt; key ID.
+	V3 = "v3"
+)
+
+//
+// Keys.
+const (
+	DefaultKeyID = "1"
+)
+
+//
+// Key derivation.
+const (
+	KeyLen  = 32
+	SaltLen = 16
+	// scrypt parameters.
+	ScryptN = 1 << 15
+	ScryptR = 8
+	ScryptP = 1
+)
+
+//
+// Errors.
+var (
+	ErrVersion   = errors.New("encryption: version not supported")
+	ErrMalformed = errors.New("encryption: ciphertext malformed")
+	ErrKeyID     = errors.New("encryption: key ID not matched")
+)
+
+//
+// cache of encryptors keyed by key ID and passphrase.
+var cache = struct {
+	sync.Mutex
+	content map[string]*AES
+}{
+	content: make(map[string]*AES),
+}
+
 //
 // AES encryption.
+// Ciphertext (v3) format: "v3:" + ID + ":" + base64(salt|nonce|sealed).
+// The salt used to derive the key is stored in the ciphertext.
+// The key ID is authenticated as additional data.
 type AES struct {
-	// Key Length must be (12|24|32).
-	Key []byte
+	// ID key identifier.
+	ID string
+	// passphrase used to derive keys.
+	passphrase string
+	// salt used to encrypt.
+	salt []byte
+	// derived keys keyed by salt.
+	keys map[string][]byte
+	// mutex protects keys.
+	mutex sync.Mutex
 }
 
 //
 // Encrypt plain string.
-// Returns an AES encrypted; base64 encoded string.
+// Returns a versioned; key identified, AES/GCM encrypted; base64 encoded string.
 func (r *AES) Encrypt(plain string) (encrypted string, err error) {
 	if plain == "" {
 		encrypted = plain
 		return
 	}
-	block, err := aes.NewCipher(r.Key)
+	key, err := r.key(r.salt)
 	if err != nil {
 		return
 	}
-	input := make([]byte, aes.BlockSize+len(plain))
-	iv := input[:aes.BlockSize]
-	_, err = io.ReadFull(rand.Reader, iv)
+	gcm, err := r.gcm(key)
 	if err != nil {
 		return
 	}
-	cfb := cipher.NewCFBEncrypter(block, iv)
-	cfb.XORKeyStream(input[aes.BlockSize:], []byte(plain))
-	encrypted = r.encode(input)
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return
+	}
+	output := append([]byte{}, r.salt...)
+	output = append(output, nonce...)
+	output = gcm.Seal(output, nonce, []byte(plain), []byte(r.ID))
+	encrypted = V3 + ":" + r.ID + ":" + r.encode(output)
 	return
 }
 
 //
-// Decrypt and AES encrypted string.
-// The `encrypted` string is an AES encrypted; base64 encoded string.
+// Decrypt an AES encrypted string.
+// The `encrypted` string is a (versioned) AES encrypted; base64 encoded string.
+// Legacy (v1) ciphertext is decrypted using AES/CFB.
 // Returns the decoded string.
 func (r *AES) Decrypt(encrypted string) (plain string, err error) {
 	if encrypted == "" {
 		plain = encrypted
 		return
 	}
-	block, err := aes.NewCipher(r.Key)
+	version, id, encrypted := Parse(encrypted)
+	switch version {
+	case V1:
+		plain, err = r.decryptV1(encrypted)
+	case V2:
+		plain, err = r.decryptV2(encrypted, nil)
+	case V3:
+		if id != r.ID {
+			err = ErrKeyID
+			return
+		}
+		plain, err = r.decryptV2(encrypted, []byte(id))
+	default:
+		err = ErrVersion
+	}
+	return
+}
+
+//
+// With Sets the passphrase and generates the salt.
+func (r *AES) With(passphrase string) {
+	r.passphrase = passphrase
+	r.keys = make(map[string][]byte)
+	r.salt = make([]byte, SaltLen)
+	_, err := io.ReadFull(rand.Reader, r.salt)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//
+// decryptV2 decrypts AES/GCM ciphertext.
+func (r *AES) decryptV2(encrypted string, data []byte) (plain string, err error) {
+	input, err := r.decode(encrypted)
+	if err != nil {
+		return
+	}
+	if len(input) < SaltLen {
+		err = ErrMalformed
+		return
+	}
+	salt := input[:SaltLen]
+	input = input[SaltLen:]
+	key, err := r.key(salt)
+	if err != nil {
+		return
+	}
+	gcm, err := r.gcm(key)
+	if err != nil {
+		return
+	}
+	if len(input) < gcm.NonceSize() {
+		err = ErrMalformed
+		return
+	}
+	nonce := input[:gcm.NonceSize()]
+	input = input[gcm.NonceSize():]
+	b, err := gcm.Open(nil, nonce, input, data)
+	if err != nil {
+		return
+	}
+	plain = string(b)
+	return
+}
+
+//
+// decryptV1 decrypts legacy AES/CFB ciphertext.
+// Only the first 32 bytes of the passphrase are used
+// as the (zero-padded) key.
+func (r *AES) decryptV1(encrypted string) (plain string, err error) {
+	key := make([]byte, KeyLen)
+	copy(key, r.passphrase)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return
 	}
@@ -68,19 +206,37 @@ func (r *AES) Decrypt(encrypted string) (plain string, err error) {
 }
 
 //
-// With Sets the key using the passphrase.
-// Only the first 32 bytes of the passphrase are used.
-func (r *AES) With(passphrase string) {
-	keyLen := 32
-	r.Key = make([]byte, keyLen)
-	input := []byte(passphrase)
-	for n := range input {
-		if n < keyLen {
-			r.Key[n] = input[n]
-		} else {
-			break
-		}
+// key returns the key derived from the passphrase and salt.
+func (r *AES) key(salt []byte) (key []byte, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	key, found := r.keys[string(salt)]
+	if found {
+		return
+	}
+	key, err = scrypt.Key(
+		[]byte(r.passphrase),
+		salt,
+		ScryptN,
+		ScryptR,
+		ScryptP,
+		KeyLen)
+	if err != nil {
+		return
 	}
+	r.keys[string(salt)] = key
+	return
+}
+
+//
+// gcm returns the AES/GCM cipher for the key.
+func (r *AES) gcm(key []byte) (gcm cipher.AEAD, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+	gcm, err = cipher.NewGCM(block)
+	return
 }
 
 //
@@ -97,10 +253,51 @@ func (r *AES) decode(in string) (out []byte, err error) {
 	return
 }
 
+//
+// Parse (versioned) ciphertext.
+// Returns the version, key ID and the encoded payload.
+func Parse(encrypted string) (version, id, payload string) {
+	version = V1
+	payload = encrypted
+	part := strings.SplitN(encrypted, ":", 2)
+	if len(part) < 2 {
+		return
+	}
+	version = part[0]
+	payload = part[1]
+	if version != V3 {
+		return
+	}
+	part = strings.SplitN(payload, ":", 2)
+	if len(part) < 2 {
+		payload = ""
+		return
+	}
+	id = part[0]
+	payload = part[1]
+	return
+}
+
 //
 // New AES encryptor for passphrase.
 func New(passphrase string) (n *AES) {
-	n = &AES{}
+	n = NewWithID(DefaultKeyID, passphrase)
+	return
+}
+
+//
+// NewWithID AES encryptor for a key ID and passphrase.
+// Encryptors are cached to limit the (expensive) key derivation.
+func NewWithID(id, passphrase string) (n *AES) {
+	cache.Lock()
+	defer cache.Unlock()
+	key := id + ":" + passphrase
+	n, found := cache.content[key]
+	if found {
+		return
+	}
+	n = &AES{ID: id}
 	n.With(passphrase)
+	cache.content[key] = n
 	return
 }
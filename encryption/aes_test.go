@@ -1,7 +1,11 @@
 package encryption
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
 	"github.com/onsi/gomega"
+	"strings"
 	"testing"
 )
 
@@ -15,9 +19,63 @@ func TestAES(t *testing.T) {
 	encrypted, err := aes.Encrypt(plain)
 	g.Expect(err).To(gomega.BeNil())
 	g.Expect(len(encrypted)).ToNot(gomega.Equal(0))
+	g.Expect(strings.HasPrefix(encrypted, V3+":"+DefaultKeyID+":")).To(gomega.BeTrue())
 	//
 	// Decrypt.
 	decrypted, err := aes.Decrypt(encrypted)
 	g.Expect(err).To(gomega.BeNil())
 	g.Expect(plain).To(gomega.Equal(decrypted))
+	//
+	// Decrypt (new encryptor).
+	other := &AES{ID: DefaultKeyID}
+	other.With("MyPassphrase")
+	decrypted, err = other.Decrypt(encrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(plain).To(gomega.Equal(decrypted))
+	//
+	// Wrong passphrase.
+	_, err = New("Other").Decrypt(encrypted)
+	g.Expect(err).ToNot(gomega.BeNil())
+	//
+	// Tampered.
+	_, id, payload := Parse(encrypted)
+	b, _ := base64.StdEncoding.DecodeString(payload)
+	b[len(b)-1] ^= 0xff
+	_, err = aes.Decrypt(V3 + ":" + id + ":" + base64.StdEncoding.EncodeToString(b))
+	g.Expect(err).ToNot(gomega.BeNil())
+	//
+	// Key ID (authenticated) altered.
+	_, err = other.Decrypt(V3 + ":2:" + payload)
+	g.Expect(err).To(gomega.Equal(ErrKeyID))
+	other.ID = "2"
+	_, err = other.Decrypt(V3 + ":2:" + payload)
+	g.Expect(err).ToNot(gomega.BeNil())
+	//
+	// Version not supported.
+	_, err = aes.Decrypt("v9:" + payload)
+	g.Expect(err).To(gomega.Equal(ErrVersion))
+}
+
+func TestAESLegacy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	plain := "ABCDEFGHIJKLMNOPQUSTUVQXYZ"
+	encrypted := legacyEncrypt("MyPassphrase", plain)
+	decrypted, err := New("MyPassphrase").Decrypt(encrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(plain).To(gomega.Equal(decrypted))
+}
+
+//
+// legacyEncrypt AES/CFB encrypt as done by v1.
+func legacyEncrypt(passphrase, plain string) (encrypted string) {
+	key := make([]byte, KeyLen)
+	copy(key, passphrase)
+	block, _ := aes.NewCipher(key)
+	output := make([]byte, aes.BlockSize+len(plain))
+	iv := output[:aes.BlockSize]
+	cfb := cipher.NewCFBEncrypter(block, iv)
+	cfb.XORKeyStream(output[aes.BlockSize:], []byte(plain))
+	encrypted = base64.StdEncoding.EncodeToString(output)
+	return
 }
@@ -0,0 +1,124 @@
+package encryption
+
+import (
+	"errors"
+	"sort"
+)
+
+//
+// Errors.
+var (
+	ErrKeyNotFound = errors.New("encryption: key not found")
+)
+
+//
+// Keyring of AES encryptors keyed by key ID.
+// Encryption is performed using the current key.
+// Decryption is performed using the key identified in
+// the ciphertext so that values encrypted using previous
+// keys may be decrypted (and re-encrypted) after rotation.
+type Keyring struct {
+	// Current key ID.
+	Current string
+	// Legacy key ID used to decrypt legacy (v1) ciphertext
+	// which does not identify the key. Defaults to Current.
+	Legacy string
+	// keys by ID.
+	keys map[string]*AES
+}
+
+//
+// Encrypt plain string using the current key.
+func (r *Keyring) Encrypt(plain string) (encrypted string, err error) {
+	key, found := r.keys[r.Current]
+	if !found {
+		err = ErrKeyNotFound
+		return
+	}
+	encrypted, err = key.Encrypt(plain)
+	return
+}
+
+//
+// Decrypt an encrypted string.
+// Ciphertext without a key ID (v2) is decrypted by trying each key
+// because it is authenticated. Legacy (v1) ciphertext is not
+// authenticated and is decrypted using the legacy key.
+func (r *Keyring) Decrypt(encrypted string) (plain string, err error) {
+	version, id, _ := Parse(encrypted)
+	switch version {
+	case V3:
+		key, found := r.keys[id]
+		if !found {
+			err = ErrKeyNotFound
+			return
+		}
+		plain, err = key.Decrypt(encrypted)
+	case V2:
+		err = ErrKeyNotFound
+		for _, id := range r.ids() {
+			key, found := r.keys[id]
+			if !found {
+				continue
+			}
+			plain, err = key.Decrypt(encrypted)
+			if err == nil {
+				break
+			}
+		}
+	default:
+		id := r.Legacy
+		if id == "" {
+			id = r.Current
+		}
+		key, found := r.keys[id]
+		if !found {
+			err = ErrKeyNotFound
+			return
+		}
+		plain, err = key.Decrypt(encrypted)
+	}
+	return
+}
+
+//
+// IsCurrent returns true when the (encrypted) string
+// has been encrypted using the current key.
+func (r *Keyring) IsCurrent(encrypted string) (current bool) {
+	if encrypted == "" {
+		current = true
+		return
+	}
+	version, id, _ := Parse(encrypted)
+	current = version == V3 && id == r.Current
+	return
+}
+
+//
+// ids returns the key IDs; current first.
+func (r *Keyring) ids() (ids []string) {
+	ids = []string{r.Current}
+	others := []string{}
+	for id := range r.keys {
+		if id != r.Current {
+			others = append(others, id)
+		}
+	}
+	sort.Strings(others)
+	ids = append(ids, others...)
+	return
+}
+
+//
+// NewKeyring returns a keyring.
+// The passphrases are keyed by key ID.
+func NewKeyring(current string, passphrases map[string]string) (n *Keyring) {
+	n = &Keyring{
+		Current: current,
+		keys:    make(map[string]*AES),
+	}
+	for id, passphrase := range passphrases {
+		n.keys[id] = NewWithID(id, passphrase)
+	}
+	return
+}
@@ -0,0 +1,78 @@
+package encryption
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	plain := "ABCDEFGHIJKLMNOPQUSTUVQXYZ"
+	//
+	// Encrypted using key: 1.
+	keyring := NewKeyring("1", map[string]string{"1": "Passphrase1"})
+	encrypted, err := keyring.Encrypt(plain)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(keyring.IsCurrent(encrypted)).To(gomega.BeTrue())
+	//
+	// Rotated to key: 2.
+	keyring = NewKeyring(
+		"2",
+		map[string]string{
+			"1": "Passphrase1",
+			"2": "Passphrase2",
+		})
+	g.Expect(keyring.IsCurrent(encrypted)).To(gomega.BeFalse())
+	decrypted, err := keyring.Decrypt(encrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(decrypted).To(gomega.Equal(plain))
+	reencrypted, err := keyring.Encrypt(decrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(keyring.IsCurrent(reencrypted)).To(gomega.BeTrue())
+	//
+	// Previous key removed.
+	keyring = NewKeyring("2", map[string]string{"2": "Passphrase2"})
+	_, err = keyring.Decrypt(encrypted)
+	g.Expect(err).To(gomega.Equal(ErrKeyNotFound))
+	decrypted, err = keyring.Decrypt(reencrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(decrypted).To(gomega.Equal(plain))
+	//
+	// Legacy.
+	decrypted, err = keyring.Decrypt(legacyEncrypt("Passphrase2", plain))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(decrypted).To(gomega.Equal(plain))
+}
+
+func TestKeyringLegacy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	plain := `{"user":"admin","password":"secret"}`
+	encrypted := legacyEncrypt("tackle", plain)
+	//
+	// Rotated to key: 2.
+	keyring := NewKeyring(
+		"2",
+		map[string]string{
+			"1": "tackle",
+			"2": "newpass",
+		})
+	keyring.Legacy = "1"
+	g.Expect(keyring.IsCurrent(encrypted)).To(gomega.BeFalse())
+	decrypted, err := keyring.Decrypt(encrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(decrypted).To(gomega.Equal(plain))
+	reencrypted, err := keyring.Encrypt(decrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(keyring.IsCurrent(reencrypted)).To(gomega.BeTrue())
+	//
+	// Legacy key removed.
+	keyring = NewKeyring("2", map[string]string{"2": "newpass"})
+	keyring.Legacy = "1"
+	_, err = keyring.Decrypt(encrypted)
+	g.Expect(err).To(gomega.Equal(ErrKeyNotFound))
+	decrypted, err = keyring.Decrypt(reencrypted)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(decrypted).To(gomega.Equal(plain))
+}
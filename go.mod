@@ -4,13 +4,17 @@ go 1.16
 
 require (
 	github.com/gin-gonic/gin v1.7.4
+	github.com/go-playground/validator/v10 v10.4.1
 	github.com/google/uuid v1.1.2
+	github.com/jackc/pgconn v1.10.1
 	github.com/konveyor/controller v0.8.0
 	github.com/mattn/go-sqlite3 v1.14.9
+	github.com/minio/minio-go/v7 v7.0.23
 	github.com/onsi/gomega v1.7.0
 	github.com/swaggo/swag v1.7.8
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 	gorm.io/datatypes v1.0.5
-	gorm.io/driver/postgres v1.2.3 // indirect
+	gorm.io/driver/postgres v1.2.3
 	gorm.io/driver/sqlite v1.2.4
 	gorm.io/driver/sqlserver v1.2.1 // indirect
 	gorm.io/gorm v1.22.4
@@ -10,8 +10,6 @@ curl -X POST ${host}/identities -d \
     "description": "Forklift",
     "user": "userA",
     "password": "passwordA",
-    "key": "keyA",
-    "settings": "settingsA",
     "application": 1
 }' | jq -M .
 
@@ -21,8 +19,5 @@ curl -X POST ${host}/application-inventory/application/1/identities -d \
     "kind": "mvn",
     "name":"jeff-mvn",
     "description": "Forklift",
-    "user": "userA",
-    "password": "passwordA",
-    "key": "keyA",
-    "settings": "settingsA"
+    "settings": "<settings><servers><server><id>A</id><username>userA</username><password>passwordA</password></server></servers></settings>"
 }' | jq -M .
@@ -77,32 +77,24 @@ func ensureBucket(d *Data, paths []string) (err error) {
 	}
 	//
 	// Write files.
+	names := []string{}
 	for _, p := range paths {
-		var b []byte
-		//
-		// Read file.
-		b, err = os.ReadFile(p)
-		if err != nil {
-			if errors.Is(err, os.ErrPermission) {
-				continue
-			}
-			return
-		}
 		//
 		// Task update: The current addon activity.
-		target := pathlib.Join(
-			bucket.Path,
-			pathlib.Base(p))
 		addon.Activity("writing: %s", p)
 		//
 		// Write file.
-		err = os.WriteFile(
-			target,
-			b,
-			0644)
+		err = addon.Bucket.PutContent(
+			bucket.ID,
+			p,
+			pathlib.Base(p))
 		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				continue
+			}
 			return
 		}
+		names = append(names, pathlib.Base(p))
 		time.Sleep(time.Second)
 		//
 		// Task update: Increment the number of completed
@@ -111,7 +103,7 @@ func ensureBucket(d *Data, paths []string) (err error) {
 	}
 	//
 	// Build the index.
-	err = buildIndex(bucket)
+	err = buildIndex(bucket, names)
 	if err != nil {
 		return
 	}
@@ -123,32 +115,31 @@ func ensureBucket(d *Data, paths []string) (err error) {
 
 //
 // Build index.html
-func buildIndex(bucket *api.Bucket) (err error) {
+func buildIndex(bucket *api.Bucket, names []string) (err error) {
 	addon.Activity("Building index.")
 	time.Sleep(time.Second)
-	dir := bucket.Path
-	path := pathlib.Join(dir, "index.html")
-	f, err := os.Create(path)
+	f, err := os.CreateTemp("", "index-*.html")
 	if err != nil {
 		return
 	}
 	defer func() {
 		_ = f.Close()
+		_ = os.Remove(f.Name())
 	}()
 	body := []string{"<ul>"}
-	list, err := os.ReadDir(dir)
-	if err != nil {
-		return
-	}
-	for _, name := range list {
+	for _, name := range names {
 		body = append(
 			body,
-			"<li><a href=\""+name.Name()+"\">"+name.Name()+"</a>")
+			"<li><a href=\""+name+"\">"+name+"</a>")
 	}
 
 	body = append(body, "</ul>")
 
-	_, _ = f.WriteString(strings.Join(body, "\n"))
+	_, err = f.WriteString(strings.Join(body, "\n"))
+	if err != nil {
+		return
+	}
+	err = addon.Bucket.PutContent(bucket.ID, f.Name(), "index.html")
 
 	return
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/konveyor/tackle2-hub/api"
 	"github.com/konveyor/tackle2-hub/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strings"
 	"time"
 )
@@ -39,7 +40,7 @@ func (m *Manager) Run(ctx context.Context) {
 func (m *Manager) processImports() (err error) {
 	list := []model.Import{}
 	db := m.DB.Preload("ImportTags")
-	result := db.Find(&list, "processed = ?", false)
+	result := db.Find(&list, "Processed", false)
 	if result.Error != nil {
 		err = result.Error
 		return
@@ -54,7 +55,7 @@ func (m *Manager) processImports() (err error) {
 		}
 		imp.IsValid = ok
 		imp.Processed = true
-		result = m.DB.Save(&imp)
+		result = model.Bookkeeping(m.DB).Save(&imp)
 		if result.Error != nil {
 			err = result.Error
 			return
@@ -68,14 +69,14 @@ func (m *Manager) processImports() (err error) {
 // a dependency import record.
 func (m *Manager) createDependency(imp *model.Import) (ok bool) {
 	app := &model.Application{}
-	result := m.DB.Select("id").Where("name LIKE ?", imp.ApplicationName).First(app)
+	result := m.DB.Select("ID").Where("LOWER(?) LIKE LOWER(?)", clause.Column{Name: "Name"}, imp.ApplicationName).First(app)
 	if result.Error != nil {
 		imp.ErrorMessage = fmt.Sprintf("Application '%s' could not be found.", imp.ApplicationName)
 		return
 	}
 
 	dep := &model.Application{}
-	result = m.DB.Select("id").Where("name LIKE ?", imp.Dependency).First(dep)
+	result = m.DB.Select("ID").Where("LOWER(?) LIKE LOWER(?)", clause.Column{Name: "Name"}, imp.Dependency).First(dep)
 	if result.Error != nil {
 		imp.ErrorMessage = fmt.Sprintf("Application dependency '%s' could not be found.", imp.Dependency)
 		return
@@ -107,7 +108,7 @@ func (m *Manager) createDependency(imp *model.Import) (ok bool) {
 func (m *Manager) createApplication(imp *model.Import) (ok bool) {
 	app := &model.Application{}
 	businessService := &model.BusinessService{}
-	result := m.DB.Select("id").Where("name LIKE ?", imp.BusinessService).First(businessService)
+	result := m.DB.Select("ID").Where("LOWER(?) LIKE LOWER(?)", clause.Column{Name: "Name"}, imp.BusinessService).First(businessService)
 	if result.Error != nil {
 		imp.ErrorMessage = fmt.Sprintf("BusinessService '%s' could not be found.", imp.BusinessService)
 		return
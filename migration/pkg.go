@@ -0,0 +1,195 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"github.com/konveyor/controller/pkg/logging"
+	"github.com/konveyor/tackle2-hub/migration/v1"
+	"github.com/konveyor/tackle2-hub/migration/v3"
+	"github.com/konveyor/tackle2-hub/settings"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	Settings = &settings.Settings
+	log      = logging.WithName("migration")
+)
+
+//
+// LockKey the (postgres) advisory lock key.
+const LockKey = 0x7461636b6c65
+
+//
+// ErrNewer reports the DB schema is newer than supported.
+var ErrNewer = errors.New("schema newer than supported")
+
+//
+// Migration a versioned schema migration.
+type Migration struct {
+	// Version (ordinal) of the schema.
+	Version uint
+	// Name (description).
+	Name string
+	// Up applies the migration.
+	Up func(db *gorm.DB) (err error)
+}
+
+//
+// SchemaVersion records the applied migrations.
+type SchemaVersion struct {
+	Version uint `gorm:"primaryKey;autoIncrement:false"`
+	Name    string
+	Applied time.Time
+}
+
+//
+// All returns all migrations (ordered by version).
+// Migrations are appended and must not be changed once released.
+func All() []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "Create (baseline) schema.",
+			Up:      v1.Up,
+		},
+		{
+			Version: 2,
+			Name:    "Seed.",
+			Up:      v2,
+		},
+		{
+			Version: 3,
+			Name:    "Add revisions, buckets, application identities and secrets.",
+			Up:      v3.Up,
+		},
+		{
+			Version: 4,
+			Name:    "Migrate application identities.",
+			Up:      v4,
+		},
+		{
+			Version: 5,
+			Name:    "Add identity credential indicators.",
+			Up:      v5,
+		},
+	}
+}
+
+//
+// Migrate applies all (pending) migrations.
+func Migrate(db *gorm.DB) (err error) {
+	err = Apply(db, All())
+	return
+}
+
+//
+// Apply the (pending) migrations.
+// The migrations are applied in order (by version) in a single
+// transaction under a lock. Each applied migration is recorded in
+// the SchemaVersion table. Fails with ErrNewer when the schema
+// version is newer than the latest migration.
+func Apply(db *gorm.DB, migrations []Migration) (err error) {
+	err = validate(migrations)
+	if err != nil {
+		return
+	}
+	err = db.AutoMigrate(&SchemaVersion{})
+	if err != nil {
+		if !db.Migrator().HasTable(&SchemaVersion{}) {
+			return
+		}
+		// Created concurrently.
+		err = nil
+	}
+	err = db.Transaction(func(tx *gorm.DB) (err error) {
+		err = lock(tx)
+		if err != nil {
+			return
+		}
+		current, err := Version(tx)
+		if err != nil {
+			return
+		}
+		latest := uint(0)
+		if len(migrations) > 0 {
+			latest = migrations[len(migrations)-1].Version
+		}
+		if current > latest {
+			err = fmt.Errorf(
+				"%w: schema version: %d, latest migration: %d.",
+				ErrNewer,
+				current,
+				latest)
+			return
+		}
+		for _, m := range migrations {
+			if m.Version <= current {
+				continue
+			}
+			log.Info(
+				"Migrating.",
+				"version",
+				m.Version,
+				"name",
+				m.Name)
+			err = m.Up(tx)
+			if err != nil {
+				err = fmt.Errorf("migration %d: %w", m.Version, err)
+				return
+			}
+			applied := &SchemaVersion{
+				Version: m.Version,
+				Name:    m.Name,
+				Applied: time.Now(),
+			}
+			err = tx.Create(applied).Error
+			if err != nil {
+				return
+			}
+		}
+		log.Info("Schema migrated.", "version", latest)
+		return
+	})
+	return
+}
+
+//
+// Version returns the (current) schema version.
+// Returns 0 when no migrations have been applied.
+func Version(db *gorm.DB) (version uint, err error) {
+	err = db.Model(&SchemaVersion{}).
+		Select("COALESCE(MAX(?), 0)", clause.Column{Name: "Version"}).
+		Scan(&version).Error
+	return
+}
+
+//
+// lock the schema (for the transaction).
+// PostgreSQL: an advisory lock is acquired. Otherwise: the
+// (database) write lock is acquired by a (no-op) update.
+func lock(tx *gorm.DB) (err error) {
+	if tx.Dialector.Name() == "postgres" {
+		err = tx.Exec("SELECT pg_advisory_xact_lock(?)", LockKey).Error
+		return
+	}
+	err = tx.Model(&SchemaVersion{}).
+		Where("1 = 0").
+		Update("Name", "").Error
+	return
+}
+
+//
+// validate the migrations are ordered by version.
+func validate(migrations []Migration) (err error) {
+	last := uint(0)
+	for _, m := range migrations {
+		if m.Version <= last {
+			err = fmt.Errorf("migration %d: not ordered by version.", m.Version)
+			return
+		}
+		last = m.Version
+	}
+	return
+}
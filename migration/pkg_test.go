@@ -0,0 +1,311 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/konveyor/tackle2-hub/database"
+	"github.com/konveyor/tackle2-hub/database/dbtest"
+	"github.com/konveyor/tackle2-hub/migration/v1"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/secret"
+	"github.com/onsi/gomega"
+	"gorm.io/gorm"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	seeded := []map[string]interface{}{
+		{"ID": 10, "Name": "A"},
+		{"ID": 11, "Name": "B"},
+	}
+	b, _ := json.Marshal(seeded)
+	err := os.WriteFile(path.Join(Settings.DB.SeedPath, "tagtype.json"), b, 0644)
+	g.Expect(err).To(gomega.BeNil())
+	//
+	// Applied.
+	err = Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	version, err := Version(db)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(version).To(gomega.Equal(uint(5)))
+	var applied []SchemaVersion
+	err = db.Order("Version").Find(&applied).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(applied)).To(gomega.Equal(5))
+	g.Expect(applied[1].Name).To(gomega.Equal("Seed."))
+	//
+	// Seeded (and resequenced).
+	tagType := &model.TagType{Name: "C"}
+	err = db.Create(tagType).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(tagType.ID).To(gomega.Equal(uint(12)))
+	//
+	// Not applied again.
+	err = Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	var count int64
+	err = db.Model(&model.TagType{}).Count(&count).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(count).To(gomega.Equal(int64(3)))
+}
+
+func TestAdopt(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	//
+	// Created (and seeded) before versioned migrations.
+	err := db.AutoMigrate(v1.All()...)
+	g.Expect(err).To(gomega.BeNil())
+	err = db.Create(&v1.TagType{Name: "A"}).Error
+	g.Expect(err).To(gomega.BeNil())
+	err = db.Create(&v1.Setting{Key: Seeded, Value: []byte("true")}).Error
+	g.Expect(err).To(gomega.BeNil())
+	err = os.WriteFile(
+		path.Join(Settings.DB.SeedPath, "tagtype.json"),
+		[]byte(`[{"Name": "B"}]`),
+		0644)
+	g.Expect(err).To(gomega.BeNil())
+	err = Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	version, err := Version(db)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(version).To(gomega.Equal(uint(5)))
+	var names []string
+	err = db.Model(&model.TagType{}).Pluck("Name", &names).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(names).To(gomega.Equal([]string{"A"}))
+	//
+	// Migrated to the (current) schema.
+	tagType := &model.TagType{}
+	err = db.First(tagType, "Name", "A").Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(tagType.Revision).To(gomega.Equal(uint(1)))
+	for _, m := range model.All() {
+		g.Expect(db.Migrator().HasTable(m)).To(gomega.BeTrue())
+	}
+	g.Expect(db.Migrator().HasColumn(&model.Identity{}, "Secret")).To(gomega.BeTrue())
+	g.Expect(db.Migrator().HasColumn(&model.Task{}, "Token")).To(gomega.BeTrue())
+	g.Expect(db.Migrator().HasColumn(&model.Bucket{}, "Objects")).To(gomega.BeTrue())
+	g.Expect(db.Migrator().HasColumn(&model.Identity{}, "ApplicationID")).To(gomega.BeFalse())
+	g.Expect(db.Migrator().HasTable(AppIdentity)).To(gomega.BeFalse())
+	identity := &model.Identity{Kind: "git", Name: "A", User: "elmer"}
+	err = db.Create(identity).Error
+	g.Expect(err).To(gomega.BeNil())
+	err = db.Create(
+		&model.Bucket{
+			Name:  "A",
+			Quota: 10,
+		}).Error
+	g.Expect(err).To(gomega.BeNil())
+}
+
+func TestNewer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	migrations := append(
+		All(),
+		Migration{
+			Version: 6,
+			Name:    "Next.",
+			Up: func(db *gorm.DB) (err error) {
+				return
+			},
+		})
+	err := Apply(db, migrations)
+	g.Expect(err).To(gomega.BeNil())
+	err = Migrate(db)
+	g.Expect(errors.Is(err, ErrNewer)).To(gomega.BeTrue())
+	version, err := Version(db)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(version).To(gomega.Equal(uint(6)))
+}
+
+func TestFailed(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	err := Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	failed := errors.New("failed")
+	migrations := append(
+		All(),
+		Migration{
+			Version: 6,
+			Name:    "Created.",
+			Up: func(db *gorm.DB) (err error) {
+				err = db.Migrator().CreateTable(&Widget{})
+				return
+			},
+		},
+		Migration{
+			Version: 7,
+			Name:    "Failed.",
+			Up: func(db *gorm.DB) (err error) {
+				err = failed
+				return
+			},
+		})
+	//
+	// Rolled back.
+	err = Apply(db, migrations)
+	g.Expect(errors.Is(err, failed)).To(gomega.BeTrue())
+	version, err := Version(db)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(version).To(gomega.Equal(uint(5)))
+	g.Expect(db.Migrator().HasTable(&Widget{})).To(gomega.BeFalse())
+	//
+	// Not ordered.
+	migrations[5], migrations[6] = migrations[6], migrations[5]
+	err = Apply(db, migrations)
+	g.Expect(err).ToNot(gomega.BeNil())
+	g.Expect(db.Migrator().HasTable(&Widget{})).To(gomega.BeFalse())
+}
+
+func TestLock(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	other, err := database.Open()
+	g.Expect(err).To(gomega.BeNil())
+	done := make(chan error)
+	for _, handle := range []*gorm.DB{db, other} {
+		go func(db *gorm.DB) {
+			done <- Migrate(db)
+		}(handle)
+	}
+	for i := 0; i < 2; i++ {
+		err = <-done
+		g.Expect(err).To(gomega.BeNil())
+	}
+	var count int64
+	err = db.Model(&SchemaVersion{}).Count(&count).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(count).To(gomega.Equal(int64(len(All()))))
+}
+
+func TestApplicationIdentity(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	//
+	// Baseline.
+	err := Apply(db, All()[:2])
+	g.Expect(err).To(gomega.BeNil())
+	service := &v1.BusinessService{Name: "A"}
+	err = db.Create(service).Error
+	g.Expect(err).To(gomega.BeNil())
+	for _, name := range []string{"A", "B", "C"} {
+		err = db.Create(
+			&v1.Application{
+				Name:              name,
+				BusinessServiceID: service.ID,
+			}).Error
+		g.Expect(err).To(gomega.BeNil())
+	}
+	for _, m := range []v1.Identity{
+		{Model: v1.Model{ID: 1}, Kind: "git", Name: "A", ApplicationID: 1},
+		{Model: v1.Model{ID: 2}, Kind: "mvn", Name: "B", ApplicationID: 2},
+		{Model: v1.Model{ID: 3}, Kind: "proxy", Name: "C"},
+		{Model: v1.Model{ID: 4}, Kind: "git", Name: "D", ApplicationID: 99},
+	} {
+		err = db.Create(&m).Error
+		g.Expect(err).To(gomega.BeNil())
+	}
+	for _, m := range []AppIdentityJoin{
+		{ApplicationID: 1, IdentityID: 1},
+		{ApplicationID: 3, IdentityID: 2},
+		{ApplicationID: 3, IdentityID: 3},
+	} {
+		err = db.Create(&m).Error
+		g.Expect(err).To(gomega.BeNil())
+	}
+	//
+	// Migrated.
+	err = Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	var links []model.ApplicationIdentity
+	err = db.Order("ApplicationID").Order("IdentityID").Find(&links).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(links).To(gomega.Equal([]model.ApplicationIdentity{
+		{ApplicationID: 1, IdentityID: 1, Role: "source"},
+		{ApplicationID: 2, IdentityID: 2, Role: "maven"},
+		{ApplicationID: 3, IdentityID: 2, Role: "maven"},
+		{ApplicationID: 3, IdentityID: 3, Role: "proxy"},
+	}))
+	g.Expect(db.Migrator().HasTable(AppIdentity)).To(gomega.BeFalse())
+	g.Expect(db.Migrator().HasColumn(&model.Identity{}, "ApplicationID")).To(gomega.BeFalse())
+	identity := &model.Identity{}
+	err = db.First(identity, 4).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(identity.Name).To(gomega.Equal("D"))
+}
+
+func TestIdentityIndicators(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := testDB(t, g)
+	err := Apply(db, All()[:4])
+	g.Expect(err).To(gomega.BeNil())
+	store := model.SecretStore()
+	encrypted, err := store.Put("", &secret.Secret{User: "u", Password: "p"})
+	g.Expect(err).To(gomega.BeNil())
+	for _, m := range []map[string]interface{}{
+		{"ID": 1, "Kind": "git", "Name": "A", "Encrypted": encrypted},
+		{"ID": 2, "Kind": "git", "Name": "B"},
+	} {
+		err = db.Table("Identity").Create(m).Error
+		g.Expect(err).To(gomega.BeNil())
+	}
+	//
+	// Migrated.
+	err = Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	var list []model.Identity
+	err = db.Order("ID").Find(&list).Error
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(list)).To(gomega.Equal(2))
+	g.Expect(list[0].HasPassword).To(gomega.BeTrue())
+	g.Expect(list[0].HasKey).To(gomega.BeFalse())
+	g.Expect(list[0].HasSettings).To(gomega.BeFalse())
+	g.Expect(list[1].HasPassword).To(gomega.BeFalse())
+}
+
+//
+// Widget test model.
+type Widget struct {
+	ID   uint
+	Name string
+}
+
+//
+// AppIdentityJoin the (baseline) application identity join.
+type AppIdentityJoin struct {
+	ApplicationID uint
+	IdentityID    uint
+}
+
+func (AppIdentityJoin) TableName() string {
+	return AppIdentity
+}
+
+//
+// testDB opens (and empties) the DB.
+// The driver is selected by the DB_DRIVER (and DB_DSN)
+// environment variables. SQLite (temporary file) by default.
+func testDB(t *testing.T, g *gomega.WithT) (db *gorm.DB) {
+	err := Settings.Hub.Load()
+	g.Expect(err).To(gomega.BeNil())
+	if Settings.DB.Driver == database.SQLite {
+		Settings.DB.Path = path.Join(t.TempDir(), "test.db")
+	}
+	Settings.DB.SeedPath = t.TempDir()
+	db, err = database.Open()
+	g.Expect(err).To(gomega.BeNil())
+	err = dbtest.Reset(db)
+	g.Expect(err).To(gomega.BeNil())
+	t.Cleanup(func() {
+		_ = dbtest.Reset(db)
+		dbtest.Close(db)
+	})
+	return
+}
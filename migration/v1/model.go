@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+//
+// The (frozen) models defining the version 1 (baseline) schema.
+// The schema of DBs created (auto-migrated) before versioned
+// migrations. Must not be changed. Schema changes are made by
+// subsequent migrations.
+
+//
+// JSON field type.
+type JSON = datatypes.JSON
+
+//
+// Model Base model.
+type Model struct {
+	ID         uint `gorm:"primaryKey"`
+	CreateUser string
+	UpdateUser string
+	CreateTime time.Time `gorm:"autoCreateTime"`
+}
+
+type Setting struct {
+	ID    uint   `gorm:"primaryKey"`
+	Key   string `gorm:"uniqueIndex"`
+	Value JSON
+}
+
+type Application struct {
+	Model
+	Name              string `gorm:"index;unique;not null"`
+	Description       string
+	Review            *Review
+	Repository        JSON
+	Comments          string
+	Tags              []Tag      `gorm:"many2many:applicationTags"`
+	Identities        []Identity `gorm:"many2many:appIdentity"`
+	BusinessServiceID uint       `gorm:"index"`
+	BusinessService   *BusinessService
+}
+
+type Dependency struct {
+	Model
+	ToID   uint         `gorm:"index"`
+	To     *Application `gorm:"foreignKey:ToID;constraint:OnDelete:CASCADE"`
+	FromID uint         `gorm:"index"`
+	From   *Application `gorm:"foreignKey:FromID;constraint:OnDelete:CASCADE"`
+}
+
+type Review struct {
+	Model
+	BusinessCriticality uint   `gorm:"not null"`
+	EffortEstimate      string `gorm:"not null"`
+	ProposedAction      string `gorm:"not null"`
+	WorkPriority        uint   `gorm:"not null"`
+	Comments            string
+	Application         *Application
+	ApplicationID       uint `gorm:"uniqueIndex"`
+}
+
+type Import struct {
+	Model
+	Filename            string
+	ApplicationName     string
+	BusinessService     string
+	Comments            string
+	Dependency          string
+	DependencyDirection string
+	Description         string
+	ErrorMessage        string
+	IsValid             bool
+	RecordType1         string
+	ImportSummary       ImportSummary
+	ImportSummaryID     uint `gorm:"index"`
+	Processed           bool
+	ImportTags          []ImportTag `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type ImportSummary struct {
+	Model
+	Content      []byte
+	Filename     string
+	ImportStatus string
+	Imports      []Import `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type ImportTag struct {
+	Model
+	Name     string
+	TagType  string
+	ImportID uint `gorm:"index"`
+	Import   *Import
+}
+
+type Bucket struct {
+	Model
+	Name          string `gorm:"uniqueIndex:A"`
+	Path          string
+	ApplicationID uint `gorm:"uniqueIndex:A"`
+}
+
+type BusinessService struct {
+	Model
+	Name        string `gorm:"index;unique;not null"`
+	Description string
+	OwnerID     *uint `gorm:"index"`
+	Owner       *Stakeholder
+}
+
+type StakeholderGroup struct {
+	Model
+	Name         string `gorm:"index;unique;not null"`
+	Username     string
+	Description  string
+	Stakeholders []Stakeholder `gorm:"many2many:sgStakeholder"`
+}
+
+type Stakeholder struct {
+	Model
+	DisplayName      string             `gorm:"not null;"`
+	Email            string             `gorm:"index;unique;not null"`
+	Groups           []StakeholderGroup `gorm:"many2many:sgStakeholder"`
+	BusinessServices []BusinessService  `gorm:"foreignKey:OwnerID"`
+	JobFunctionID    *uint              `gorm:"index"`
+	JobFunction      *JobFunction
+}
+
+type JobFunction struct {
+	Model
+	Username     string
+	Role         string `gorm:"index;unique;not null"`
+	Stakeholders []Stakeholder
+}
+
+type Tag struct {
+	Model
+	Name      string `gorm:"uniqueIndex:tag_a;not null"`
+	Username  string
+	TagTypeID uint `gorm:"uniqueIndex:tag_a;index;not null"`
+	TagType   TagType
+}
+
+type TagType struct {
+	Model
+	Name     string `gorm:"index;unique;not null"`
+	Username string
+	Rank     uint
+	Color    string
+	Tags     []Tag
+}
+
+type Identity struct {
+	Model
+	Kind          string `gorm:"not null"`
+	Name          string `gorm:"not null"`
+	Description   string
+	User          string
+	Password      string
+	Key           string
+	Settings      string
+	Encrypted     string
+	ApplicationID uint `gorm:"many2many:appIdentity"`
+}
+
+type Proxy struct {
+	Model
+	Kind       string `gorm:"uniqueIndex"`
+	Host       string `gorm:"not null"`
+	Port       int
+	IdentityID uint `gorm:"index"`
+}
+
+type TaskReport struct {
+	Model
+	Status    string
+	Error     string
+	Total     int
+	Completed int
+	Activity  JSON
+	TaskID    uint `gorm:"uniqueIndex"`
+	Task      *Task
+}
+
+type Task struct {
+	Model
+	Name       string `gorm:"index"`
+	Addon      string `gorm:"index"`
+	Locator    string `gorm:"index"`
+	Image      string
+	Isolated   bool
+	Data       JSON
+	Started    *time.Time
+	Terminated *time.Time
+	Status     string
+	Error      string
+	Job        string
+	Report     *TaskReport `gorm:"constraint:OnDelete:CASCADE"`
+}
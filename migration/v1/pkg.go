@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"gorm.io/gorm"
+)
+
+//
+// Up creates the (baseline) schema.
+// DBs created (auto-migrated) before versioned migrations
+// are adopted as version 1.
+func Up(db *gorm.DB) (err error) {
+	err = db.AutoMigrate(All()...)
+	return
+}
+
+//
+// All builds all (version 1) models.
+// Models are enumerated such that each are listed after
+// all the other models on which they may depend.
+func All() []interface{} {
+	return []interface{}{
+		Setting{},
+		ImportSummary{},
+		Import{},
+		ImportTag{},
+		JobFunction{},
+		TagType{},
+		Tag{},
+		StakeholderGroup{},
+		Stakeholder{},
+		BusinessService{},
+		Application{},
+		Bucket{},
+		Dependency{},
+		Review{},
+		Identity{},
+		Task{},
+		TaskReport{},
+		Proxy{},
+	}
+}
@@ -0,0 +1,117 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/konveyor/tackle2-hub/migration/v1"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+)
+
+//
+// Seeded the (setting) key marking the DB seeded.
+const Seeded = ".hub.db.seeded"
+
+//
+// v2 seeds the (version 1) models with the contents of the
+// json files contained in DB_SEED_PATH. DBs already seeded
+// (before versioned migrations) are not seeded.
+func v2(db *gorm.DB) (err error) {
+	result := db.First(&v1.Setting{}, "Key", Seeded)
+	if result.Error == nil {
+		log.Info("Database already seeded, skipping.")
+		return
+	}
+	if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		err = result.Error
+		return
+	}
+	for _, m := range v1.All() {
+		err = seed(db, m)
+		if err != nil {
+			return
+		}
+	}
+	err = Resequence(db, v1.All())
+	if err != nil {
+		return
+	}
+	//
+	// Marked for (earlier) versions which (re)create
+	// the DB when not seeded.
+	seeded, _ := json.Marshal(true)
+	setting := v1.Setting{Key: Seeded, Value: seeded}
+	err = db.Create(&setting).Error
+	if err != nil {
+		return
+	}
+	log.Info("Database seeded.")
+	return
+}
+
+//
+// seed the model with the contents of the json file.
+func seed(db *gorm.DB, m interface{}) (err error) {
+	kind := reflect.TypeOf(m).Name()
+	fileName := strings.ToLower(kind) + ".json"
+	filePath := path.Join(Settings.DB.SeedPath, fileName)
+	file, err := os.Open(filePath)
+	if err != nil {
+		err = nil
+		return
+	}
+	defer file.Close()
+	jsonBytes, err := ioutil.ReadAll(file)
+	if err != nil {
+		return
+	}
+	var unmarshalled []map[string]interface{}
+	err = json.Unmarshal(jsonBytes, &unmarshalled)
+	if err != nil {
+		return
+	}
+	for i := range unmarshalled {
+		result := db.Model(&m).Create(unmarshalled[i])
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+	}
+	return
+}
+
+//
+// Resequence the (postgres) ID sequences.
+// Sequences are advanced past the models created (seeded)
+// with an ID.
+func Resequence(db *gorm.DB, models []interface{}) (err error) {
+	if db.Dialector.Name() != "postgres" {
+		return
+	}
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		err = stmt.Parse(m)
+		if err != nil {
+			return
+		}
+		id := stmt.Schema.PrioritizedPrimaryField
+		if id == nil || !id.AutoIncrement {
+			continue
+		}
+		err = db.Exec(
+			"SELECT setval(pg_get_serial_sequence(?, ?), COALESCE(MAX(?), 0) + 1, false) FROM ?",
+			stmt.Quote(stmt.Schema.Table),
+			id.DBName,
+			clause.Column{Name: id.DBName},
+			clause.Table{Name: stmt.Schema.Table}).Error
+		if err != nil {
+			return
+		}
+	}
+	return
+}
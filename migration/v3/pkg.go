@@ -0,0 +1,47 @@
+package v3
+
+import (
+	"gorm.io/gorm"
+)
+
+//
+// Up adds the (version 3) schema to the baseline schema:
+// revisions, bucket quotas, usage, expiration, digests and
+// versions, application identities (by role), identity secrets
+// and task tokens. The tables and columns are added (auto-migrated);
+// the baseline application identities are migrated (and dropped)
+// by the next migration.
+func Up(db *gorm.DB) (err error) {
+	err = db.AutoMigrate(All()...)
+	return
+}
+
+//
+// All builds all (version 3) models.
+// Models are enumerated such that each are listed after
+// all the other models on which they may depend.
+func All() []interface{} {
+	return []interface{}{
+		Setting{},
+		ImportSummary{},
+		Import{},
+		ImportTag{},
+		JobFunction{},
+		TagType{},
+		Tag{},
+		StakeholderGroup{},
+		Stakeholder{},
+		BusinessService{},
+		Application{},
+		Bucket{},
+		BucketDigest{},
+		BucketVersion{},
+		Dependency{},
+		Review{},
+		Identity{},
+		ApplicationIdentity{},
+		Task{},
+		TaskReport{},
+		Proxy{},
+	}
+}
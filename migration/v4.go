@@ -0,0 +1,118 @@
+package migration
+
+import (
+	"github.com/konveyor/tackle2-hub/migration/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//
+// AppIdentity the (baseline) application identity join table.
+const AppIdentity = "appIdentity"
+
+//
+// v4 migrates the (baseline) application identities.
+// Applications were associated with identities by the appIdentity
+// (join) table and the Identity.ApplicationID column. The associations
+// are copied to the ApplicationIdentity table in the (default) role for
+// the identity kind. The baseline table and column are dropped.
+func v4(db *gorm.DB) (err error) {
+	migrator := db.Migrator()
+	joined := migrator.HasTable(AppIdentity)
+	owned := migrator.HasColumn(&v3.Identity{}, "ApplicationID")
+	if !joined && !owned {
+		return
+	}
+	var applications []uint
+	err = db.Model(&v3.Application{}).Pluck("ID", &applications).Error
+	if err != nil {
+		return
+	}
+	appFound := make(map[uint]bool)
+	for _, id := range applications {
+		appFound[id] = true
+	}
+	identities := []struct {
+		ID            uint
+		Kind          string
+		ApplicationID uint
+	}{}
+	err = db.Table("Identity").Find(&identities).Error
+	if err != nil {
+		return
+	}
+	kind := make(map[uint]string)
+	links := []v3.ApplicationIdentity{}
+	add := func(appId, id uint) {
+		if !appFound[appId] {
+			return
+		}
+		if _, found := kind[id]; !found {
+			return
+		}
+		links = append(
+			links,
+			v3.ApplicationIdentity{
+				ApplicationID: appId,
+				IdentityID:    id,
+				Role:          role(kind[id]),
+			})
+	}
+	for _, m := range identities {
+		kind[m.ID] = m.Kind
+	}
+	for _, m := range identities {
+		add(m.ApplicationID, m.ID)
+	}
+	if joined {
+		joins := []struct {
+			ApplicationID uint
+			IdentityID    uint
+		}{}
+		err = db.Table(AppIdentity).Find(&joins).Error
+		if err != nil {
+			return
+		}
+		for _, m := range joins {
+			add(m.ApplicationID, m.IdentityID)
+		}
+	}
+	for i := range links {
+		err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&links[i]).Error
+		if err != nil {
+			return
+		}
+	}
+	log.Info("Application identities migrated.", "count", len(links))
+	if joined {
+		err = migrator.DropTable(AppIdentity)
+		if err != nil {
+			return
+		}
+	}
+	if owned {
+		err = db.Exec(
+			"ALTER TABLE ? DROP COLUMN ?",
+			clause.Table{Name: "Identity"},
+			clause.Column{Name: "ApplicationID"}).Error
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// role returns the (default) application role for
+// the identity kind.
+func role(kind string) (role string) {
+	switch kind {
+	case "mvn":
+		role = "maven"
+	case "proxy":
+		role = "proxy"
+	default:
+		role = "source"
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package migration
+
+import (
+	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
+)
+
+//
+// v5Identity the identity credential indicators.
+type v5Identity struct {
+	ID          uint
+	Encrypted   string
+	Secret      string
+	HasPassword bool `gorm:"not null;default:false"`
+	HasKey      bool `gorm:"not null;default:false"`
+	HasSettings bool `gorm:"not null;default:false"`
+}
+
+func (v5Identity) TableName() string {
+	return "Identity"
+}
+
+//
+// v5 adds the identity credential indicators.
+// The indicators are set for existing identities using the
+// (decrypted) credentials. Identities with secrets that cannot
+// be read are logged and the indicators are left unset.
+func v5(db *gorm.DB) (err error) {
+	migrator := db.Migrator()
+	for _, column := range []string{"HasPassword", "HasKey", "HasSettings"} {
+		if migrator.HasColumn(&v5Identity{}, column) {
+			continue
+		}
+		err = migrator.AddColumn(&v5Identity{}, column)
+		if err != nil {
+			return
+		}
+	}
+	var identities []v5Identity
+	err = db.Find(&identities).Error
+	if err != nil {
+		return
+	}
+	for _, m := range identities {
+		identity := model.Identity{
+			Encrypted: m.Encrypted,
+			Secret:    m.Secret,
+		}
+		dErr := identity.Decrypt(model.SecretStore())
+		if dErr != nil {
+			log.Error(dErr, "Identity credentials not read.", "id", m.ID)
+			continue
+		}
+		err = db.Model(&v5Identity{}).Where("ID", m.ID).Updates(
+			map[string]interface{}{
+				"HasPassword": identity.Password != "",
+				"HasKey":      identity.Key != "",
+				"HasSettings": identity.Settings != "",
+			}).Error
+		if err != nil {
+			return
+		}
+	}
+	return
+}
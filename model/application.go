@@ -9,10 +9,11 @@ type Application struct {
 	Review            *Review
 	Repository        JSON
 	Comments          string
-	Tags              []Tag      `gorm:"many2many:applicationTags"`
-	Identities        []Identity `gorm:"many2many:appIdentity"`
-	BusinessServiceID uint       `gorm:"index"`
+	Tags              []Tag                 `gorm:"many2many:applicationTags"`
+	Identities        []ApplicationIdentity `gorm:"constraint:OnDelete:CASCADE"`
+	BusinessServiceID uint                  `gorm:"index"`
 	BusinessService   *BusinessService
+	BucketQuota       int64
 }
 
 type Dependency struct {
@@ -11,4 +11,5 @@ type Model struct {
 	CreateUser string
 	UpdateUser string
 	CreateTime time.Time `gorm:"autoCreateTime"`
+	Revision   uint      `gorm:"not null;default:1"`
 }
@@ -2,7 +2,7 @@ package model
 
 import (
 	"gorm.io/gorm"
-	"os"
+	"time"
 )
 
 type Bucket struct {
@@ -10,9 +10,60 @@ type Bucket struct {
 	Name          string `gorm:"uniqueIndex:A"`
 	Path          string
 	ApplicationID uint `gorm:"uniqueIndex:A"`
+	Quota         int64
+	Bytes         int64
+	Files         int64
+	Expiration    *time.Time
+	Objects       string
 }
 
+//
+// AfterDelete removes the bucket content after the transaction
+// is committed. Not removed when the (conditional) delete matched
+// no rows.
 func (m *Bucket) AfterDelete(db *gorm.DB) (err error) {
-	err = os.RemoveAll(m.Path)
+	if Affected(db) == 0 {
+		return
+	}
+	root := m.Path
+	objects := m.Objects
+	PendingOf(db).Committed(func() (err error) {
+		err = Buckets().Remove(root)
+		if err != nil {
+			return
+		}
+		if objects != "" {
+			err = Buckets().Remove(objects)
+		}
+		return
+	})
 	return
 }
+
+//
+// BucketDigest the (cached) content digest of a bucket file.
+// The digest is valid while the file size and ModTime (ns) match.
+type BucketDigest struct {
+	BucketID uint    `gorm:"primaryKey"`
+	Bucket   *Bucket `gorm:"constraint:OnDelete:CASCADE"`
+	Path     string  `gorm:"primaryKey"`
+	Size     int64
+	ModTime  int64
+	Digest   string
+}
+
+//
+// BucketVersion an (immutable) snapshot of the bucket content.
+// The manifest lists the (content addressed) objects stored in
+// the bucket object root.
+type BucketVersion struct {
+	Model
+	BucketID uint    `gorm:"uniqueIndex:BucketVersionA"`
+	Bucket   *Bucket `gorm:"constraint:OnDelete:CASCADE"`
+	Version  uint    `gorm:"uniqueIndex:BucketVersionA"`
+	Digest   string  `gorm:"index"`
+	Bytes    int64
+	Files    int64
+	Manifest JSON
+	TaskID   *uint `gorm:"index"`
+}
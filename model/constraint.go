@@ -0,0 +1,294 @@
+package model
+
+import (
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+)
+
+//
+// Constraint kinds.
+var (
+	ErrUnique     = errors.New("unique constraint")
+	ErrForeignKey = errors.New("foreign key constraint")
+	ErrNotNull    = errors.New("not null constraint")
+	ErrCheck      = errors.New("check constraint")
+)
+
+//
+// SQLSTATE (integrity constraint violation) codes.
+const (
+	StateNotNull    = "23502"
+	StateForeignKey = "23503"
+	StateUnique     = "23505"
+	StateCheck      = "23514"
+)
+
+//
+// ConstraintError reports a constraint violation.
+// Matches (errors.Is) the constraint kind.
+type ConstraintError struct {
+	// Kind of constraint. Example: ErrUnique.
+	Kind error
+	// Table (name) when known.
+	Table string
+	// Fields (column names) when known.
+	Fields []string
+	// Constraint (name) when known.
+	Constraint string
+	// Err the (driver) error.
+	Err error
+}
+
+//
+// Error returns the error description.
+func (e *ConstraintError) Error() (s string) {
+	s = e.Err.Error()
+	return
+}
+
+//
+// Unwrap returns the (driver) error.
+func (e *ConstraintError) Unwrap() (err error) {
+	err = e.Err
+	return
+}
+
+//
+// Is matches the constraint kind.
+func (e *ConstraintError) Is(err error) (matched bool) {
+	matched = err == e.Kind
+	return
+}
+
+//
+// Classify returns the constraint error for the (driver) error.
+// Returns nil when the error does not report a constraint violation.
+// Errors are classified by SQLSTATE (when reported by the driver),
+// the SQLite (extended) code or by the message.
+// Supported drivers: SQLite, PostgreSQL.
+func Classify(err error) (ce *ConstraintError) {
+	if err == nil {
+		return
+	}
+	if errors.As(err, &ce) {
+		return
+	}
+	var kind error
+	var stateErr interface{ SQLState() string }
+	pgErr := &pgconn.PgError{}
+	sqliteErr := &sqlite3.Error{}
+	switch {
+	case errors.As(err, &pgErr):
+		kind = stateKind(pgErr.Code)
+	case errors.As(err, &stateErr):
+		kind = stateKind(stateErr.SQLState())
+	case errors.As(err, sqliteErr):
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique,
+			sqlite3.ErrConstraintPrimaryKey:
+			kind = ErrUnique
+		case sqlite3.ErrConstraintForeignKey:
+			kind = ErrForeignKey
+		case sqlite3.ErrConstraintNotNull:
+			kind = ErrNotNull
+		case sqlite3.ErrConstraintCheck:
+			kind = ErrCheck
+		}
+	default:
+		kind = messageKind(err.Error())
+	}
+	if kind == nil {
+		return
+	}
+	ce = &ConstraintError{
+		Kind: kind,
+		Err:  err,
+	}
+	if pgErr.Code != "" {
+		ce.parsePg(pgErr)
+	} else {
+		ce.parse(err.Error())
+	}
+	return
+}
+
+//
+// parse the (SQLite) message for the table and fields.
+// Example: UNIQUE constraint failed: Tag.Name, Tag.TagTypeID
+func (e *ConstraintError) parse(message string) {
+	part := strings.SplitN(message, "constraint failed:", 2)
+	if len(part) != 2 {
+		return
+	}
+	for _, name := range strings.Split(part[1], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if e.Kind == ErrCheck {
+			e.Constraint = name
+			continue
+		}
+		qualified := strings.SplitN(name, ".", 2)
+		if len(qualified) == 2 {
+			e.Table = qualified[0]
+			name = qualified[1]
+		}
+		e.Fields = append(e.Fields, name)
+	}
+}
+
+//
+// parsePg parses the (PostgreSQL) error for the table and fields.
+// The fields are reported by the column or the detail.
+// Example: Key ("Name", "TagTypeID")=(a, 1) already exists.
+func (e *ConstraintError) parsePg(pgErr *pgconn.PgError) {
+	e.Table = pgErr.TableName
+	e.Constraint = pgErr.ConstraintName
+	if pgErr.ColumnName != "" {
+		e.Fields = []string{pgErr.ColumnName}
+		return
+	}
+	part := strings.SplitN(pgErr.Detail, "Key (", 2)
+	if len(part) != 2 {
+		return
+	}
+	part = strings.SplitN(part[1], ")=(", 2)
+	if len(part) != 2 {
+		return
+	}
+	for _, name := range strings.Split(part[0], ",") {
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		if name != "" {
+			e.Fields = append(e.Fields, name)
+		}
+	}
+}
+
+//
+// Constrain (callback) classifies constraint violations.
+// Foreign key violations not naming the field are resolved
+// to the (belongs-to) fields referencing models not found.
+func Constrain(db *gorm.DB) {
+	ce := Classify(db.Error)
+	if ce == nil {
+		return
+	}
+	stmt := db.Statement
+	if stmt.Schema != nil {
+		_, deleted := stmt.Clauses["DELETE"]
+		switch {
+		case ce.Kind == ErrForeignKey && deleted:
+			// The (deleted) model is referenced.
+			ce.Table = stmt.Schema.Table
+			ce.Fields = nil
+		case ce.Kind == ErrForeignKey && len(ce.Fields) == 0:
+			db.Error = nil
+			ce.Fields = unresolved(db)
+		}
+		if ce.Table == "" {
+			ce.Table = stmt.Schema.Table
+		}
+	}
+	db.Error = ce
+}
+
+//
+// unresolved returns the (foreign key) fields referencing
+// models not found.
+func unresolved(db *gorm.DB) (fields []string) {
+	stmt := db.Statement
+	for _, rel := range stmt.Schema.Relationships.Relations {
+		if rel.Type != schema.BelongsTo {
+			continue
+		}
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey || ref.PrimaryKey == nil {
+				continue
+			}
+			for _, value := range fieldValues(stmt, ref.ForeignKey) {
+				var count int64
+				tx := db.Session(&gorm.Session{NewDB: true})
+				tx = tx.Table(rel.FieldSchema.Table)
+				tx = tx.Where(stmt.Quote(ref.PrimaryKey.DBName)+" = ?", value)
+				err := tx.Count(&count).Error
+				if err == nil && count == 0 {
+					fields = append(fields, ref.ForeignKey.DBName)
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+//
+// fieldValues returns the (non-zero) values of the field
+// assigned by the statement.
+func fieldValues(stmt *gorm.Statement, field *schema.Field) (values []interface{}) {
+	if assigned, cast := stmt.Dest.(map[string]interface{}); cast {
+		for _, name := range []string{field.Name, field.DBName} {
+			value, found := assigned[name]
+			if found && value != nil && !reflect.ValueOf(value).IsZero() {
+				values = append(values, value)
+			}
+		}
+		return
+	}
+	rv := reflect.Indirect(stmt.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		value, zero := field.ValueOf(rv)
+		if !zero {
+			values = append(values, value)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			value, zero := field.ValueOf(reflect.Indirect(rv.Index(i)))
+			if !zero {
+				values = append(values, value)
+			}
+		}
+	}
+	return
+}
+
+//
+// stateKind returns the constraint kind for the SQLSTATE.
+func stateKind(state string) (kind error) {
+	switch state {
+	case StateUnique:
+		kind = ErrUnique
+	case StateForeignKey:
+		kind = ErrForeignKey
+	case StateNotNull:
+		kind = ErrNotNull
+	case StateCheck:
+		kind = ErrCheck
+	}
+	return
+}
+
+//
+// messageKind returns the constraint kind reported by the message.
+func messageKind(message string) (kind error) {
+	message = strings.ToUpper(message)
+	switch {
+	case strings.Contains(message, "UNIQUE CONSTRAINT"),
+		strings.Contains(message, "DUPLICATE KEY"):
+		kind = ErrUnique
+	case strings.Contains(message, "FOREIGN KEY CONSTRAINT"):
+		kind = ErrForeignKey
+	case strings.Contains(message, "NOT NULL CONSTRAINT"),
+		strings.Contains(message, "NOT-NULL CONSTRAINT"):
+		kind = ErrNotNull
+	case strings.Contains(message, "CHECK CONSTRAINT"):
+		kind = ErrCheck
+	}
+	return
+}
@@ -1,95 +1,261 @@
 package model
 
 import (
-	"encoding/json"
-	"github.com/konveyor/tackle2-hub/encryption"
+	"github.com/konveyor/tackle2-hub/secret"
 	"gorm.io/gorm"
+	"reflect"
 )
 
 //
 // Identity represents and identity with a set of credentials.
 // Kinds = (git|svn|mvn|proxy)
+// The credentials are stored in the secret store. When stored in
+// the DB, the encrypted credentials are stored in `Encrypted`. Otherwise,
+// `Secret` contains the (qualified) reference to the external secret.
+// Secrets stored in another (previously configured) store are moved
+// to the configured store when saved. The credentials present in the
+// secret are indicated (Has*) so identities may be listed without
+// fetching the secrets.
 type Identity struct {
 	Model
-	Kind          string `gorm:"not null"`
-	Name          string `gorm:"not null"`
-	Description   string
-	User          string
-	Password      string
-	Key           string
-	Settings      string
-	Encrypted     string
-	ApplicationID uint `gorm:"many2many:appIdentity"`
+	Kind         string `gorm:"not null"`
+	Name         string `gorm:"not null"`
+	Description  string
+	User         string
+	Password     string
+	Key          string
+	Settings     string
+	Encrypted    string
+	Secret       string
+	HasPassword  bool                  `gorm:"not null;default:false"`
+	HasKey       bool                  `gorm:"not null;default:false"`
+	HasSettings  bool                  `gorm:"not null;default:false"`
+	Applications []ApplicationIdentity `gorm:"constraint:OnDelete:CASCADE"`
+	// created (qualified) reference of the secret created when saved.
+	created string
+	// moved (qualified) reference of the secret replaced when saved.
+	moved string
+}
+
+//
+// ApplicationIdentity associates an identity with an
+// application in a role.
+// Roles = (source|maven|proxy)
+type ApplicationIdentity struct {
+	ApplicationID uint `gorm:"primaryKey"`
+	Application   *Application
+	IdentityID    uint `gorm:"primaryKey;index"`
+	Identity      *Identity
+	Role          string `gorm:"primaryKey"`
 }
 
 //
 // Encrypt sensitive fields.
-func (r *Identity) Encrypt(passphrase string) (err error) {
-	aes := encryption.New(passphrase)
-	encrypted, err := r.encrypted(passphrase)
+// The credentials are moved to the secret store.
+func (r *Identity) Encrypt(store secret.Store) (err error) {
+	material, err := r.secret(store)
 	if err != nil {
 		return
 	}
 	if r.User != "" {
-		encrypted.User = r.User
+		material.User = r.User
 		r.User = ""
 	}
 	if r.Password != "" {
-		encrypted.Password = r.Password
+		material.Password = r.Password
 		r.Password = ""
 	}
 	if r.Key != "" {
-		encrypted.Key = r.Key
+		material.Key = r.Key
 		r.Key = ""
 	}
 	if r.Settings != "" {
-		encrypted.Settings = r.Settings
+		material.Settings = r.Settings
 		r.Settings = ""
 	}
-	b, err := json.Marshal(encrypted)
+	r.HasPassword = material.Password != ""
+	r.HasKey = material.Key != ""
+	r.HasSettings = material.Settings != ""
+	if store.Kind() == secret.DB {
+		r.Encrypted, err = store.Put("", material)
+		if err != nil {
+			return
+		}
+		r.moved = r.Secret
+		r.Secret = ""
+		return
+	}
+	ref := ""
+	if r.Secret != "" {
+		kind, unqualified, _ := secret.Parse(r.Secret)
+		if kind == store.Kind() {
+			ref = unqualified
+		} else {
+			r.moved = r.Secret
+		}
+	}
+	created := ref == ""
+	ref, err = store.Put(ref, material)
 	if err != nil {
 		return
 	}
-	r.Encrypted, err = aes.Encrypt(string(b))
+	r.Secret = secret.Ref(store, ref)
+	r.Encrypted = ""
+	if created {
+		r.created = r.Secret
+	}
 	return
 }
 
 //
 // Decrypt sensitive fields.
-func (r *Identity) Decrypt(passphrase string) (err error) {
-	encrypted, err := r.encrypted(passphrase)
+// The credentials are fetched from the secret store.
+func (r *Identity) Decrypt(store secret.Store) (err error) {
+	material, err := r.secret(store)
 	if err != nil {
 		return
 	}
-	r.User = encrypted.User
-	r.Password = encrypted.Password
-	r.Key = encrypted.Key
-	r.Settings = encrypted.Settings
+	r.User = material.User
+	r.Password = material.Password
+	r.Key = material.Key
+	r.Settings = material.Settings
 	return
 }
 
+//
+// Merge credentials not specified with the
+// (decrypted) credentials of the other identity.
+func (r *Identity) Merge(other *Identity) {
+	if r.User == "" {
+		r.User = other.User
+	}
+	if r.Password == "" {
+		r.Password = other.Password
+	}
+	if r.Key == "" {
+		r.Key = other.Key
+	}
+	if r.Settings == "" {
+		r.Settings = other.Settings
+	}
+}
+
 //
 // BeforeSave ensure encrypted.
 func (r *Identity) BeforeSave(tx *gorm.DB) (err error) {
-	err = r.Encrypt(Settings.Encryption.Passphrase)
+	err = r.Encrypt(SecretStore())
 	return
 }
 
 //
-// encrypted returns the encrypted credentials.
-func (r *Identity) encrypted(passphrase string) (encrypted *Identity, err error) {
-	aes := encryption.New(passphrase)
-	encrypted = &Identity{}
-	if r.Encrypted != "" {
-		var dj string
-		dj, err = aes.Decrypt(r.Encrypted)
+// AfterDelete deletes the external secret after the
+// transaction is committed. Not deleted when the (conditional)
+// delete matched no rows.
+func (r *Identity) AfterDelete(tx *gorm.DB) (err error) {
+	if Affected(tx) == 0 {
+		return
+	}
+	qualified := r.Secret
+	PendingOf(tx).Committed(func() error {
+		return r.delete(qualified)
+	})
+	return
+}
+
+//
+// Settle the external secrets of saved identities.
+// When saved, secrets moved from another store are deleted after
+// the transaction is committed and the secrets created are deleted
+// when rolled back. Otherwise, the secrets created are deleted (not
+// orphaned).
+func Settle(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.ModelType != reflect.TypeOf(Identity{}) {
+		return
+	}
+	identities := []*Identity{}
+	add := func(v reflect.Value) {
+		if v.Kind() != reflect.Ptr {
+			if !v.CanAddr() {
+				return
+			}
+			v = v.Addr()
+		}
+		if m, cast := v.Interface().(*Identity); cast && m != nil {
+			identities = append(identities, m)
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			add(stmt.ReflectValue.Index(i))
+		}
+	case reflect.Struct, reflect.Ptr:
+		add(stmt.ReflectValue)
+	}
+	for _, m := range identities {
+		identity := m
+		created := m.created
+		moved := m.moved
+		m.created = ""
+		m.moved = ""
+		if db.Error == nil {
+			pending := PendingOf(db)
+			pending.Committed(func() error {
+				return identity.delete(moved)
+			})
+			pending.RolledBack(func() error {
+				return identity.delete(created)
+			})
+			continue
+		}
+		err := m.delete(created)
 		if err != nil {
+			log.Error(err, "Delete secret failed.", "id", m.ID)
+		}
+	}
+}
+
+//
+// delete the external secret by (qualified) reference.
+func (r *Identity) delete(qualified string) (err error) {
+	if qualified == "" {
+		return
+	}
+	kind, ref, err := secret.Parse(qualified)
+	if err != nil {
+		return
+	}
+	store, err := StoreOf(kind)
+	if err != nil {
+		return
+	}
+	err = store.Delete(ref)
+	return
+}
+
+//
+// secret returns the stored credentials.
+// Credentials encrypted in the DB are always readable so
+// they may be moved to an external store when saved. Likewise,
+// secrets in another (configured) store.
+func (r *Identity) secret(store secret.Store) (material *secret.Secret, err error) {
+	if r.Secret != "" {
+		kind, ref, pErr := secret.Parse(r.Secret)
+		if pErr != nil {
+			err = pErr
 			return
 		}
-		err = json.Unmarshal([]byte(dj), encrypted)
+		store, err = StoreOf(kind)
 		if err != nil {
 			return
 		}
+		material, err = store.Get(ref)
+		return
+	}
+	if store.Kind() != secret.DB {
+		store = &secret.DBStore{Keyring: Keyring()}
 	}
+	material, err = store.Get(r.Encrypted)
 	return
 }
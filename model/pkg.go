@@ -1,18 +1,183 @@
 package model
 
 import (
+	"fmt"
+	"github.com/konveyor/controller/pkg/logging"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/encryption"
+	"github.com/konveyor/tackle2-hub/secret"
 	"github.com/konveyor/tackle2-hub/settings"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
 )
 
 var (
 	Settings = &settings.Settings
+	log      = logging.WithName("model")
+	// Store the (configured) identity secret store.
+	// The DB store is used when not set.
+	Store secret.Store
+	// Stores the (other) identity secret stores by kind.
+	// Used to read (and move) secrets stored before the
+	// configured store was changed.
+	Stores = map[string]secret.Store{}
+	// BucketStore the (configured) bucket content store.
+	// The filesystem store is used when not set.
+	BucketStore bucket.Store
 )
 
 //
 // Field (data) types.
 type JSON = datatypes.JSON
 
+//
+// Keyring returns the encryption keyring
+// built using the (hub) settings.
+func Keyring() (keyring *encryption.Keyring) {
+	keyring = encryption.NewKeyring(
+		Settings.Encryption.KeyID,
+		Settings.Hub.EncryptionKeys())
+	keyring.Legacy = Settings.Encryption.LegacyKeyID
+	return
+}
+
+//
+// SecretStore returns the identity secret store.
+func SecretStore() (store secret.Store) {
+	store = Store
+	if store == nil {
+		store = &secret.DBStore{Keyring: Keyring()}
+	}
+	return
+}
+
+//
+// StoreOf returns the identity secret store by kind.
+func StoreOf(kind string) (store secret.Store, err error) {
+	store = SecretStore()
+	if store.Kind() == kind {
+		return
+	}
+	store, found := Stores[kind]
+	if found {
+		return
+	}
+	if kind == secret.DB {
+		store = &secret.DBStore{Keyring: Keyring()}
+		return
+	}
+	err = fmt.Errorf("%w: store '%s' not configured.", secret.ErrStore, kind)
+	return
+}
+
+//
+// Buckets returns the bucket content store.
+func Buckets() (store bucket.Store) {
+	store = BucketStore
+	if store == nil {
+		store = &bucket.FSStore{Path: Settings.Hub.Bucket.Path}
+	}
+	return
+}
+
+//
+// Register (gorm) callbacks.
+func Register(db *gorm.DB) (err error) {
+	if db.Dialector.Name() == "sqlite" {
+		create := db.Callback().Create()
+		err = create.Replace("gorm:create", sqliteCreate(create.Clauses))
+		if err != nil {
+			return
+		}
+	}
+	err = db.Callback().Update().
+		Before("gorm:update").
+		Register("hub:revision", Revise)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Create().
+		After("gorm:create").
+		Register("hub:constraint", Constrain)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Create().
+		After("gorm:create").
+		Register("hub:secret", Settle)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Update().
+		After("gorm:update").
+		Register("hub:secret", Settle)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Update().
+		After("gorm:update").
+		Register("hub:constraint", Constrain)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Delete().
+		After("gorm:delete").
+		Register("hub:constraint", Constrain)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Create().
+		After("gorm:commit_or_rollback_transaction").
+		Register("hub:settled", Settled)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Update().
+		After("gorm:commit_or_rollback_transaction").
+		Register("hub:settled", Settled)
+	if err != nil {
+		return
+	}
+	err = db.Callback().Delete().
+		After("gorm:commit_or_rollback_transaction").
+		Register("hub:settled", Settled)
+	return
+}
+
+//
+// sqliteCreate returns the (gorm) SQLite create callback.
+// SQLite reports (immediate) foreign key violations when the
+// statement completes. The default callback adds RETURNING (to
+// read the default values) to the insert of each model with an
+// (auto-increment) ID and queries the insert. The violation is then
+// reported by the rows (iteration) which GORM does not check, and
+// the failed create is reported as succeeded. Since most models
+// are referenced by (or reference) other models, the insert is
+// executed without RETURNING and the ID is set using the last
+// insert ID. Other default values are set by GORM (using the
+// field default) before the insert. Inserts with RETURNING
+// (explicitly) requested by the caller are queried by the
+// default callback.
+func sqliteCreate(clauses []string) func(db *gorm.DB) {
+	queried := callbacks.Create(
+		&callbacks.Config{
+			CreateClauses:        clauses,
+			LastInsertIDReversed: true,
+		})
+	executed := callbacks.Create(
+		&callbacks.Config{
+			LastInsertIDReversed: true,
+		})
+	return func(db *gorm.DB) {
+		if _, found := db.Statement.Clauses["RETURNING"]; found {
+			queried(db)
+		} else {
+			executed(db)
+		}
+	}
+}
+
 //
 // All builds all models.
 // Models are enumerated such that each are listed after
@@ -31,9 +196,12 @@ func All() []interface{} {
 		BusinessService{},
 		Application{},
 		Bucket{},
+		BucketDigest{},
+		BucketVersion{},
 		Dependency{},
 		Review{},
 		Identity{},
+		ApplicationIdentity{},
 		Task{},
 		TaskReport{},
 		Proxy{},
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+//
+// RevisionField the (model) revision field.
+const RevisionField = "Revision"
+
+//
+// NoRevision the (statement) setting used to suppress the
+// revision increment.
+const NoRevision = "hub:no-revision"
+
+//
+// Bookkeeping returns a session for internal (bookkeeping) updates.
+// The revision is not incremented so the entity tag changes only
+// when the resource is updated (by the user).
+func Bookkeeping(db *gorm.DB) *gorm.DB {
+	return db.Set(NoRevision, true).Session(&gorm.Session{})
+}
+
+//
+// Affected returns the rows affected by the statement.
+// Used by hooks which are passed a (new) session.
+func Affected(db *gorm.DB) (affected int64) {
+	affected = db.Statement.DB.RowsAffected
+	return
+}
+
+//
+// Revise increments the revision of updated models.
+// The update (SQL) is built here so the revision may be incremented
+// in the same statement: SET ..., Revision = Revision + 1.
+// The (stale) revision assigned by the model is never written.
+// Not incremented for bookkeeping updates.
+func Revise(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	field := stmt.Schema.LookUpField(RevisionField)
+	if field == nil {
+		return
+	}
+	stmt.AddClauseIfNotExists(clause.Update{})
+	set := clause.Set{}
+	for _, assignment := range callbacks.ConvertToAssignments(stmt) {
+		if assignment.Column.Name != field.DBName {
+			set = append(set, assignment)
+		}
+	}
+	if len(set) == 0 {
+		return
+	}
+	if skip, found := db.Get(NoRevision); !found || skip != true {
+		set = append(
+			set,
+			clause.Assignment{
+				Column: clause.Column{Name: field.DBName},
+				Value:  gorm.Expr(stmt.Quote(field.DBName) + " + 1"),
+			})
+	}
+	stmt.AddClause(set)
+	stmt.Build(stmt.BuildClauses...)
+}
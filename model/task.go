@@ -1,6 +1,8 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 )
 
@@ -15,6 +17,10 @@ type TaskReport struct {
 	Task      *Task
 }
 
+//
+// Task an addon task.
+// The Token is the (SHA-256) digest of the (bearer) token
+// issued to the addon. See: TokenDigest().
 type Task struct {
 	Model
 	Name       string `gorm:"index"`
@@ -28,7 +34,9 @@ type Task struct {
 	Status     string
 	Error      string
 	Job        string
+	Token      string      `gorm:"index"`
 	Report     *TaskReport `gorm:"constraint:OnDelete:CASCADE"`
+	BucketID   *uint
 }
 
 func (m *Task) Reset() {
@@ -36,4 +44,14 @@ func (m *Task) Reset() {
 	m.Terminated = nil
 	m.Report = nil
 	m.Status = ""
+	m.Token = ""
+}
+
+//
+// TokenDigest returns the (SHA-256) digest of the task token.
+// The token is not stored or queried, only the digest.
+func TokenDigest(token string) (digest string) {
+	sum := sha256.Sum256([]byte(token))
+	digest = hex.EncodeToString(sum[:])
+	return
 }
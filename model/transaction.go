@@ -0,0 +1,158 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+//
+// PendingKey the (statement) setting for the pending actions
+// of the transaction.
+const PendingKey = "hub:pending"
+
+//
+// Pending actions of a transaction.
+// The actions (with external side effects) are run after the
+// transaction is committed or rolled back.
+type Pending struct {
+	actions []action
+}
+
+//
+// action pending.
+type action struct {
+	// committed when run after commit. Otherwise, after rollback.
+	committed bool
+	fn        func() error
+}
+
+//
+// Committed adds an action to be run after commit.
+func (r *Pending) Committed(fn func() error) {
+	r.actions = append(r.actions, action{committed: true, fn: fn})
+}
+
+//
+// RolledBack adds an action to be run after rollback.
+func (r *Pending) RolledBack(fn func() error) {
+	r.actions = append(r.actions, action{fn: fn})
+}
+
+//
+// Mark returns the mark (savepoint) of the pending actions.
+func (r *Pending) Mark() (mark int) {
+	mark = len(r.actions)
+	return
+}
+
+//
+// Commit runs (and clears) the committed actions.
+func (r *Pending) Commit() {
+	r.run(0, true)
+}
+
+//
+// Rollback runs (and clears) the rolled back actions
+// added after the mark. The committed actions added after
+// the mark are discarded.
+func (r *Pending) Rollback(mark int) {
+	r.run(mark, false)
+}
+
+//
+// run (and clear) the actions added after the mark.
+func (r *Pending) run(mark int, committed bool) {
+	if mark < 0 || mark > len(r.actions) {
+		mark = 0
+	}
+	actions := r.actions[mark:]
+	r.actions = r.actions[:mark]
+	for _, a := range actions {
+		if a.committed != committed {
+			continue
+		}
+		err := a.fn()
+		if err != nil {
+			log.Error(err, "Pending action failed.")
+		}
+	}
+}
+
+//
+// PendingOf returns the pending actions of the transaction.
+// When not in a transaction (started by Begin or Transaction), the
+// actions are pending on the statement and run when the (default)
+// statement transaction is committed or rolled back.
+func PendingOf(db *gorm.DB) (pending *Pending) {
+	if v, found := db.Get(PendingKey); found {
+		if p, cast := v.(*Pending); cast {
+			pending = p
+			return
+		}
+	}
+	if v, found := db.InstanceGet(PendingKey); found {
+		pending = v.(*Pending)
+		return
+	}
+	pending = &Pending{}
+	// Stored on the statement (shared with the hook session).
+	// Not db.InstanceSet() which may clone the statement.
+	stmt := db.Statement
+	stmt.Settings.Store(fmt.Sprintf("%p", stmt)+PendingKey, pending)
+	return
+}
+
+//
+// Begin a transaction.
+// The caller must Commit() or Rollback() the pending actions
+// after the transaction is committed or rolled back.
+func Begin(db *gorm.DB) (tx *gorm.DB, pending *Pending) {
+	pending = &Pending{}
+	tx = db.Set(PendingKey, pending).Begin()
+	return
+}
+
+//
+// Transaction runs the function in a transaction.
+// The pending actions are run after the transaction is
+// committed or rolled back. When nested, the committed actions
+// are run after the outer transaction is committed.
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) (err error) {
+	pending := &Pending{}
+	nested := false
+	if v, found := db.Get(PendingKey); found {
+		if p, cast := v.(*Pending); cast {
+			pending = p
+			nested = true
+		}
+	}
+	if !nested {
+		db = db.Set(PendingKey, pending)
+	}
+	mark := pending.Mark()
+	err = db.Transaction(fn)
+	if err != nil {
+		pending.Rollback(mark)
+		return
+	}
+	if !nested {
+		pending.Commit()
+	}
+	return
+}
+
+//
+// Settled runs the actions pending on the statement after the
+// (default) statement transaction is committed or rolled back.
+func Settled(db *gorm.DB) {
+	v, found := db.InstanceGet(PendingKey)
+	if !found {
+		return
+	}
+	pending := v.(*Pending)
+	if db.Error == nil {
+		pending.Commit()
+	} else {
+		pending.Rollback(0)
+	}
+}
@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"encoding/json"
+	"github.com/konveyor/tackle2-hub/encryption"
+)
+
+//
+// DBStore stores secrets in the DB.
+// The reference is the encrypted secret which is
+// stored in the DB by the caller.
+type DBStore struct {
+	// Keyring used to encrypt.
+	Keyring *encryption.Keyring
+}
+
+//
+// Kind of store.
+func (r *DBStore) Kind() string {
+	return DB
+}
+
+//
+// Get the secret by (encrypted) reference.
+func (r *DBStore) Get(ref string) (secret *Secret, err error) {
+	secret = &Secret{}
+	if ref == "" {
+		return
+	}
+	dj, err := r.Keyring.Decrypt(ref)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(dj), secret)
+	return
+}
+
+//
+// Put the secret.
+// Returns the encrypted secret as the reference.
+func (r *DBStore) Put(ref string, secret *Secret) (updated string, err error) {
+	b, err := json.Marshal(secret)
+	if err != nil {
+		return
+	}
+	updated, err = r.Keyring.Encrypt(string(b))
+	return
+}
+
+//
+// Delete the secret.
+// Nothing to be done (the reference is the secret).
+func (r *DBStore) Delete(ref string) (err error) {
+	return
+}
@@ -0,0 +1,103 @@
+package secret
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/konveyor/tackle2-hub/encryption"
+	"os"
+	pathlib "path"
+)
+
+//
+// FileStore stores secrets in (encrypted) files.
+// Intended for local testing.
+// The reference is the file name.
+type FileStore struct {
+	// Path to the directory.
+	Path string
+	// Keyring used to encrypt.
+	Keyring *encryption.Keyring
+}
+
+//
+// Kind of store.
+func (r *FileStore) Kind() string {
+	return File
+}
+
+//
+// Get the secret by reference.
+func (r *FileStore) Get(ref string) (secret *Secret, err error) {
+	path, err := r.path(ref)
+	if err != nil {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = ErrNotFound
+		}
+		return
+	}
+	dj, err := r.Keyring.Decrypt(string(b))
+	if err != nil {
+		return
+	}
+	secret = &Secret{}
+	err = json.Unmarshal([]byte(dj), secret)
+	return
+}
+
+//
+// Put the secret.
+func (r *FileStore) Put(ref string, secret *Secret) (updated string, err error) {
+	updated = ref
+	if updated == "" {
+		updated = uuid.New().String()
+	}
+	path, err := r.path(updated)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(secret)
+	if err != nil {
+		return
+	}
+	encrypted, err := r.Keyring.Encrypt(string(b))
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(r.Path, 0700)
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(path, []byte(encrypted), 0600)
+	return
+}
+
+//
+// Delete the secret.
+func (r *FileStore) Delete(ref string) (err error) {
+	path, err := r.path(ref)
+	if err != nil {
+		return
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return
+}
+
+//
+// path returns the path of the file.
+// The reference must be a file name.
+func (r *FileStore) path(ref string) (path string, err error) {
+	if ref == "" || ref != pathlib.Base(ref) || ref == "." || ref == ".." {
+		err = ErrStore
+		return
+	}
+	path = pathlib.Join(r.Path, ref)
+	return
+}
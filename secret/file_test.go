@@ -0,0 +1,40 @@
+package secret
+
+import (
+	"errors"
+	"github.com/onsi/gomega"
+	"os"
+	pathlib "path"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := &FileStore{
+		Path:    pathlib.Join(t.TempDir(), "secrets"),
+		Keyring: testKeyring(),
+	}
+	testStore(t, store)
+	//
+	// Encrypted.
+	ref, err := store.Put("", &Secret{Password: "secret"})
+	g.Expect(err).To(gomega.BeNil())
+	b, err := os.ReadFile(pathlib.Join(store.Path, ref))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(string(b)).ToNot(gomega.ContainSubstring("secret"))
+	//
+	// References must be (file) names.
+	for _, ref := range []string{"", ".", "..", "../x", "a/b"} {
+		_, err = store.Get(ref)
+		g.Expect(errors.Is(err, ErrStore)).To(gomega.BeTrue(), ref)
+		_, err = store.Put(ref, &Secret{})
+		if ref == "" {
+			g.Expect(err).To(gomega.BeNil())
+			continue
+		}
+		g.Expect(errors.Is(err, ErrStore)).To(gomega.BeTrue(), ref)
+		err = store.Delete(ref)
+		g.Expect(errors.Is(err, ErrStore)).To(gomega.BeTrue(), ref)
+	}
+}
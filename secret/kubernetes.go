@@ -0,0 +1,129 @@
+package secret
+
+import (
+	"context"
+	core "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//
+// Secret data keys.
+const (
+	KeyUser     = "user"
+	KeyPassword = "password"
+	KeyKey      = "key"
+	KeySettings = "settings"
+)
+
+//
+// KubernetesStore stores secrets as k8s Secrets.
+// A Secret is created for each identity.
+// The reference is the Secret name.
+type KubernetesStore struct {
+	// k8s client.
+	Client client.Client
+	// Namespace for secrets.
+	Namespace string
+}
+
+//
+// Kind of store.
+func (r *KubernetesStore) Kind() string {
+	return Kubernetes
+}
+
+//
+// Get the secret by reference.
+func (r *KubernetesStore) Get(ref string) (secret *Secret, err error) {
+	object := &core.Secret{}
+	err = r.Client.Get(
+		context.TODO(),
+		client.ObjectKey{
+			Namespace: r.Namespace,
+			Name:      ref,
+		},
+		object)
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			err = ErrNotFound
+		}
+		return
+	}
+	secret = &Secret{
+		User:     string(object.Data[KeyUser]),
+		Password: string(object.Data[KeyPassword]),
+		Key:      string(object.Data[KeyKey]),
+		Settings: string(object.Data[KeySettings]),
+	}
+	return
+}
+
+//
+// Put the secret.
+func (r *KubernetesStore) Put(ref string, secret *Secret) (updated string, err error) {
+	data := map[string][]byte{
+		KeyUser:     []byte(secret.User),
+		KeyPassword: []byte(secret.Password),
+		KeyKey:      []byte(secret.Key),
+		KeySettings: []byte(secret.Settings),
+	}
+	if ref == "" {
+		object := &core.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:    r.Namespace,
+				GenerateName: "identity-",
+				Labels: map[string]string{
+					"app":  "tackle-hub",
+					"role": "identity",
+				},
+			},
+			Type: core.SecretTypeOpaque,
+			Data: data,
+		}
+		err = r.Client.Create(context.TODO(), object)
+		if err != nil {
+			return
+		}
+		updated = object.Name
+		return
+	}
+	object := &core.Secret{}
+	err = r.Client.Get(
+		context.TODO(),
+		client.ObjectKey{
+			Namespace: r.Namespace,
+			Name:      ref,
+		},
+		object)
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			err = ErrNotFound
+		}
+		return
+	}
+	object.Data = data
+	err = r.Client.Update(context.TODO(), object)
+	if err != nil {
+		return
+	}
+	updated = ref
+	return
+}
+
+//
+// Delete the secret.
+func (r *KubernetesStore) Delete(ref string) (err error) {
+	object := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: r.Namespace,
+			Name:      ref,
+		},
+	}
+	err = r.Client.Delete(context.TODO(), object)
+	if k8serr.IsNotFound(err) {
+		err = nil
+	}
+	return
+}
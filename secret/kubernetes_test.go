@@ -0,0 +1,63 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/onsi/gomega"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestKubernetesStore(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &testClient{Client: fake.NewFakeClient()}
+	store := &KubernetesStore{
+		Client:    client,
+		Namespace: "konveyor",
+	}
+	testStore(t, store)
+	//
+	// Secret.
+	ref, err := store.Put("", &Secret{User: "admin", Password: "secret"})
+	g.Expect(err).To(gomega.BeNil())
+	object := &core.Secret{}
+	err = client.Get(
+		context.TODO(),
+		k8s.ObjectKey{
+			Namespace: store.Namespace,
+			Name:      ref,
+		},
+		object)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(object.Labels["role"]).To(gomega.Equal("identity"))
+	g.Expect(string(object.Data[KeyUser])).To(gomega.Equal("admin"))
+	g.Expect(string(object.Data[KeyPassword])).To(gomega.Equal("secret"))
+	//
+	// Update (not found).
+	_, err = store.Put("missing", &Secret{})
+	g.Expect(errors.Is(err, ErrNotFound)).To(gomega.BeTrue())
+}
+
+//
+// testClient (fake) client which generates names.
+type testClient struct {
+	k8s.Client
+}
+
+//
+// Create the object.
+// The name is generated (as by the API server).
+func (r *testClient) Create(ctx context.Context, object runtime.Object) (err error) {
+	if secret, cast := object.(*core.Secret); cast {
+		if secret.Name == "" && secret.GenerateName != "" {
+			secret.Name = secret.GenerateName + uuid.New().String()[:5]
+		}
+	}
+	err = r.Client.Create(ctx, object)
+	return
+}
@@ -0,0 +1,68 @@
+package secret
+
+import (
+	"errors"
+	"strings"
+)
+
+//
+// Store kinds.
+const (
+	DB         = "db"
+	File       = "file"
+	Kubernetes = "kubernetes"
+)
+
+//
+// Errors.
+var (
+	ErrNotFound = errors.New("secret: not found")
+	ErrStore    = errors.New("secret: reference not managed by the store")
+)
+
+//
+// Secret (credentials) material.
+type Secret struct {
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Settings string `json:"settings,omitempty"`
+}
+
+//
+// Store of secret material.
+// The secret is referenced by a (store defined) reference.
+type Store interface {
+	// Kind of store.
+	Kind() string
+	// Get the secret by reference.
+	Get(ref string) (secret *Secret, err error)
+	// Put (create|update) the secret.
+	// An empty reference creates the secret.
+	// Returns the (updated) reference.
+	Put(ref string, secret *Secret) (updated string, err error)
+	// Delete the secret by reference.
+	Delete(ref string) (err error)
+}
+
+//
+// Ref returns a qualified reference.
+// Format: <kind>:<ref>.
+func Ref(store Store, ref string) (qualified string) {
+	qualified = store.Kind() + ":" + ref
+	return
+}
+
+//
+// Parse a qualified reference.
+// Returns the store kind and the (unqualified) reference.
+func Parse(qualified string) (kind, ref string, err error) {
+	part := strings.SplitN(qualified, ":", 2)
+	if len(part) != 2 {
+		err = ErrStore
+		return
+	}
+	kind = part[0]
+	ref = part[1]
+	return
+}
@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"errors"
+	"github.com/konveyor/tackle2-hub/encryption"
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestRef(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := &FileStore{}
+	qualified := Ref(store, "a:b")
+	g.Expect(qualified).To(gomega.Equal("file:a:b"))
+	kind, ref, err := Parse(qualified)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(kind).To(gomega.Equal(File))
+	g.Expect(ref).To(gomega.Equal("a:b"))
+	_, _, err = Parse("invalid")
+	g.Expect(errors.Is(err, ErrStore)).To(gomega.BeTrue())
+}
+
+func TestDBStore(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := &DBStore{Keyring: testKeyring()}
+	secret := &Secret{User: "admin", Password: "secret"}
+	ref, err := store.Put("", secret)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(ref).ToNot(gomega.ContainSubstring("secret"))
+	got, err := store.Get(ref)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(got).To(gomega.Equal(secret))
+	got, err = store.Get("")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(got).To(gomega.Equal(&Secret{}))
+}
+
+//
+// testStore tests the (external) store contract.
+func testStore(t *testing.T, store Store) {
+	g := gomega.NewGomegaWithT(t)
+
+	//
+	// Created.
+	secret := &Secret{User: "admin", Password: "secret"}
+	ref, err := store.Put("", secret)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(ref).ToNot(gomega.BeEmpty())
+	other, err := store.Put("", &Secret{Key: "key"})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(other).ToNot(gomega.Equal(ref))
+	got, err := store.Get(ref)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(got).To(gomega.Equal(secret))
+	//
+	// Updated.
+	secret = &Secret{User: "admin", Settings: "<settings/>"}
+	updated, err := store.Put(ref, secret)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(updated).To(gomega.Equal(ref))
+	got, err = store.Get(ref)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(got).To(gomega.Equal(secret))
+	got, err = store.Get(other)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(got).To(gomega.Equal(&Secret{Key: "key"}))
+	//
+	// Deleted.
+	err = store.Delete(ref)
+	g.Expect(err).To(gomega.BeNil())
+	_, err = store.Get(ref)
+	g.Expect(errors.Is(err, ErrNotFound)).To(gomega.BeTrue())
+	err = store.Delete(ref)
+	g.Expect(err).To(gomega.BeNil())
+}
+
+//
+// testKeyring returns a keyring.
+func testKeyring() (keyring *encryption.Keyring) {
+	keyring = encryption.NewKeyring("1", map[string]string{"1": "test"})
+	return
+}
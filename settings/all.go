@@ -19,3 +19,12 @@ func (r *TackleSettings) Load() (err error) {
 
 	return
 }
+
+//
+// Redacted returns a copy of the settings with
+// the secrets masked. Used for logging.
+func (r *TackleSettings) Redacted() (redacted TackleSettings) {
+	redacted = *r
+	redacted.Hub = r.Hub.Redacted()
+	return
+}
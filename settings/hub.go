@@ -1,34 +1,135 @@
 package settings
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	EnvNamespace  = "NAMESPACE"
-	EnvDbPath     = "DB_PATH"
-	EnvDbSeedPath = "DB_SEED_PATH"
-	EnvBucketPath = "BUCKET_PATH"
-	EnvBucketPVC  = "BUCKET_PVC"
-	EnvPassphrase = "ENCRYPTION_PASSPHRASE"
+	EnvNamespace    = "NAMESPACE"
+	EnvDbDriver     = "DB_DRIVER"
+	EnvDbPath       = "DB_PATH"
+	EnvDbDSN        = "DB_DSN"
+	EnvDbSeedPath   = "DB_SEED_PATH"
+	EnvBucketPath   = "BUCKET_PATH"
+	EnvBucketPVC    = "BUCKET_PVC"
+	EnvUploadLimit  = "BUCKET_UPLOAD_LIMIT"
+	EnvExtractLimit = "BUCKET_EXTRACT_LIMIT"
+	EnvEntryLimit   = "BUCKET_ENTRY_LIMIT"
+	EnvBucketStore  = "BUCKET_STORE"
+	EnvS3Endpoint   = "BUCKET_S3_ENDPOINT"
+	EnvS3Bucket     = "BUCKET_S3_BUCKET"
+	EnvS3Region     = "BUCKET_S3_REGION"
+	EnvS3AccessKey  = "BUCKET_S3_ACCESS_KEY"
+	EnvS3SecretKey  = "BUCKET_S3_SECRET_KEY"
+	EnvS3TLS        = "BUCKET_S3_TLS"
+	EnvBucketQuota  = "BUCKET_QUOTA"
+	EnvAppQuota     = "BUCKET_APP_QUOTA"
+	EnvScanInterval = "BUCKET_SCAN_INTERVAL"
+	EnvOrphanAge    = "BUCKET_ORPHAN_AGE"
+	EnvPassphrase   = "ENCRYPTION_PASSPHRASE"
+	EnvKeyID        = "ENCRYPTION_KEY_ID"
+	EnvPrevious     = "ENCRYPTION_PREVIOUS_KEYS"
+	EnvLegacyKeyID  = "ENCRYPTION_LEGACY_KEY_ID"
+	EnvAuthToken    = "AUTH_TOKEN"
+	EnvSecretStore  = "SECRET_STORE"
+	EnvSecretPath   = "SECRET_PATH"
+	EnvKnownHosts   = "SSH_KNOWN_HOSTS"
+	EnvDevelopment  = "DEVELOPMENT"
 )
 
+const (
+	DefaultPassphrase = "tackle"
+	DefaultKeyID      = "1"
+)
+
+//
+// Masked value of redacted (secret) settings.
+const Masked = "********"
+
 type Hub struct {
 	// k8s namespace.
 	Namespace string
+	// Development mode.
+	Development bool
 	// DB settings.
 	DB struct {
-		Path     string
+		// Driver (sqlite|postgres).
+		Driver string
+		// Path (sqlite) file.
+		Path string
+		// DSN (postgres) data source name.
+		DSN      string
 		SeedPath string
 	}
 	// Bucket settings.
 	Bucket struct {
-		Path string
-		PVC  string
+		// Store kind (filesystem|s3).
+		Store string
+		Path  string
+		PVC   string
+		// S3 (compatible) object store.
+		S3 struct {
+			Endpoint  string
+			Bucket    string
+			Region    string
+			AccessKey string
+			SecretKey string
+			TLS       bool
+		}
+		// Archive limits.
+		Archive struct {
+			// UploadLimit max (uploaded) archive bytes.
+			UploadLimit int64
+			// ExtractLimit max (extracted) content bytes.
+			ExtractLimit int64
+			// EntryLimit max (extracted) entries.
+			EntryLimit int64
+		}
+		// Default quotas (bytes); 0 = unlimited.
+		Quota struct {
+			// Bucket quota.
+			Bucket int64
+			// Application (all buckets) quota.
+			Application int64
+		}
+		// ScanInterval usage scan and reconcile interval.
+		ScanInterval time.Duration
+		// OrphanAge min age of (orphan) roots removed.
+		OrphanAge time.Duration
 	}
 	// Encryption settings.
 	Encryption struct {
+		// Passphrase (current).
 		Passphrase string
+		// KeyID identifies the current passphrase.
+		KeyID string
+		// Previous passphrases keyed by key ID.
+		Previous map[string]string
+		// LegacyKeyID identifies the passphrase used to
+		// encrypt legacy (v1) values.
+		LegacyKeyID string
+	}
+	// Identity secret settings.
+	Secret struct {
+		// Store kind (db|file|kubernetes).
+		Store string
+		// Path (file store).
+		Path string
+	}
+	// SSH settings.
+	SSH struct {
+		// KnownHosts (file) path used to verify host keys.
+		KnownHosts string
+	}
+	// Authorization settings.
+	Auth struct {
+		// Admin token.
+		Token string
 	}
 }
 
@@ -38,10 +139,15 @@ func (r *Hub) Load() (err error) {
 	if err != nil {
 		return
 	}
+	r.DB.Driver, found = os.LookupEnv(EnvDbDriver)
+	if !found {
+		r.DB.Driver = "sqlite"
+	}
 	r.DB.Path, found = os.LookupEnv(EnvDbPath)
 	if !found {
 		r.DB.Path = "/tmp/tackle.db"
 	}
+	r.DB.DSN = os.Getenv(EnvDbDSN)
 	r.DB.SeedPath, found = os.LookupEnv(EnvDbSeedPath)
 	if !found {
 		r.DB.SeedPath = "/tmp/seed"
@@ -54,14 +160,228 @@ func (r *Hub) Load() (err error) {
 	if !found {
 		r.Bucket.PVC = "bucket"
 	}
+	r.Bucket.Store, found = os.LookupEnv(EnvBucketStore)
+	if !found {
+		r.Bucket.Store = "filesystem"
+	}
+	r.Bucket.S3.Endpoint = os.Getenv(EnvS3Endpoint)
+	r.Bucket.S3.Bucket = os.Getenv(EnvS3Bucket)
+	r.Bucket.S3.Region, found = os.LookupEnv(EnvS3Region)
+	if !found {
+		r.Bucket.S3.Region = "us-east-1"
+	}
+	r.Bucket.S3.AccessKey = os.Getenv(EnvS3AccessKey)
+	r.Bucket.S3.SecretKey = os.Getenv(EnvS3SecretKey)
+	if s, found := os.LookupEnv(EnvS3TLS); found {
+		r.Bucket.S3.TLS, err = strconv.ParseBool(s)
+		if err != nil {
+			err = errors.New(EnvS3TLS + ": must be a boolean.")
+			return
+		}
+	}
+	r.Bucket.Archive.UploadLimit, err = r.limit(EnvUploadLimit, 1<<30)
+	if err != nil {
+		return
+	}
+	r.Bucket.Archive.ExtractLimit, err = r.limit(EnvExtractLimit, 4<<30)
+	if err != nil {
+		return
+	}
+	r.Bucket.Archive.EntryLimit, err = r.limit(EnvEntryLimit, 100000)
+	if err != nil {
+		return
+	}
+	r.Bucket.Quota.Bucket, err = r.quota(EnvBucketQuota)
+	if err != nil {
+		return
+	}
+	r.Bucket.Quota.Application, err = r.quota(EnvAppQuota)
+	if err != nil {
+		return
+	}
+	r.Bucket.ScanInterval, err = r.duration(EnvScanInterval, 10*time.Minute)
+	if err != nil {
+		return
+	}
+	r.Bucket.OrphanAge, err = r.duration(EnvOrphanAge, time.Hour)
+	if err != nil {
+		return
+	}
 	r.Encryption.Passphrase, found = os.LookupEnv(EnvPassphrase)
 	if !found {
-		r.Encryption.Passphrase = "tackle"
+		r.Encryption.Passphrase = DefaultPassphrase
+	}
+	r.Encryption.KeyID, found = os.LookupEnv(EnvKeyID)
+	if !found {
+		r.Encryption.KeyID = DefaultKeyID
+	}
+	r.Encryption.LegacyKeyID, found = os.LookupEnv(EnvLegacyKeyID)
+	if !found {
+		r.Encryption.LegacyKeyID = DefaultKeyID
+	}
+	r.Encryption.Previous = make(map[string]string)
+	s, found := os.LookupEnv(EnvPrevious)
+	if found {
+		err = json.Unmarshal([]byte(s), &r.Encryption.Previous)
+		if err != nil {
+			err = errors.New(EnvPrevious + ": must be a JSON object of {keyID: passphrase}.")
+			return
+		}
+	}
+	r.Secret.Store, found = os.LookupEnv(EnvSecretStore)
+	if !found {
+		r.Secret.Store = "db"
 	}
+	r.Secret.Path, found = os.LookupEnv(EnvSecretPath)
+	if !found {
+		r.Secret.Path = "/tmp/identity"
+	}
+	r.SSH.KnownHosts, _ = os.LookupEnv(EnvKnownHosts)
+	s, found = os.LookupEnv(EnvDevelopment)
+	if found {
+		r.Development, _ = strconv.ParseBool(s)
+	}
+	r.Auth.Token, _ = os.LookupEnv(EnvAuthToken)
 
 	return
 }
 
+//
+// Validate the settings.
+// The default passphrase is only permitted in development mode.
+func (r *Hub) Validate() (err error) {
+	if r.Encryption.Passphrase == DefaultPassphrase && !r.Development {
+		err = errors.New(
+			EnvPassphrase + ": default passphrase not permitted (outside development mode).")
+		return
+	}
+	for id := range r.EncryptionKeys() {
+		if id == "" || strings.Contains(id, ":") {
+			err = errors.New(EnvKeyID + ": key ID must not be empty or contain ':'.")
+			return
+		}
+	}
+	if _, found := r.Encryption.Previous[r.Encryption.KeyID]; found {
+		err = errors.New(EnvPrevious + ": must not contain the current key ID.")
+		return
+	}
+	switch r.DB.Driver {
+	case "sqlite":
+	case "postgres":
+		if r.DB.DSN == "" {
+			err = errors.New(EnvDbDSN + ": required by the postgres driver.")
+			return
+		}
+	default:
+		err = errors.New(EnvDbDriver + ": must be (sqlite|postgres).")
+		return
+	}
+	switch r.Secret.Store {
+	case "db", "file", "kubernetes":
+	default:
+		err = errors.New(EnvSecretStore + ": must be (db|file|kubernetes).")
+		return
+	}
+	switch r.Bucket.Store {
+	case "filesystem":
+	case "s3":
+		if r.Bucket.S3.Endpoint == "" || r.Bucket.S3.Bucket == "" {
+			err = errors.New(
+				EnvS3Endpoint + ", " + EnvS3Bucket + ": required by the s3 store.")
+			return
+		}
+	default:
+		err = errors.New(EnvBucketStore + ": must be (filesystem|s3).")
+		return
+	}
+
+	return
+}
+
+//
+// EncryptionKeys returns the current and previous
+// passphrases keyed by key ID.
+func (r *Hub) EncryptionKeys() (keys map[string]string) {
+	keys = make(map[string]string)
+	for id, passphrase := range r.Encryption.Previous {
+		keys[id] = passphrase
+	}
+	keys[r.Encryption.KeyID] = r.Encryption.Passphrase
+	return
+}
+
+//
+// Redacted returns a copy of the settings with the secrets
+// (passphrases, tokens, keys and the DSN) masked.
+func (r *Hub) Redacted() (redacted Hub) {
+	mask := func(s string) string {
+		if s != "" {
+			s = Masked
+		}
+		return s
+	}
+	redacted = *r
+	redacted.DB.DSN = mask(r.DB.DSN)
+	redacted.Bucket.S3.SecretKey = mask(r.Bucket.S3.SecretKey)
+	redacted.Encryption.Passphrase = mask(r.Encryption.Passphrase)
+	redacted.Encryption.Previous = make(map[string]string)
+	for id, passphrase := range r.Encryption.Previous {
+		redacted.Encryption.Previous[id] = mask(passphrase)
+	}
+	redacted.Auth.Token = mask(r.Auth.Token)
+	return
+}
+
+//
+// limit returns the (positive) limit defined by the
+// environment variable or the default.
+func (r *Hub) limit(name string, d int64) (n int64, err error) {
+	n = d
+	s, found := os.LookupEnv(name)
+	if !found {
+		return
+	}
+	n, err = strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 1 {
+		err = errors.New(name + ": must be a positive integer.")
+		return
+	}
+	return
+}
+
+//
+// quota returns the quota defined by the environment
+// variable; 0 (default) = unlimited.
+func (r *Hub) quota(name string) (n int64, err error) {
+	s, found := os.LookupEnv(name)
+	if !found {
+		return
+	}
+	n, err = strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		err = errors.New(name + ": must be a non-negative integer.")
+		return
+	}
+	return
+}
+
+//
+// duration returns the (positive) duration defined by the
+// environment variable or the default.
+func (r *Hub) duration(name string, d time.Duration) (n time.Duration, err error) {
+	n = d
+	s, found := os.LookupEnv(name)
+	if !found {
+		return
+	}
+	n, err = time.ParseDuration(s)
+	if err != nil || n <= 0 {
+		err = errors.New(name + ": must be a positive duration.")
+		return
+	}
+	return
+}
+
 //
 // namespace determines the namespace.
 func (r *Hub) namespace() (ns string, err error) {
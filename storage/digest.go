@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"errors"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//
+// Digest returns the (sha256) digest of the file content.
+// The digest is cached until the file is modified.
+func (m *Manager) Digest(b *model.Bucket, entry *bucket.Entry) (digest string, err error) {
+	cached := &model.BucketDigest{}
+	result := m.DB.Where("BucketID", b.ID).Where("Path", entry.Path).Take(cached)
+	switch {
+	case result.Error == nil:
+		if cached.Size == entry.Size && cached.ModTime == entry.ModTime.UnixNano() {
+			digest = cached.Digest
+			return
+		}
+	case !errors.Is(result.Error, gorm.ErrRecordNotFound):
+		err = result.Error
+		return
+	}
+	reader, err := model.Buckets().Get(b.Path, entry.Path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	digest, err = bucket.Sum(reader)
+	if err != nil {
+		return
+	}
+	m.Remember(b, entry, digest)
+	return
+}
+
+//
+// Remember (caches) the digest of the file content.
+func (m *Manager) Remember(b *model.Bucket, entry *bucket.Entry, digest string) {
+	cached := &model.BucketDigest{
+		BucketID: b.ID,
+		Path:     entry.Path,
+		Size:     entry.Size,
+		ModTime:  entry.ModTime.UnixNano(),
+		Digest:   digest,
+	}
+	db := m.DB.Clauses(clause.OnConflict{UpdateAll: true})
+	result := db.Create(cached)
+	if result.Error != nil {
+		log.Error(
+			result.Error,
+			"Bucket digest not cached.",
+			"id",
+			b.ID,
+			"path",
+			entry.Path)
+	}
+}
+
+//
+// Forget the (cached) digests of the content at the path.
+func (m *Manager) Forget(b *model.Bucket, path string) {
+	db := m.DB.Where("BucketID", b.ID)
+	if path != "" {
+		db = db.Where(
+			clause.Or(
+				clause.Eq{Column: "Path", Value: path},
+				clause.Like{Column: "Path", Value: path + "/%"}))
+	}
+	result := db.Delete(&model.BucketDigest{})
+	if result.Error != nil {
+		log.Error(
+			result.Error,
+			"Bucket digest not forgotten.",
+			"id",
+			b.ID,
+			"path",
+			path)
+	}
+}
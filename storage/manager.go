@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"github.com/konveyor/controller/pkg/logging"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/konveyor/tackle2-hub/settings"
+	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	Settings = &settings.Settings
+	log      = logging.WithName("storage")
+)
+
+//
+// Manager for bucket storage.
+// The storage is periodically reconciled and the bucket
+// usage measured.
+type Manager struct {
+	// DB
+	DB *gorm.DB
+}
+
+//
+// Run the manager.
+func (m *Manager) Run(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				time.Sleep(Settings.Hub.Bucket.ScanInterval)
+				_, err := m.Reconcile(false)
+				if err != nil {
+					log.Error(err, "Bucket reconcile failed.")
+				}
+				_ = m.measure()
+			}
+		}
+	}()
+}
+
+//
+// measure the content of each bucket and update the
+// tracked usage.
+func (m *Manager) measure() (err error) {
+	list := []model.Bucket{}
+	result := m.DB.Find(&list)
+	if result.Error != nil {
+		err = result.Error
+		log.Error(err, "Bucket scan failed.")
+		return
+	}
+	store := model.Buckets()
+	for i := range list {
+		b := &list[i]
+		usage, mErr := bucket.Measure(store, b.Path)
+		if mErr == nil {
+			b.Bytes = usage.Bytes
+			b.Files = usage.Files
+			result = model.Bookkeeping(m.DB).Model(b).Select("Bytes", "Files").Updates(b)
+			mErr = result.Error
+		}
+		if mErr != nil {
+			log.Error(
+				mErr,
+				"Bucket usage scan failed.",
+				"id",
+				b.ID)
+		}
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm/clause"
+	pathlib "path"
+	"time"
+)
+
+//
+// Report of bucket reconciliation.
+type Report struct {
+	// Expired buckets.
+	Expired []model.Bucket
+	// Unowned buckets; the application has been deleted.
+	Unowned []model.Bucket
+	// Orphans roots not referenced by any bucket (content
+	// or objects).
+	Orphans []bucket.Entry
+}
+
+//
+// Reconcile bucket storage.
+// Expired and unowned buckets are deleted and orphan roots
+// (older than the orphan age) are removed. Nothing is deleted
+// or removed when dryRun is true. Roots are matched to buckets
+// by name (uuid) so they are not orphaned when the store path
+// is changed (remounted). As a safeguard, no roots are orphaned
+// when buckets exist but none match a root.
+func (m *Manager) Reconcile(dryRun bool) (report Report, err error) {
+	now := time.Now()
+	result := m.DB.Find(&report.Expired, clause.Lt{Column: "Expiration", Value: now})
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	applications := m.DB.Model(&model.Application{}).Select("ID")
+	result = m.DB.Find(
+		&report.Unowned,
+		"? NOT IN (?)",
+		clause.Column{Name: "ApplicationID"},
+		applications)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	known := make(map[string]bool)
+	var buckets []model.Bucket
+	result = m.DB.Select("Path", "Objects").Find(&buckets)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	for _, b := range buckets {
+		known[pathlib.Base(b.Path)] = true
+		if b.Objects != "" {
+			known[pathlib.Base(b.Objects)] = true
+		}
+	}
+	store := model.Buckets()
+	roots, err := store.Roots()
+	if err != nil {
+		return
+	}
+	matched := false
+	var orphans []bucket.Entry
+	for _, root := range roots {
+		if known[pathlib.Base(root.Path)] {
+			matched = true
+			continue
+		}
+		if now.Sub(root.ModTime) < Settings.Hub.Bucket.OrphanAge {
+			continue
+		}
+		orphans = append(orphans, root)
+	}
+	if len(buckets) > 0 && !matched {
+		if len(orphans) > 0 {
+			log.Info(
+				"No bucket matched a root; orphans not removed.",
+				"buckets",
+				len(buckets),
+				"roots",
+				len(roots))
+		}
+	} else {
+		report.Orphans = orphans
+	}
+	if dryRun {
+		return
+	}
+	deleted := make(map[uint]bool)
+	for _, list := range [][]model.Bucket{report.Expired, report.Unowned} {
+		for i := range list {
+			b := &list[i]
+			if deleted[b.ID] {
+				continue
+			}
+			deleted[b.ID] = true
+			result = m.DB.Delete(b)
+			if result.Error != nil {
+				log.Error(
+					result.Error,
+					"Bucket delete failed.",
+					"id",
+					b.ID)
+				continue
+			}
+			log.Info(
+				"Bucket deleted.",
+				"id",
+				b.ID,
+				"path",
+				b.Path)
+		}
+	}
+	for _, root := range report.Orphans {
+		rErr := store.Remove(root.Path)
+		if rErr != nil {
+			log.Error(
+				rErr,
+				"Orphan bucket remove failed.",
+				"root",
+				root.Path)
+			continue
+		}
+		log.Info(
+			"Orphan bucket removed.",
+			"root",
+			root.Path)
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/database"
+	"github.com/konveyor/tackle2-hub/database/dbtest"
+	"github.com/konveyor/tackle2-hub/migration"
+	"github.com/konveyor/tackle2-hub/model"
+	"github.com/onsi/gomega"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestReconcileOrphans(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	err := Settings.Hub.Load()
+	g.Expect(err).To(gomega.BeNil())
+	if Settings.DB.Driver == database.SQLite {
+		Settings.DB.Path = path.Join(t.TempDir(), "test.db")
+	}
+	Settings.DB.SeedPath = t.TempDir()
+	Settings.Hub.Bucket.OrphanAge = 0
+	db, err := database.Open()
+	g.Expect(err).To(gomega.BeNil())
+	err = dbtest.Reset(db)
+	g.Expect(err).To(gomega.BeNil())
+	err = migration.Migrate(db)
+	g.Expect(err).To(gomega.BeNil())
+	t.Cleanup(func() {
+		_ = dbtest.Reset(db)
+		dbtest.Close(db)
+	})
+	store := &bucket.FSStore{Path: t.TempDir()}
+	model.BucketStore = store
+	t.Cleanup(func() {
+		model.BucketStore = nil
+	})
+	business := &model.BusinessService{Name: "B"}
+	err = db.Create(business).Error
+	g.Expect(err).To(gomega.BeNil())
+	application := &model.Application{Name: "A", BusinessServiceID: business.ID}
+	err = db.Create(application).Error
+	g.Expect(err).To(gomega.BeNil())
+	owned, err := store.Create()
+	g.Expect(err).To(gomega.BeNil())
+	orphan, err := store.Create()
+	g.Expect(err).To(gomega.BeNil())
+	m := &model.Bucket{
+		Name:          "A",
+		Path:          path.Join("/other/mount", path.Base(owned)),
+		ApplicationID: application.ID,
+	}
+	err = db.Create(m).Error
+	g.Expect(err).To(gomega.BeNil())
+	manager := &Manager{DB: db}
+	exists := func(root string) bool {
+		_, err := os.Stat(root)
+		return err == nil
+	}
+	//
+	// Matched by name (the store path changed).
+	report, err := manager.Reconcile(false)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(report.Orphans).To(gomega.HaveLen(1))
+	g.Expect(report.Orphans[0].Path).To(gomega.Equal(orphan))
+	g.Expect(exists(owned)).To(gomega.BeTrue())
+	g.Expect(exists(orphan)).To(gomega.BeFalse())
+	//
+	// No bucket matched; not removed.
+	err = db.Model(m).Update("Path", "/other/mount/unknown").Error
+	g.Expect(err).To(gomega.BeNil())
+	report, err = manager.Reconcile(false)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(report.Orphans).To(gomega.BeEmpty())
+	g.Expect(exists(owned)).To(gomega.BeTrue())
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"encoding/json"
+	"github.com/konveyor/tackle2-hub/bucket"
+	"github.com/konveyor/tackle2-hub/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//
+// Snapshot the bucket content.
+// Creates the next (numbered) version of the bucket. The content
+// is copied into the bucket object root. The (optional) task is
+// the task that produced the content.
+func (m *Manager) Snapshot(b *model.Bucket, taskID *uint) (version *model.BucketVersion, err error) {
+	err = m.objects(b)
+	if err != nil {
+		return
+	}
+	manifest, err := bucket.Snapshot(
+		model.Buckets(),
+		b.Path,
+		b.Objects,
+		func(entry *bucket.Entry) (string, error) {
+			return m.Digest(b, entry)
+		})
+	if err != nil {
+		return
+	}
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return
+	}
+	version = &model.BucketVersion{
+		BucketID: b.ID,
+		Digest:   manifest.Digest(),
+		Bytes:    manifest.Bytes(),
+		Files:    int64(len(manifest)),
+		Manifest: content,
+		TaskID:   taskID,
+	}
+	err = m.DB.Transaction(func(tx *gorm.DB) (err error) {
+		var last uint
+		db := tx.Model(&model.BucketVersion{})
+		db = db.Select("COALESCE(MAX(?), 0)", clause.Column{Name: "Version"})
+		db = db.Where("BucketID", b.ID)
+		result := db.Scan(&last)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		version.Version = last + 1
+		result = tx.Create(version)
+		err = result.Error
+		return
+	})
+	if err != nil {
+		return
+	}
+	log.Info(
+		"Bucket snapshot created.",
+		"id",
+		b.ID,
+		"version",
+		version.Version)
+	return
+}
+
+//
+// Manifest returns the manifest of the version.
+func (m *Manager) Manifest(version *model.BucketVersion) (manifest bucket.Manifest, err error) {
+	manifest = bucket.Manifest{}
+	if len(version.Manifest) > 0 {
+		err = json.Unmarshal(version.Manifest, &manifest)
+	}
+	return
+}
+
+//
+// objects ensures the bucket object root.
+func (m *Manager) objects(b *model.Bucket) (err error) {
+	if b.Objects != "" {
+		return
+	}
+	store := model.Buckets()
+	root, err := store.Create()
+	if err != nil {
+		return
+	}
+	db := model.Bookkeeping(m.DB).Model(&model.Bucket{})
+	db = db.Where("ID", b.ID).Where("Objects", "")
+	result := db.Update("Objects", root)
+	if result.Error != nil || result.RowsAffected == 0 {
+		_ = store.Remove(root)
+		err = result.Error
+		if err == nil {
+			result = m.DB.Select("Objects").First(b, b.ID)
+			err = result.Error
+		}
+		return
+	}
+	b.Objects = root
+	return
+}
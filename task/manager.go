@@ -2,10 +2,15 @@ package task
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"github.com/konveyor/controller/pkg/logging"
+	"github.com/konveyor/tackle2-hub/bucket"
 	crd "github.com/konveyor/tackle2-hub/k8s/api/tackle/v1alpha1"
 	"github.com/konveyor/tackle2-hub/model"
 	"github.com/konveyor/tackle2-hub/settings"
+	"github.com/konveyor/tackle2-hub/storage"
 	"gorm.io/gorm"
 	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
@@ -26,7 +31,10 @@ const (
 	Postponed = "Postponed"
 )
 
-var Settings = &settings.Settings
+var (
+	Settings = &settings.Settings
+	log      = logging.WithName("task")
+)
 
 //
 // Manager provides task management.
@@ -60,7 +68,7 @@ func (m *Manager) startPending() (err error) {
 	list := []model.Task{}
 	result := m.DB.Find(
 		&list,
-		"status IN ?",
+		"Status",
 		[]string{
 			Pending,
 			Running,
@@ -81,11 +89,11 @@ func (m *Manager) startPending() (err error) {
 			Postponed:
 			if m.postpone(pending, list) {
 				pending.Status = Postponed
-				_ = m.DB.Save(pending)
+				_ = model.Bookkeeping(m.DB).Save(pending)
 				continue
 			}
 			_ = task.Run()
-			_ = m.DB.Save(pending)
+			_ = model.Bookkeeping(m.DB).Save(pending)
 		}
 	}
 
@@ -96,7 +104,7 @@ func (m *Manager) startPending() (err error) {
 // updateRunning tasks to reflect job status.
 func (m *Manager) updateRunning() (err error) {
 	list := []model.Task{}
-	result := m.DB.Find(&list, "status", Running)
+	result := m.DB.Find(&list, "Status", Running)
 	if result.Error != nil {
 		err = result.Error
 		return
@@ -110,12 +118,35 @@ func (m *Manager) updateRunning() (err error) {
 		if err != nil {
 			continue
 		}
-		_ = m.DB.Save(&running)
+		_ = model.Bookkeeping(m.DB).Save(&running)
+		if running.Status == Succeeded && running.BucketID != nil {
+			m.snapshot(&running)
+		}
 	}
 
 	return
 }
 
+//
+// snapshot the bucket of the completed task.
+func (m *Manager) snapshot(task *model.Task) {
+	b := &model.Bucket{}
+	err := m.DB.First(b, *task.BucketID).Error
+	if err == nil {
+		manager := storage.Manager{DB: m.DB}
+		_, err = manager.Snapshot(b, &task.ID)
+	}
+	if err != nil {
+		log.Error(
+			err,
+			"Task bucket snapshot failed.",
+			"task",
+			task.ID,
+			"bucket",
+			*task.BucketID)
+	}
+}
+
 //
 // postpone task based on requested isolation.
 // An isolated task must run by itself and will cause all
@@ -163,7 +194,12 @@ func (r *Task) Run() (err error) {
 		return
 	}
 	r.Image = r.addon.Spec.Image
-	secret := r.secret()
+	token, err := r.token()
+	if err != nil {
+		return
+	}
+	r.Token = model.TokenDigest(token)
+	secret := r.secret(token)
 	err = r.client.Create(context.TODO(), &secret)
 	if err != nil {
 		return
@@ -279,17 +315,21 @@ func (r *Task) template(secret *core.Secret) (template core.PodTemplateSpec) {
 						},
 					},
 				},
-				{
-					Name: "bucket",
-					VolumeSource: core.VolumeSource{
-						PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
-							ClaimName: Settings.Hub.Bucket.PVC,
-						},
-					},
-				},
 			},
 		},
 	}
+	if Settings.Hub.Bucket.Store == bucket.Filesystem {
+		template.Spec.Volumes = append(
+			template.Spec.Volumes,
+			core.Volume{
+				Name: "bucket",
+				VolumeSource: core.VolumeSource{
+					PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+						ClaimName: Settings.Hub.Bucket.PVC,
+					},
+				},
+			})
+	}
 	mounts := r.addon.Spec.Mounts
 	for _, mnt := range mounts {
 		template.Spec.Volumes = append(
@@ -341,11 +381,15 @@ func (r *Task) container() (container core.Container) {
 				Name:      "secret",
 				MountPath: path.Dir(Settings.Addon.Path.Secret),
 			},
-			{
+		},
+	}
+	if Settings.Hub.Bucket.Store == bucket.Filesystem {
+		container.VolumeMounts = append(
+			container.VolumeMounts,
+			core.VolumeMount{
 				Name:      "bucket",
 				MountPath: Settings.Hub.Bucket.Path,
-			},
-		},
+			})
 	}
 
 	return
@@ -353,10 +397,12 @@ func (r *Task) container() (container core.Container) {
 
 //
 // secret builds the job secret.
-func (r *Task) secret() (secret core.Secret) {
+// The (bearer) token is passed to the addon. Only the
+// digest is stored.
+func (r *Task) secret(token string) (secret core.Secret) {
 	data := Secret{}
 	data.Hub.Task = r.Task.ID
-	data.Hub.Encryption.Passphrase = Settings.Encryption.Passphrase
+	data.Hub.Token = token
 	data.Addon = r.Task.Data
 	encoded, _ := json.Marshal(data)
 	secret = core.Secret{
@@ -373,6 +419,19 @@ func (r *Task) secret() (secret core.Secret) {
 	return
 }
 
+//
+// token generates the (random) token used by the addon
+// to authenticate with the hub.
+func (r *Task) token() (token string, err error) {
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+	token = hex.EncodeToString(b)
+	return
+}
+
 //
 // labels builds k8s labels.
 func (r *Task) labels() map[string]string {
@@ -385,11 +444,8 @@ func (r *Task) labels() map[string]string {
 // Secret payload.
 type Secret struct {
 	Hub struct {
-		Token      string
-		Task       uint
-		Encryption struct {
-			Passphrase string
-		}
+		Token string
+		Task  uint
 	}
 	Addon interface{}
 }